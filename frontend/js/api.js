@@ -99,11 +99,16 @@ async function getTopic(id) {
     return apiRequest(`/topics/${id}`);
 }
 
+// 获取相关话题
+async function getRelatedTopics(id) {
+    return apiRequest(`/topics/${id}/related`);
+}
+
 // 创建话题
-async function createTopic(title, content, category) {
+async function createTopic(title, content, category, tags = []) {
     return apiRequest('/topics', {
         method: 'POST',
-        body: JSON.stringify({ title, content, category })
+        body: JSON.stringify({ title, content, category, tags })
     });
 }
 
@@ -129,9 +134,13 @@ async function searchTopics(params = {}) {
     return apiRequest(`/search?${queryString}`);
 }
 
-// 获取搜索建议
-async function getSearchSuggestions(prefix) {
-    return apiRequest(`/search/suggest?prefix=${encodeURIComponent(prefix)}`);
+// 获取搜索建议（热门搜索词 + 标题补全，category 可选）
+async function getSearchSuggestions(prefix, category = '') {
+    const params = new URLSearchParams({ prefix });
+    if (category) {
+        params.set('category', category);
+    }
+    return apiRequest(`/search/suggest?${params.toString()}`);
 }
 
 // 获取分类热门话题
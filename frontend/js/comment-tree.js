@@ -55,7 +55,7 @@ function renderCommentTree(comment, level = 0) {
                 </div>
                 <span class="comment-time">${formatTime(comment.created_at)}</span>
             </div>
-            <div class="comment-content">${escapeHtml(comment.content)}</div>
+            <div class="comment-content">${highlightMentions(comment.content_html || escapeHtml(comment.content), comment.mentions)}</div>
             <div class="comment-actions">
                 ${isLoggedInUser ? `
                     <button class="btn-link reply-comment-btn">💬 回复</button>
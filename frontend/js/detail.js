@@ -23,6 +23,9 @@ document.addEventListener('DOMContentLoaded', () => {
 async function initDetailPage() {
     // 加载话题详情
     await loadTopicDetail();
+
+    // 加载相关话题（不阻塞评论加载）
+    loadRelatedTopics();
     
     // 加载评论列表
     await loadComments(1);
@@ -75,6 +78,29 @@ async function loadTopicDetail() {
     }
 }
 
+// 加载相关话题
+async function loadRelatedTopics() {
+    const section = document.getElementById('related-topics');
+    const list = document.getElementById('related-list');
+    if (!section || !list) return;
+
+    try {
+        const response = await getRelatedTopics(currentTopicId);
+        const topics = response.data || [];
+        if (topics.length === 0) return;
+
+        list.innerHTML = topics.map(topic => `
+            <li class="related-item">
+                <a href="detail.html?id=${topic.id}">${escapeHtml(topic.title)}</a>
+                <span class="related-stats">👍 ${topic.like_count || 0} · 💬 ${topic.comment_count || 0}</span>
+            </li>
+        `).join('');
+        section.style.display = '';
+    } catch (error) {
+        console.error('加载相关话题失败:', error);
+    }
+}
+
 // 渲染话题详情
 function renderTopicDetail(topic) {
     const tagClasses = {
@@ -104,9 +130,12 @@ function renderTopicDetail(topic) {
             </div>
         </div>
         <h2 class="topic-title">${escapeHtml(topic.title)}</h2>
-        <div class="topic-content">${escapeHtml(topic.content).replace(/\n/g, '<br>')}</div>
+        <div class="topic-content">${highlightMentions(topic.content_html || escapeHtml(topic.content).replace(/\n/g, '<br>'), topic.mentions)}</div>
         <div class="topic-footer">
-            <span class="topic-tag">${tagNames[topic.category]}</span>
+            <div class="topic-tags">
+                <span class="topic-tag">${tagNames[topic.category]}</span>
+                ${(topic.tags || []).map(tag => `<span class="topic-label">#${escapeHtml(tag)}</span>`).join('')}
+            </div>
             <div class="topic-stats">
                 <button class="stat-item vote-btn" data-type="like" data-topic-id="${topic.id}">
                     👍 ${topic.like_count || 0}
@@ -350,3 +379,48 @@ function escapeHtml(text) {
     return div.innerHTML;
 }
 
+
+// 高亮内容中的@提及（只处理文本节点，跳过代码和链接，不改动HTML结构）
+function highlightMentions(html, mentions) {
+    if (!mentions || mentions.length === 0) {
+        return html;
+    }
+
+    const users = {};
+    mentions.forEach(m => { users[m.username.toLowerCase()] = m.user_id; });
+
+    const container = document.createElement('div');
+    container.innerHTML = html;
+
+    const walker = document.createTreeWalker(container, NodeFilter.SHOW_TEXT);
+    const textNodes = [];
+    while (walker.nextNode()) {
+        const node = walker.currentNode;
+        if (!node.parentElement.closest('code, pre, a')) {
+            textNodes.push(node);
+        }
+    }
+
+    textNodes.forEach(node => {
+        const parts = node.textContent.split(/(@[\p{L}\p{N}_-]+)/u);
+        if (parts.length === 1) {
+            return;
+        }
+        const fragment = document.createDocumentFragment();
+        parts.forEach(part => {
+            const userId = part.startsWith('@') ? users[part.slice(1).toLowerCase()] : undefined;
+            if (userId) {
+                const span = document.createElement('span');
+                span.className = 'mention';
+                span.dataset.userId = userId;
+                span.textContent = part;
+                fragment.appendChild(span);
+            } else if (part) {
+                fragment.appendChild(document.createTextNode(part));
+            }
+        });
+        node.replaceWith(fragment);
+    });
+
+    return container.innerHTML;
+}
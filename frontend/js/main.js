@@ -77,6 +77,13 @@ function initInteractions() {
                 }
             }
         });
+
+        // 输入时加载搜索建议（防抖）
+        let suggestTimer = null;
+        topicSearchInput.addEventListener('input', () => {
+            clearTimeout(suggestTimer);
+            suggestTimer = setTimeout(() => loadSearchSuggestions(topicSearchInput.value.trim()), 200);
+        });
     }
 
     // 搜索按钮点击事件
@@ -452,6 +459,59 @@ async function loadTopics(sortType = 'hot', page = 1, pageSize = 8) {
     }
 }
 
+// 加载搜索建议到输入框的候选列表（热门搜索词在前，标题补全在后）
+async function loadSearchSuggestions(prefix) {
+    const datalist = document.getElementById('topic-search-suggestions');
+    if (!datalist) return;
+    if (!prefix) {
+        datalist.innerHTML = '';
+        return;
+    }
+
+    try {
+        const response = await getSearchSuggestions(prefix);
+        const data = response.data || {};
+        const values = [...(data.queries || []), ...(data.topics || []).map(topic => topic.title)];
+        datalist.innerHTML = '';
+        [...new Set(values)].forEach(value => {
+            const option = document.createElement('option');
+            option.value = value;
+            datalist.appendChild(option);
+        });
+    } catch (error) {
+        console.error('获取搜索建议失败:', error);
+    }
+}
+
+// 在搜索结果上方显示拼写纠正建议和放宽匹配提示
+function renderSearchNotice(container, searchResult) {
+    if (!searchResult || (!searchResult.suggestion && !searchResult.relaxed)) return;
+
+    const notice = document.createElement('div');
+    notice.className = 'search-notice';
+    if (searchResult.relaxed) {
+        notice.innerHTML = '<span>没有完全匹配的话题，以下是相近的结果</span>';
+    }
+    if (searchResult.suggestion) {
+        const link = document.createElement('a');
+        link.href = '#';
+        link.className = 'search-suggestion';
+        link.textContent = searchResult.suggestion;
+        link.addEventListener('click', async (e) => {
+            e.preventDefault();
+            const input = document.getElementById('topic-search-input');
+            if (input) input.value = searchResult.suggestion;
+            await performSearch(searchResult.suggestion);
+        });
+
+        const text = document.createElement('span');
+        text.textContent = '你是不是要找：';
+        text.appendChild(link);
+        notice.appendChild(text);
+    }
+    container.prepend(notice);
+}
+
 // 执行搜索
 async function performSearch(keyword) {
     const container = document.querySelector('.topics-grid');
@@ -474,7 +534,8 @@ async function performSearch(keyword) {
         const response = await searchTopics({
             keyword: keyword,
             page: currentPage,
-            page_size: pageSize
+            page_size: pageSize,
+            with_comments: true
         });
         
         const searchResult = response.data;
@@ -492,6 +553,7 @@ async function performSearch(keyword) {
                     <div style="font-size: 14px; color: #666; font-weight: 600;">换个关键词试试？</div>
                 </div>
             `;
+            renderSearchNotice(container, searchResult);
             updatePaginationUI();
             return;
         }
@@ -502,6 +564,7 @@ async function performSearch(keyword) {
         
         // 渲染搜索结果
         renderTopics(searchResult.topics, true);
+        renderSearchNotice(container, searchResult);
         
         // 更新分页UI
         updatePaginationUI();
@@ -633,8 +696,9 @@ function createTopicCard(topic) {
                     <span class="post-time">${formatTimeAgo(topic.created_at)}</span>
                 </div>
             </div>
-            <h3 class="topic-title">${escapeHtml(topic.title)}</h3>
-            <p class="topic-excerpt">${escapeHtml(truncateText(topic.content, 120))}</p>
+            <h3 class="topic-title">${topic.highlight_title || escapeHtml(topic.title)}</h3>
+            <p class="topic-excerpt">${topic.snippet !== undefined ? topic.snippet : escapeHtml(truncateText(topic.content, 120))}</p>
+            ${topic.matched_comment ? `<p class="topic-excerpt topic-comment-match">💬 ${topic.matched_comment.snippet}</p>` : ''}
             <div class="topic-footer">
                 <span class="tag ${tagClasses[topic.category]}">${tagNames[topic.category]}</span>
                 <div class="topic-stats">
@@ -698,7 +762,7 @@ function initPagination() {
             if (currentPage > 1) {
                 currentPage--;
                 if (currentSortType === 'search') {
-                    const response = await searchTopics({ keyword: currentSearchKeyword, page: currentPage, page_size: pageSize });
+                    const response = await searchTopics({ keyword: currentSearchKeyword, page: currentPage, page_size: pageSize, with_comments: true });
                     const container = document.querySelector('.topics-grid');
                     container.innerHTML = ''; // 清空
                     renderTopics(response.data.topics, true);
@@ -715,7 +779,7 @@ function initPagination() {
             if (currentPage < totalPages) {
                 currentPage++;
                 if (currentSortType === 'search') {
-                    const response = await searchTopics({ keyword: currentSearchKeyword, page: currentPage, page_size: pageSize });
+                    const response = await searchTopics({ keyword: currentSearchKeyword, page: currentPage, page_size: pageSize, with_comments: true });
                     const container = document.querySelector('.topics-grid');
                     container.innerHTML = ''; // 清空
                     renderTopics(response.data.topics, true);
@@ -37,6 +37,10 @@ function initPostPage() {
         const title = titleInput.value.trim();
         const content = contentInput.value.trim();
         const category = document.querySelector('input[name="category"]:checked').value;
+        const tags = document.getElementById('post-tags').value
+            .split(/[,，]/)
+            .map(tag => tag.trim())
+            .filter(tag => tag);
 
         // 验证
         if (!title) {
@@ -59,9 +63,14 @@ function initPostPage() {
             return;
         }
 
+        if (tags.length > 5) {
+            showMessage('标签最多5个', 'error');
+            return;
+        }
+
         try {
             // 调用创建话题 API
-            const result = await createTopic(title, content, category);
+            const result = await createTopic(title, content, category, tags);
             
             showMessage('话题发布成功！', 'success');
             
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+	"web_app/consumers"
+	"web_app/dao/elasticsearch"
+	"web_app/dao/mysql"
+	"web_app/tasks"
+)
+
+// runCommand 执行运维子命令（如 `web_app replay-dlq -limit 100`、`web_app check-es -repair`），返回false表示不是子命令，正常启动服务
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	switch args[0] {
+	case "replay-dlq":
+		fs := flag.NewFlagSet("replay-dlq", flag.ExitOnError)
+		limit := fs.Int("limit", 0, "最多重放的消息数，0表示全部")
+		_ = fs.Parse(args[1:])
+
+		replayed, err := consumers.ReplayDeadLetters(ctx, *limit)
+		fmt.Printf("已重新投递 %d 条死信消息\n", replayed)
+		if err != nil {
+			fmt.Printf("重放死信消息失败, 错误:%v\n", err)
+			os.Exit(1)
+		}
+	case "check-es":
+		opts := tasks.LoadConsistencyOptions()
+		fs := flag.NewFlagSet("check-es", flag.ExitOnError)
+		fs.BoolVar(&opts.Repair, "repair", false, "修复发现的差异（写入MySQL中的数据，删除多余的文档）")
+		fs.IntVar(&opts.BatchSize, "batch", opts.BatchSize, "每批读取的话题/文档数")
+		fs.DurationVar(&opts.RecheckDelay, "recheck-delay", opts.RecheckDelay, "发现差异后等待多久再确认")
+		_ = fs.Parse(args[1:])
+
+		if err := checkES(ctx, opts); err != nil {
+			fmt.Printf("一致性检查失败, 错误:%v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("未知命令: %s\n可用命令: replay-dlq, check-es\n", args[0])
+		os.Exit(2)
+	}
+	return true
+}
+
+// checkES 连接MySQL和ES执行一次一致性检查并输出结果
+func checkES(ctx context.Context, opts tasks.ConsistencyOptions) error {
+	if err := mysql.Init(); err != nil {
+		return fmt.Errorf("初始化MySQL失败: %w", err)
+	}
+	defer mysql.Close()
+	if err := elasticsearch.Init(); err != nil {
+		return fmt.Errorf("初始化Elasticsearch失败: %w", err)
+	}
+	defer elasticsearch.Close()
+
+	report, err := tasks.CheckESConsistency(ctx, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已扫描 MySQL话题 %d 个，ES文档 %d 个，耗时 %s\n",
+		report.MySQLScanned, report.ESScanned, report.FinishedAt.Sub(report.StartedAt).Round(time.Millisecond))
+	fmt.Printf("缺失: %d %s\n", report.Missing, strings.Join(report.MissingIDs, ","))
+	fmt.Printf("多余: %d %s\n", report.Extra, strings.Join(report.ExtraIDs, ","))
+	fmt.Printf("过期: %d %s\n", report.Stale, strings.Join(report.StaleIDs, ","))
+	if opts.Repair {
+		fmt.Printf("已修复 %d 个文档，%d 个已有更新的变更而跳过\n", report.Repaired, report.Superseded)
+	}
+	return nil
+}
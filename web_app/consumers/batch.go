@@ -0,0 +1,140 @@
+package consumers
+
+import (
+	"sort"
+	"web_app/dao/elasticsearch"
+
+	"go.uber.org/zap"
+)
+
+// changeBatch 合并一批Canal消息中的变更，同一文档只保留最新的一条
+type changeBatch struct {
+	topics         map[int64]*elasticsearch.TopicChange
+	comments       map[int64]*elasticsearch.CommentChange
+	countTopics    map[int64]bool // 评论有变化、需要重新统计评论数的话题
+	relatedChanged map[int64]bool // 相关话题缓存需要失效的话题
+	messages       int            // 合并的消息数
+}
+
+// newChangeBatch 创建空的变更批次
+func newChangeBatch() *changeBatch {
+	return &changeBatch{
+		topics:         make(map[int64]*elasticsearch.TopicChange),
+		comments:       make(map[int64]*elasticsearch.CommentChange),
+		countTopics:    make(map[int64]bool),
+		relatedChanged: make(map[int64]bool),
+	}
+}
+
+// add 将一条Canal消息合并到批次中（跳过DDL和其他表）
+func (b *changeBatch) add(c *ESConsumer, msg *CanalMessage) {
+	b.messages++
+	if msg.IsDdl {
+		return
+	}
+
+	switch msg.Table {
+	case "topics":
+		b.addTopics(c, msg)
+	case "comments":
+		b.addComments(c, msg)
+	}
+}
+
+// addTopics 合并话题变更
+func (b *changeBatch) addTopics(c *ESConsumer, msg *CanalMessage) {
+	for i, data := range msg.Data {
+		change := &elasticsearch.TopicChange{
+			TopicID: c.parseInt64(data["id"]),
+			Version: msg.Es,
+		}
+		if change.TopicID <= 0 {
+			continue
+		}
+
+		switch msg.Type {
+		case "INSERT", "UPDATE":
+			topic, err := c.parseTopicFromData(data)
+			if err != nil {
+				zap.L().Error("解析话题数据失败", zap.Error(err), zap.Int64("topic_id", change.TopicID))
+				continue
+			}
+			change.Topic = topic
+			// 标题/内容/分类/标签变化后相关话题需要重新计算（浏览数、点赞数等变化不影响）
+			if msg.Type == "UPDATE" && i < len(msg.Old) && relatedFieldsChanged(msg.Old[i]) {
+				b.relatedChanged[change.TopicID] = true
+			}
+		case "DELETE":
+			change.Deleted = true
+			b.relatedChanged[change.TopicID] = true
+		default:
+			continue
+		}
+		b.putTopic(change)
+	}
+}
+
+// addComments 合并评论变更，并记录评论数需要重新统计的话题
+func (b *changeBatch) addComments(c *ESConsumer, msg *CanalMessage) {
+	for _, data := range msg.Data {
+		change := &elasticsearch.CommentChange{
+			CommentID: c.parseInt64(data["id"]),
+			Version:   msg.Es,
+		}
+		if change.CommentID <= 0 {
+			continue
+		}
+
+		switch msg.Type {
+		case "INSERT", "UPDATE":
+			change.Comment = c.parseCommentFromData(data)
+		case "DELETE":
+			change.Deleted = true
+		default:
+			continue
+		}
+		b.putComment(change)
+
+		if topicID := c.parseInt64(data["topic_id"]); topicID > 0 {
+			b.countTopics[topicID] = true
+		}
+	}
+}
+
+// putTopic 保留版本号更大的变更，版本号相同时后到的消息覆盖先到的（同一毫秒内按binlog顺序）
+func (b *changeBatch) putTopic(change *elasticsearch.TopicChange) {
+	if prev, ok := b.topics[change.TopicID]; ok && prev.Version > change.Version {
+		return
+	}
+	b.topics[change.TopicID] = change
+}
+
+// putComment 保留版本号更大的变更，规则同 putTopic
+func (b *changeBatch) putComment(change *elasticsearch.CommentChange) {
+	if prev, ok := b.comments[change.CommentID]; ok && prev.Version > change.Version {
+		return
+	}
+	b.comments[change.CommentID] = change
+}
+
+// empty 批次中是否没有需要写入的变更
+func (b *changeBatch) empty() bool {
+	return len(b.topics) == 0 && len(b.comments) == 0 && len(b.countTopics) == 0
+}
+
+// changeSet 按文档ID排序生成变更集合（评论数由调用方填充）
+func (b *changeBatch) changeSet() *elasticsearch.ChangeSet {
+	changes := &elasticsearch.ChangeSet{
+		Topics:   make([]*elasticsearch.TopicChange, 0, len(b.topics)),
+		Comments: make([]*elasticsearch.CommentChange, 0, len(b.comments)),
+	}
+	for _, change := range b.topics {
+		changes.Topics = append(changes.Topics, change)
+	}
+	for _, change := range b.comments {
+		changes.Comments = append(changes.Comments, change)
+	}
+	sort.Slice(changes.Topics, func(i, j int) bool { return changes.Topics[i].TopicID < changes.Topics[j].TopicID })
+	sort.Slice(changes.Comments, func(i, j int) bool { return changes.Comments[i].CommentID < changes.Comments[j].CommentID })
+	return changes
+}
@@ -0,0 +1,95 @@
+package consumers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func topicMessage(typ string, es int64, data map[string]interface{}, old map[string]interface{}) *CanalMessage {
+	msg := &CanalMessage{Type: typ, Table: "topics", Es: es, Data: []map[string]interface{}{data}}
+	if old != nil {
+		msg.Old = []map[string]interface{}{old}
+	}
+	return msg
+}
+
+// TestChangeBatch_CoalesceTopic 测试同一话题的多次更新只保留最新版本
+func TestChangeBatch_CoalesceTopic(t *testing.T) {
+	c := &ESConsumer{}
+	b := newChangeBatch()
+	b.add(c, topicMessage("INSERT", 100, map[string]interface{}{"id": "1", "title": "v1"}, nil))
+	b.add(c, topicMessage("UPDATE", 300, map[string]interface{}{"id": "1", "title": "v3"}, map[string]interface{}{"title": "v2"}))
+	// 乱序到达的旧消息不覆盖新变更
+	b.add(c, topicMessage("UPDATE", 200, map[string]interface{}{"id": "1", "title": "v2"}, map[string]interface{}{"view_count": "1"}))
+	// 同一毫秒内后到的消息覆盖先到的
+	b.add(c, topicMessage("UPDATE", 300, map[string]interface{}{"id": "1", "title": "v4"}, map[string]interface{}{"view_count": "2"}))
+
+	changes := b.changeSet()
+	require.Len(t, changes.Topics, 1)
+	assert.EqualValues(t, 300, changes.Topics[0].Version)
+	assert.Equal(t, "v4", changes.Topics[0].Topic.Title)
+	assert.False(t, changes.Topics[0].Deleted)
+	assert.Equal(t, 4, b.messages)
+	assert.True(t, b.relatedChanged[1])
+}
+
+// TestChangeBatch_DeleteAfterUpdate 测试更新后删除的话题只保留删除
+func TestChangeBatch_DeleteAfterUpdate(t *testing.T) {
+	c := &ESConsumer{}
+	b := newChangeBatch()
+	b.add(c, topicMessage("UPDATE", 100, map[string]interface{}{"id": "2", "title": "a"}, map[string]interface{}{"view_count": "1"}))
+	b.add(c, topicMessage("DELETE", 150, map[string]interface{}{"id": "2"}, nil))
+
+	changes := b.changeSet()
+	require.Len(t, changes.Topics, 1)
+	assert.True(t, changes.Topics[0].Deleted)
+	assert.Nil(t, changes.Topics[0].Topic)
+	assert.True(t, b.relatedChanged[2])
+}
+
+// TestChangeBatch_RelatedOnlyForContentFields 测试只有标题/内容等字段变化才失效相关话题缓存
+func TestChangeBatch_RelatedOnlyForContentFields(t *testing.T) {
+	c := &ESConsumer{}
+	b := newChangeBatch()
+	b.add(c, topicMessage("UPDATE", 100, map[string]interface{}{"id": "3"}, map[string]interface{}{"like_count": "5"}))
+	b.add(c, topicMessage("INSERT", 100, map[string]interface{}{"id": "4"}, nil))
+
+	assert.Empty(t, b.relatedChanged)
+	assert.Len(t, b.changeSet().Topics, 2)
+}
+
+// TestChangeBatch_Comments 测试评论变更合并并记录需要重新统计评论数的话题
+func TestChangeBatch_Comments(t *testing.T) {
+	c := &ESConsumer{}
+	b := newChangeBatch()
+	b.add(c, &CanalMessage{Type: "INSERT", Table: "comments", Es: 100, Data: []map[string]interface{}{
+		{"id": "12", "topic_id": "1", "content": "b"},
+		{"id": "11", "topic_id": "1", "content": "a"},
+	}})
+	b.add(c, &CanalMessage{Type: "DELETE", Table: "comments", Es: 200, Data: []map[string]interface{}{
+		{"id": "12", "topic_id": "1"},
+		{"id": "13", "topic_id": "2"},
+	}})
+
+	changes := b.changeSet()
+	require.Len(t, changes.Comments, 3)
+	assert.EqualValues(t, 11, changes.Comments[0].CommentID)
+	assert.Equal(t, "a", changes.Comments[0].Comment.Content)
+	assert.True(t, changes.Comments[1].Deleted)
+	assert.EqualValues(t, 200, changes.Comments[1].Version)
+	assert.Equal(t, map[int64]bool{1: true, 2: true}, b.countTopics)
+	assert.Empty(t, changes.Topics)
+}
+
+// TestChangeBatch_SkipIgnored 测试跳过DDL和其他表
+func TestChangeBatch_SkipIgnored(t *testing.T) {
+	c := &ESConsumer{}
+	b := newChangeBatch()
+	b.add(c, &CanalMessage{Type: "ALTER", Table: "topics", IsDdl: true})
+	b.add(c, &CanalMessage{Type: "INSERT", Table: "users", Data: []map[string]interface{}{{"id": "1"}}})
+
+	assert.True(t, b.empty())
+	assert.Equal(t, 2, b.messages)
+}
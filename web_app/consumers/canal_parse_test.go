@@ -0,0 +1,220 @@
+package consumers
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"web_app/dao/elasticsearch"
+	"web_app/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cst 测试数据库所在时区（testdata 中的消息来自 Asia/Shanghai 的MySQL）
+var cst = time.FixedZone("CST", 8*3600)
+
+// loadCanalFixture 读取 testdata 中的Canal flatMessage
+func loadCanalFixture(t *testing.T, name string) *CanalMessage {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	var msg CanalMessage
+	require.NoError(t, json.Unmarshal(data, &msg))
+	return &msg
+}
+
+// TestParseTopicFromData 测试Canal话题数据到模型的完整映射
+func TestParseTopicFromData(t *testing.T) {
+	base := models.Topic{
+		ID:          1747052461824102400,
+		UserID:      1747049122318766080,
+		Title:       "Go 语言并发模式总结",
+		Content:     "## channel\n使用 `select` 处理超时",
+		ContentHTML: "<h2>channel</h2>\n<p>使用 <code>select</code> 处理超时</p>",
+		Category:    "tech",
+		Tags:        models.TagList{"go", "并发"},
+		CreatedAt:   time.Date(2024, 1, 15, 10, 30, 0, 0, cst),
+	}
+
+	tests := []struct {
+		name    string
+		fixture string
+		want    func() models.Topic
+	}{
+		{
+			name:    "新增话题",
+			fixture: "topic_insert.json",
+			want: func() models.Topic {
+				topic := base
+				topic.UpdatedAt = time.Date(2024, 1, 15, 10, 30, 0, 0, cst)
+				return topic
+			},
+		},
+		{
+			name:    "点赞点踩和置顶更新",
+			fixture: "topic_update_votes.json",
+			want: func() models.Topic {
+				topic := base
+				topic.LikeCount = 12
+				topic.DislikeCount = 3
+				topic.CommentCount = 5
+				topic.ViewCount = 240
+				topic.IsPinned = true
+				topic.PinScope = "category"
+				topic.UpdatedAt = time.Date(2024, 1, 16, 8, 5, 42, 0, cst)
+				return topic
+			},
+		},
+		{
+			name:    "删除话题",
+			fixture: "topic_delete.json",
+			want: func() models.Topic {
+				topic := base
+				topic.LikeCount = 12
+				topic.DislikeCount = 3
+				topic.CommentCount = 5
+				topic.ViewCount = 240
+				topic.IsPinned = true
+				topic.PinScope = "category"
+				topic.IsLocked = true
+				topic.UpdatedAt = time.Date(2024, 1, 20, 21, 0, 0, 0, cst)
+				return topic
+			},
+		},
+	}
+
+	c := &ESConsumer{location: cst}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := loadCanalFixture(t, tt.fixture)
+			require.Len(t, msg.Data, 1)
+
+			topic, err := c.parseTopicFromData(msg.Data[0])
+			require.NoError(t, err)
+			want := tt.want()
+			// 时间按数据库时区解释，比较时刻而不是时区名
+			assert.True(t, want.CreatedAt.Equal(topic.CreatedAt), "created_at: %v", topic.CreatedAt)
+			assert.True(t, want.UpdatedAt.Equal(topic.UpdatedAt), "updated_at: %v", topic.UpdatedAt)
+			want.CreatedAt, want.UpdatedAt = topic.CreatedAt, topic.UpdatedAt
+			assert.Equal(t, want, *topic)
+			// binlog执行时间与 updated_at 是同一时刻
+			assert.True(t, time.UnixMilli(msg.Es).Equal(topic.UpdatedAt))
+		})
+	}
+}
+
+// TestParseTopicFromData_InvalidID 测试缺少ID的数据返回错误
+func TestParseTopicFromData_InvalidID(t *testing.T) {
+	c := &ESConsumer{}
+	_, err := c.parseTopicFromData(map[string]interface{}{"title": "a"})
+	assert.Error(t, err)
+}
+
+// TestParseCommentFromData 测试Canal评论数据映射（parent_id 可为空）
+func TestParseCommentFromData(t *testing.T) {
+	msg := loadCanalFixture(t, "comment_insert.json")
+	require.Len(t, msg.Data, 2)
+	c := &ESConsumer{location: cst}
+
+	tests := []struct {
+		name      string
+		data      map[string]interface{}
+		wantID    int64
+		wantUser  int64
+		parentID  *int64
+		createdAt time.Time
+	}{
+		{"顶层评论", msg.Data[0], 1747060233651507200, 1747049571805548544, nil, time.Date(2024, 1, 15, 11, 0, 53, 0, cst)},
+		{"回复评论", msg.Data[1], 1747060410252677120, 1747049122318766080, int64Ptr(1747060233651507200), time.Date(2024, 1, 15, 11, 1, 35, 0, cst)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comment := c.parseCommentFromData(tt.data)
+			assert.Equal(t, tt.wantID, comment.ID)
+			assert.EqualValues(t, 1747052461824102400, comment.TopicID)
+			assert.Equal(t, tt.wantUser, comment.UserID)
+			assert.Equal(t, tt.parentID, comment.ParentID)
+			assert.True(t, tt.createdAt.Equal(comment.CreatedAt))
+			assert.NotEmpty(t, comment.ContentHTML)
+		})
+	}
+}
+
+func int64Ptr(v int64) *int64 { return &v }
+
+// TestParseTime 测试DATETIME字段按数据库时区解析
+func TestParseTime(t *testing.T) {
+	c := &ESConsumer{location: cst}
+	tests := []struct {
+		name string
+		val  interface{}
+		want time.Time
+	}{
+		{"秒精度", "2024-01-15 10:30:00", time.Date(2024, 1, 15, 2, 30, 0, 0, time.UTC)},
+		{"小数秒", "2024-01-15 10:30:00.125", time.Date(2024, 1, 15, 2, 30, 0, 125000000, time.UTC)},
+		{"NULL", nil, time.Time{}},
+		{"空字符串", "", time.Time{}},
+		{"格式错误", "15/01/2024", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.want.Equal(c.parseTime(tt.val)))
+		})
+	}
+}
+
+// TestChangeBatch_Fixtures 测试真实Canal消息的合并：投票更新不失效相关话题缓存，DDL被跳过
+func TestChangeBatch_Fixtures(t *testing.T) {
+	c := &ESConsumer{location: cst}
+	b := newChangeBatch()
+	for _, name := range []string{"topic_insert.json", "ddl_alter.json", "topic_update_votes.json", "comment_insert.json"} {
+		b.add(c, loadCanalFixture(t, name))
+	}
+
+	changes := b.changeSet()
+	require.Len(t, changes.Topics, 1)
+	assert.EqualValues(t, 1705363542000, changes.Topics[0].Version)
+	assert.Equal(t, 12, changes.Topics[0].Topic.LikeCount)
+	assert.Equal(t, 3, changes.Topics[0].Topic.DislikeCount)
+	assert.Empty(t, b.relatedChanged)
+	assert.Len(t, changes.Comments, 2)
+	assert.Equal(t, map[int64]bool{1747052461824102400: true}, b.countTopics)
+
+	b.add(c, loadCanalFixture(t, "topic_delete.json"))
+	changes = b.changeSet()
+	assert.True(t, changes.Topics[0].Deleted)
+	assert.True(t, b.relatedChanged[1747052461824102400])
+}
+
+// TestFillUsernames 测试补充话题作者用户名
+func TestFillUsernames(t *testing.T) {
+	var lookups [][]int64
+	c := &ESConsumer{usernames: newUsernameCache(time.Minute, 10, func(ids []int64) (map[int64]string, error) {
+		lookups = append(lookups, ids)
+		return map[int64]string{1: "alice", 2: "bob"}, nil
+	})}
+	changes := []*elasticsearch.TopicChange{
+		{TopicID: 10, Topic: &models.Topic{ID: 10, UserID: 1}},
+		{TopicID: 11, Topic: &models.Topic{ID: 11, UserID: 2}},
+		{TopicID: 12, Topic: &models.Topic{ID: 12, UserID: 1}},
+		{TopicID: 13, Deleted: true},
+	}
+
+	require.NoError(t, c.fillUsernames(changes))
+	assert.Equal(t, "alice", changes[0].Topic.Username)
+	assert.Equal(t, "bob", changes[1].Topic.Username)
+	assert.Equal(t, "alice", changes[2].Topic.Username)
+	assert.Equal(t, [][]int64{{1, 2}}, lookups)
+}
+
+// TestFillUsernames_LookupFailed 测试查询失败时返回错误（整批重试）
+func TestFillUsernames_LookupFailed(t *testing.T) {
+	c := &ESConsumer{usernames: newUsernameCache(time.Minute, 10, func([]int64) (map[int64]string, error) {
+		return nil, errors.New("数据库不可用")
+	})}
+	err := c.fillUsernames([]*elasticsearch.TopicChange{{TopicID: 1, Topic: &models.Topic{ID: 1, UserID: 1}}})
+	assert.Error(t, err)
+}
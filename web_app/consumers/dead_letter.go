@@ -0,0 +1,162 @@
+package consumers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 死信消息头，记录消息来源和失败原因
+const (
+	dlqHeaderTopic     = "x-original-topic"
+	dlqHeaderPartition = "x-original-partition"
+	dlqHeaderOffset    = "x-original-offset"
+	dlqHeaderError     = "x-error"
+	dlqHeaderFailedAt  = "x-failed-at"
+)
+
+const (
+	// dlqReplayIdleTimeout 重放死信时超过该时间没有读到新消息即认为已重放完
+	dlqReplayIdleTimeout = 5 * time.Second
+	// writeBatchTimeout 写入器凑批的最长等待时间（kafka-go默认1秒，每次同步写入都要等满）
+	writeBatchTimeout = 5 * time.Millisecond
+)
+
+// newKafkaWriter 创建Kafka写入器，topic为空时按消息中的Topic写入
+// 按key分区，保证同一条记录的消息顺序；一次写入多条消息时仍按批发送
+func newKafkaWriter(brokers []string, topic string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: writeBatchTimeout,
+	}
+}
+
+// deadLetter 将无效的消息（无法解析或被ES拒绝）写入死信主题，写入失败时退避重试直到成功
+// 返回false表示ctx已取消（消息不提交，重启后重新处理）
+func (c *ESConsumer) deadLetter(ctx context.Context, msg kafka.Message, cause error) bool {
+	if c.dlq == nil {
+		zap.L().Error("处理消息失败，未配置死信主题，跳过该消息",
+			zap.Error(cause),
+			zap.Int("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset),
+			zap.String("message", string(msg.Value)))
+		return true
+	}
+
+	dead := newDeadLetterMessage(msg, cause, time.Now())
+	for attempt := 0; ; attempt++ {
+		err := c.dlq.WriteMessages(ctx, dead)
+		if err == nil {
+			zap.L().Warn("处理消息失败，已写入死信主题",
+				zap.Error(cause),
+				zap.Int("partition", msg.Partition),
+				zap.Int64("offset", msg.Offset))
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+
+		backoff := retryBackoff(c.retryBackoff, c.retryMaxBackoff, attempt)
+		zap.L().Error("写入死信主题失败，稍后重试",
+			zap.Error(err),
+			zap.Duration("backoff", backoff),
+			zap.Int("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset))
+		if !sleepContext(ctx, backoff) {
+			return false
+		}
+	}
+}
+
+// newDeadLetterMessage 构建死信消息：保留原始key和内容，在消息头中记录来源和失败原因
+func newDeadLetterMessage(msg kafka.Message, cause error, failedAt time.Time) kafka.Message {
+	headers := append([]kafka.Header{}, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: dlqHeaderTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: dlqHeaderPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: dlqHeaderOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		kafka.Header{Key: dlqHeaderError, Value: []byte(cause.Error())},
+		kafka.Header{Key: dlqHeaderFailedAt, Value: []byte(failedAt.Format(time.RFC3339))},
+	)
+	return kafka.Message{Key: msg.Key, Value: msg.Value, Headers: headers}
+}
+
+// messageHeader 获取消息头的值
+func messageHeader(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// ReplayDeadLetters 将死信主题中的消息重新投递到原主题，由消费者再次处理
+// 使用独立的消费者组记录重放进度，limit<=0 时重放全部，返回重放的消息数
+func ReplayDeadLetters(ctx context.Context, limit int) (int, error) {
+	dlqTopic := viper.GetString("kafka.dlq_topic")
+	if dlqTopic == "" {
+		return 0, errors.New("未配置死信主题 kafka.dlq_topic")
+	}
+	brokers := viper.GetStringSlice("kafka.brokers")
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     brokers,
+		Topic:       dlqTopic,
+		GroupID:     viper.GetString("kafka.group_id") + "-dlq-replay",
+		MinBytes:    1,
+		MaxBytes:    10e6, // 10MB
+		StartOffset: kafka.FirstOffset,
+	})
+	defer reader.Close()
+
+	writer := newKafkaWriter(brokers, "")
+	defer writer.Close()
+
+	return replayDeadLetters(ctx, reader, writer, viper.GetString("kafka.topic"), limit, dlqReplayIdleTimeout)
+}
+
+// replayDeadLetters 逐条读取死信消息写回原主题（消息头中没有原主题时写入defaultTopic），写入成功后提交
+// idle时间内没有新消息时结束
+func replayDeadLetters(ctx context.Context, reader messageReader, writer messageWriter, defaultTopic string, limit int, idle time.Duration) (int, error) {
+	replayed := 0
+	for limit <= 0 || replayed < limit {
+		fetchCtx, cancel := context.WithTimeout(ctx, idle)
+		msg, err := reader.FetchMessage(fetchCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+				break
+			}
+			return replayed, err
+		}
+
+		topic := messageHeader(msg, dlqHeaderTopic)
+		if topic == "" {
+			topic = defaultTopic
+		}
+		if err := writer.WriteMessages(ctx, kafka.Message{Topic: topic, Key: msg.Key, Value: msg.Value}); err != nil {
+			return replayed, err
+		}
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			return replayed, err
+		}
+		replayed++
+
+		zap.L().Info("死信消息已重新投递",
+			zap.String("topic", topic),
+			zap.String("original_partition", messageHeader(msg, dlqHeaderPartition)),
+			zap.String("original_offset", messageHeader(msg, dlqHeaderOffset)),
+			zap.String("error", messageHeader(msg, dlqHeaderError)))
+	}
+	return replayed, nil
+}
@@ -4,10 +4,12 @@ package consumers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 	"web_app/dao/elasticsearch"
 	"web_app/dao/mysql"
+	"web_app/dao/redis"
 	"web_app/models"
 
 	"github.com/segmentio/kafka-go"
@@ -29,9 +31,46 @@ type CanalMessage struct {
 	SqlType   map[string]int           `json:"sqlType"`   // SQL字段类型
 }
 
+// dualWriteRefreshInterval 从Redis刷新重建索引双写目标的间隔
+const dualWriteRefreshInterval = 2 * time.Second
+
+// errInvalidMessage 消息本身无法解析，重试也不会成功，直接进入死信主题
+var errInvalidMessage = errors.New("无效的Canal消息")
+
+// isPermanent 消息本身有问题（无法解析，或文档被ES拒绝），重试也不会成功，写入死信主题
+// 其他错误（连接失败、5xx、429、MySQL查询失败等）是基础设施故障，一直重试直到成功，期间不提交offset
+func isPermanent(err error) bool {
+	return errors.Is(err, errInvalidMessage) || errors.Is(err, elasticsearch.ErrRejected)
+}
+
+// messageReader Kafka消息读取接口（*kafka.Reader 实现，测试时替换）
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// messageWriter Kafka消息写入接口（*kafka.Writer 实现，测试时替换）
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
 // ESConsumer ES同步消费者
+// 消息按批合并后写入ES，处理成功（或无效消息写入死信主题）后才提交offset，进程退出时未提交的消息会重新投递
 type ESConsumer struct {
-	reader *kafka.Reader
+	reader            messageReader
+	dlq               messageWriter        // 死信主题写入器，未配置死信主题时为nil
+	handle            func([]byte) error   // 单条消息处理函数
+	handleBatch       func([][]byte) error // 批量消息处理函数
+	batchSize         int                  // 每批最多处理的消息数，<=1 时逐条处理
+	batchWindow       time.Duration        // 读到第一条消息后最多等待多久凑成一批
+	maxRetries        int                  // 连续重试超过该次数后按Error级别记录日志（基础设施故障时一直重试）
+	retryBackoff      time.Duration        // 首次重试间隔，之后按指数增长
+	retryMaxBackoff   time.Duration        // 最长重试间隔
+	targetRefreshedAt time.Time            // 上次刷新双写目标的时间
+	usernames         *usernameCache       // 话题作者用户名缓存
+	location          *time.Location       // DATETIME字段的时区（与MySQL连接的loc一致）
 }
 
 // NewESConsumer 创建ES同步消费者
@@ -41,192 +80,415 @@ func NewESConsumer() *ESConsumer {
 	groupID := viper.GetString("kafka.group_id")
 
 	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:        brokers,
-		Topic:          topic,
-		GroupID:        groupID,
-		MinBytes:       1,
-		MaxBytes:       10e6, // 10MB
-		CommitInterval: time.Second,
-		StartOffset:    kafka.LastOffset,
+		Brokers:     brokers,
+		Topic:       topic,
+		GroupID:     groupID,
+		MinBytes:    1,
+		MaxBytes:    10e6, // 10MB
+		StartOffset: kafka.LastOffset,
 	})
 
-	return &ESConsumer{
-		reader: reader,
+	c := newChangeApplier()
+	c.reader = reader
+	c.batchSize = viper.GetInt("kafka.batch_size")
+	c.batchWindow = viper.GetDuration("kafka.batch_window")
+	c.maxRetries = viper.GetInt("kafka.max_retries")
+	c.retryBackoff = viper.GetDuration("kafka.retry_backoff")
+	c.retryMaxBackoff = viper.GetDuration("kafka.retry_max_backoff")
+	if c.batchSize <= 0 {
+		c.batchSize = 500
+	}
+	if c.batchWindow <= 0 {
+		c.batchWindow = 200 * time.Millisecond
 	}
+	if c.maxRetries < 0 {
+		c.maxRetries = 0
+	}
+	if c.retryBackoff <= 0 {
+		c.retryBackoff = 500 * time.Millisecond
+	}
+	if c.retryMaxBackoff < c.retryBackoff {
+		c.retryMaxBackoff = 10 * time.Second
+	}
+	if dlqTopic := viper.GetString("kafka.dlq_topic"); dlqTopic != "" {
+		c.dlq = newKafkaWriter(brokers, dlqTopic)
+	}
+	return c
 }
 
-// Start 启动消费者
-func (c *ESConsumer) Start() {
-	zap.L().Info("ES同步消费者已启动",
-		zap.Strings("brokers", viper.GetStringSlice("kafka.brokers")),
-		zap.String("topic", viper.GetString("kafka.topic")),
-		zap.String("group_id", viper.GetString("kafka.group_id")))
+// newChangeApplier 创建只负责把Canal格式的变更写入ES的处理器（不读取Kafka，发件箱直接写入模式使用）
+func newChangeApplier() *ESConsumer {
+	c := &ESConsumer{
+		usernames: newUsernameCache(usernameCacheTTL, usernameCacheSize, mysql.GetUsernamesByIDs),
+		location:  time.Local,
+	}
+	c.handle = c.processMessage
+	c.handleBatch = c.processBatch
+	return c
+}
 
+// Run 循环消费消息直到ctx取消
+// 每次读取一批消息合并处理；基础设施故障时按指数退避（有上限）一直重试，不提交offset；
+// 批量处理遇到无效消息时逐条处理，无效消息写入死信主题，然后提交offset
+func (c *ESConsumer) Run(ctx context.Context) {
 	for {
-		msg, err := c.reader.ReadMessage(context.Background())
-		if err != nil {
+		msgs, err := c.fetchBatch(ctx)
+		if len(msgs) == 0 {
+			if ctx.Err() != nil {
+				return
+			}
 			zap.L().Error("读取Kafka消息失败", zap.Error(err))
-			time.Sleep(time.Second)
+			if !sleepContext(ctx, time.Second) {
+				return
+			}
 			continue
 		}
 
-		// 处理消息
-		if err := c.processMessage(msg.Value); err != nil {
-			zap.L().Error("处理消息失败",
+		// 关闭期间中断的消息不提交，重启后重新消费
+		if !c.processFetched(ctx, msgs) {
+			return
+		}
+
+		if err := c.reader.CommitMessages(ctx, msgs...); err != nil {
+			// 提交失败时消息会在重启或再均衡后重新投递，处理是幂等的
+			zap.L().Error("提交Kafka offset失败",
 				zap.Error(err),
-				zap.String("message", string(msg.Value)))
+				zap.Int("count", len(msgs)),
+				zap.Int64("last_offset", msgs[len(msgs)-1].Offset))
 		}
 	}
 }
 
-// processMessage 处理Canal消息
-func (c *ESConsumer) processMessage(data []byte) error {
-	var canalMsg CanalMessage
-	if err := json.Unmarshal(data, &canalMsg); err != nil {
-		return fmt.Errorf("解析Canal消息失败: %w", err)
+// fetchBatch 读取一批消息：读到第一条后在 batchWindow 内继续读取，最多 batchSize 条
+func (c *ESConsumer) fetchBatch(ctx context.Context) ([]kafka.Message, error) {
+	msg, err := c.reader.FetchMessage(ctx)
+	if err != nil {
+		return nil, err
 	}
-
-	// 跳过DDL操作
-	if canalMsg.IsDdl {
-		return nil
+	msgs := []kafka.Message{msg}
+	if c.batchSize <= 1 || c.handleBatch == nil {
+		return msgs, nil
 	}
 
-	// 只处理topics和comments表
-	switch canalMsg.Table {
-	case "topics":
-		return c.handleTopicChange(&canalMsg)
-	case "comments":
-		return c.handleCommentChange(&canalMsg)
-	default:
-		// 忽略其他表
-		return nil
+	windowCtx, cancel := context.WithTimeout(ctx, c.batchWindow)
+	defer cancel()
+	for len(msgs) < c.batchSize {
+		msg, err := c.reader.FetchMessage(windowCtx)
+		if err != nil {
+			// 窗口结束（或读取失败），先处理已读到的消息
+			break
+		}
+		msgs = append(msgs, msg)
 	}
+	return msgs, nil
 }
 
-// handleTopicChange 处理话题变更
-func (c *ESConsumer) handleTopicChange(msg *CanalMessage) error {
-	switch msg.Type {
-	case "INSERT", "UPDATE":
-		// 新增或更新话题
-		for _, data := range msg.Data {
-			topic, err := c.parseTopicFromData(data)
-			if err != nil {
-				zap.L().Error("解析话题数据失败", zap.Error(err))
-				continue
-			}
-
-			// 同步到ES
-			if err := elasticsearch.IndexTopic(topic); err != nil {
-				zap.L().Error("同步话题到ES失败",
-					zap.Error(err),
-					zap.Int64("topic_id", topic.ID))
-				return err
-			}
+// processFetched 处理读到的消息，返回false表示ctx已取消（消息不提交）
+func (c *ESConsumer) processFetched(ctx context.Context, msgs []kafka.Message) bool {
+	start := time.Now()
+	defer func() { esSyncBatchDuration.Observe(time.Since(start).Seconds()) }()
 
-			zap.L().Info("话题已同步到ES",
-				zap.Int64("topic_id", topic.ID),
-				zap.String("title", topic.Title),
-				zap.String("operation", msg.Type))
+	if len(msgs) > 1 {
+		values := make([][]byte, len(msgs))
+		for i, msg := range msgs {
+			values[i] = msg.Value
 		}
+		err := c.withRetry(ctx, func() error { return c.handleBatch(values) },
+			zap.Int("count", len(msgs)),
+			zap.Int64("first_offset", msgs[0].Offset))
+		if err == nil {
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+		// 逐条处理找出无效的消息（已写入的变更带版本号，重复写入不影响结果）
+		zap.L().Warn("批量处理消息失败，改为逐条处理", zap.Error(err), zap.Int("count", len(msgs)))
+	}
 
-	case "DELETE":
-		// 删除话题
-		for _, data := range msg.Data {
-			topicID := c.parseInt64(data["id"])
-			if err := elasticsearch.DeleteTopic(topicID); err != nil {
-				zap.L().Error("从ES删除话题失败",
-					zap.Error(err),
-					zap.Int64("topic_id", topicID))
-				return err
+	for _, msg := range msgs {
+		if err := c.handleWithRetry(ctx, msg); err != nil {
+			if ctx.Err() != nil {
+				return false
+			}
+			recordFailed(msg.Value)
+			if !c.deadLetter(ctx, msg, err) {
+				return false
 			}
+		}
+	}
+	return true
+}
 
-			zap.L().Info("话题已从ES删除",
-				zap.Int64("topic_id", topicID))
+// handleWithRetry 处理单条消息，失败时按指数退避重试（无效消息不重试）
+func (c *ESConsumer) handleWithRetry(ctx context.Context, msg kafka.Message) error {
+	return c.withRetry(ctx, func() error { return c.handle(msg.Value) },
+		zap.Int("partition", msg.Partition),
+		zap.Int64("offset", msg.Offset))
+}
+
+// withRetry 执行处理函数，失败时按指数退避重试直到成功或ctx取消
+// 无效消息（isPermanent）不重试，直接返回错误；其他错误不会丢弃消息，超过 maxRetries 次后改为Error日志
+func (c *ESConsumer) withRetry(ctx context.Context, fn func() error, fields ...zap.Field) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if isPermanent(err) {
+			return err
+		}
+
+		backoff := retryBackoff(c.retryBackoff, c.retryMaxBackoff, attempt)
+		log := zap.L().Warn
+		if attempt >= c.maxRetries {
+			log = zap.L().Error
+		}
+		log("处理消息失败，稍后重试", append([]zap.Field{
+			zap.Error(err),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("backoff", backoff),
+		}, fields...)...)
+		if !sleepContext(ctx, backoff) {
+			return ctx.Err()
 		}
 	}
+}
 
-	return nil
+// retryBackoff 第 attempt 次重试（从0开始）前的等待时间：base * 2^attempt，不超过maxBackoff
+func retryBackoff(base, maxBackoff time.Duration, attempt int) time.Duration {
+	backoff := base
+	for i := 0; i < attempt && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+// sleepContext 等待指定时间，ctx取消时提前返回false
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
 
-// handleCommentChange 处理评论变更（更新话题的评论数）
-func (c *ESConsumer) handleCommentChange(msg *CanalMessage) error {
-	zap.L().Debug("检测到评论变更",
-		zap.String("operation", msg.Type),
-		zap.Int("count", len(msg.Data)))
+// refreshDualWriteTarget 定期从Redis同步重建索引的双写目标（重建任务可能运行在其他实例上）
+func (c *ESConsumer) refreshDualWriteTarget() {
+	if time.Since(c.targetRefreshedAt) < dualWriteRefreshInterval {
+		return
+	}
+	target, err := redis.GetReindexTarget()
+	if err != nil {
+		zap.L().Warn("读取重建索引双写目标失败", zap.Error(err))
+		return
+	}
+	c.targetRefreshedAt = time.Now()
+
+	if target != elasticsearch.DualWriteTarget() {
+		zap.L().Info("重建索引双写目标变更", zap.String("target", target))
+		elasticsearch.SetDualWriteTarget(target)
+	}
+}
 
-	// 收集需要更新的话题ID（使用map去重）
-	topicIDsMap := make(map[int64]bool)
+// processMessage 处理单条Canal消息
+func (c *ESConsumer) processMessage(data []byte) error {
+	return c.processBatch([][]byte{data})
+}
 
-	// 从所有变更的评论中提取topic_id
-	for _, data := range msg.Data {
-		topicID := c.parseInt64(data["topic_id"])
-		if topicID > 0 {
-			topicIDsMap[topicID] = true
+// processBatch 合并一批Canal消息（同一文档只保留最新的变更），用一次bulk请求写入ES
+func (c *ESConsumer) processBatch(values [][]byte) error {
+	batch := newChangeBatch()
+	msgs := make([]*CanalMessage, 0, len(values))
+	for _, data := range values {
+		var canalMsg CanalMessage
+		if err := json.Unmarshal(data, &canalMsg); err != nil {
+			return fmt.Errorf("%w: %v", errInvalidMessage, err)
 		}
+		batch.add(c, &canalMsg)
+		msgs = append(msgs, &canalMsg)
 	}
 
-	// 如果没有有效的topic_id，直接返回
-	if len(topicIDsMap) == 0 {
-		zap.L().Debug("没有需要更新的话题")
-		return nil
+	if !batch.empty() {
+		// 重建索引期间需要同时写入新索引
+		c.refreshDualWriteTarget()
+
+		if err := c.applyBatch(batch); err != nil {
+			return err
+		}
 	}
+	recordProcessed(msgs, time.Now())
+	return nil
+}
 
-	// 对每个话题，查询最新的评论数并更新到ES
-	for topicID := range topicIDsMap {
-		// 1. 从MySQL查询该话题的最新评论数
-		count, err := mysql.CountCommentsByTopicID(topicID)
+// applyBatch 写入合并后的变更：重新统计评论有变化的话题的评论数，删除话题的评论，清除相关话题缓存
+func (c *ESConsumer) applyBatch(batch *changeBatch) error {
+	changes := batch.changeSet()
+
+	if len(batch.countTopics) > 0 {
+		topicIDs := make([]int64, 0, len(batch.countTopics))
+		for topicID := range batch.countTopics {
+			topicIDs = append(topicIDs, topicID)
+		}
+		counts, err := mysql.CountCommentsByTopicIDs(topicIDs)
 		if err != nil {
-			zap.L().Error("查询话题评论数失败",
-				zap.Error(err),
-				zap.Int64("topic_id", topicID))
-			continue // 继续处理其他话题
+			return fmt.Errorf("统计话题评论数失败: %w", err)
 		}
+		changes.CommentCounts = make(map[int64]int, len(counts))
+		for topicID, count := range counts {
+			changes.CommentCounts[topicID] = int(count)
+		}
+	}
 
-		// 2. 更新ES中的话题评论数
-		err = elasticsearch.UpdateTopicCommentCount(topicID, int(count))
-		if err != nil {
-			zap.L().Error("更新ES话题评论数失败",
-				zap.Error(err),
-				zap.Int64("topic_id", topicID),
-				zap.Int64("comment_count", count))
-			continue
+	if err := c.fillUsernames(changes.Topics); err != nil {
+		return err
+	}
+
+	result, err := elasticsearch.ApplyChanges(context.Background(), changes)
+	if err != nil {
+		return err
+	}
+
+	// 评论由外键级联删除，不会产生评论的binlog，需要按话题清理评论索引
+	for _, change := range changes.Topics {
+		if change.Deleted {
+			if err := elasticsearch.DeleteCommentsByTopic(change.TopicID); err != nil {
+				return err
+			}
 		}
+	}
 
-		zap.L().Info("话题评论数已同步到ES",
-			zap.Int64("topic_id", topicID),
-			zap.Int64("comment_count", count),
-			zap.String("operation", msg.Type))
+	// 标题/内容/分类/标签变化或删除后相关话题需要重新计算（浏览数、点赞数等变化不影响）
+	for topicID := range batch.relatedChanged {
+		c.invalidateRelatedTopics(topicID)
 	}
 
+	zap.L().Info("变更已同步到ES",
+		zap.Int("messages", batch.messages),
+		zap.Int("topics", len(changes.Topics)),
+		zap.Int("comments", len(changes.Comments)),
+		zap.Int("comment_counts", len(changes.CommentCounts)),
+		zap.Int("applied", result.Applied),
+		zap.Int("stale", result.Stale))
 	return nil
 }
 
-// parseTopicFromData 从Canal数据解析Topic结构
+// fillUsernames 补充话题的作者用户名（topics表的binlog中没有用户名）
+func (c *ESConsumer) fillUsernames(changes []*elasticsearch.TopicChange) error {
+	if c.usernames == nil {
+		return nil
+	}
+	userIDs := make([]int64, 0, len(changes))
+	for _, change := range changes {
+		if change.Topic != nil && change.Topic.Username == "" {
+			userIDs = append(userIDs, change.Topic.UserID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	usernames, err := c.usernames.resolve(userIDs)
+	if err != nil {
+		return fmt.Errorf("查询话题作者用户名失败: %w", err)
+	}
+	for _, change := range changes {
+		if change.Topic != nil && change.Topic.Username == "" {
+			change.Topic.Username = usernames[change.Topic.UserID]
+		}
+	}
+	return nil
+}
+
+// relatedFields 影响相关话题计算的字段
+var relatedFields = []string{"title", "content", "category", "tags"}
+
+// relatedFieldsChanged 根据UPDATE消息的旧值判断影响相关话题的字段是否变化（old只包含变化的字段）
+func relatedFieldsChanged(old map[string]interface{}) bool {
+	for _, field := range relatedFields {
+		if _, ok := old[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// invalidateRelatedTopics 清除话题的相关话题缓存
+// 其他话题缓存中的该话题在读取时按数据库过滤，删除后不会再返回
+func (c *ESConsumer) invalidateRelatedTopics(topicID int64) {
+	if err := redis.DeleteRelatedTopicsCache(topicID); err != nil {
+		zap.L().Warn("清除相关话题缓存失败", zap.Error(err), zap.Int64("topic_id", topicID))
+	}
+}
+
+// parseTopicFromData 从Canal数据解析Topic结构（用户名不在topics表中，由 fillUsernames 补充）
 func (c *ESConsumer) parseTopicFromData(data map[string]interface{}) (*models.Topic, error) {
 	topic := &models.Topic{
 		ID:           c.parseInt64(data["id"]),
 		UserID:       c.parseInt64(data["user_id"]),
 		Title:        c.parseString(data["title"]),
 		Content:      c.parseString(data["content"]),
+		ContentHTML:  c.parseString(data["content_html"]),
 		Category:     c.parseString(data["category"]),
-		ViewCount:    c.parseInt(data["view_count"]),
+		Tags:         models.ParseTagList(c.parseString(data["tags"])),
+		LikeCount:    c.parseInt(data["like_count"]),
+		DislikeCount: c.parseInt(data["dislike_count"]),
 		CommentCount: c.parseInt(data["comment_count"]),
+		ViewCount:    c.parseInt(data["view_count"]),
+		IsPinned:     c.parseBool(data["is_pinned"]),
+		PinScope:     c.parseString(data["pin_scope"]),
+		IsLocked:     c.parseBool(data["is_locked"]),
+		CreatedAt:    c.parseTime(data["created_at"]),
+		UpdatedAt:    c.parseTime(data["updated_at"]),
 	}
-
-	// 解析时间字段
-	if createdAt := c.parseString(data["created_at"]); createdAt != "" {
-		if t, err := time.Parse("2006-01-02 15:04:05", createdAt); err == nil {
-			topic.CreatedAt = t
-		}
+	if topic.ID <= 0 {
+		return nil, fmt.Errorf("话题ID无效: %v", data["id"])
 	}
+	return topic, nil
+}
 
-	if updatedAt := c.parseString(data["updated_at"]); updatedAt != "" {
-		if t, err := time.Parse("2006-01-02 15:04:05", updatedAt); err == nil {
-			topic.UpdatedAt = t
-		}
+// parseCommentFromData 从Canal数据解析Comment结构
+func (c *ESConsumer) parseCommentFromData(data map[string]interface{}) *models.Comment {
+	comment := &models.Comment{
+		ID:          c.parseInt64(data["id"]),
+		TopicID:     c.parseInt64(data["topic_id"]),
+		UserID:      c.parseInt64(data["user_id"]),
+		Content:     c.parseString(data["content"]),
+		ContentHTML: c.parseString(data["content_html"]),
+		CreatedAt:   c.parseTime(data["created_at"]),
+		UpdatedAt:   c.parseTime(data["updated_at"]),
+	}
+	if parentID := c.parseInt64(data["parent_id"]); parentID > 0 {
+		comment.ParentID = &parentID
 	}
+	return comment
+}
 
-	return topic, nil
+// canalTimeFormat Canal中DATETIME字段的格式（带小数秒的值也能解析）
+const canalTimeFormat = "2006-01-02 15:04:05"
+
+// parseTime 解析DATETIME字段：MySQL DATETIME不带时区，按与数据库连接相同的时区解释，与从MySQL读取的时间一致
+func (c *ESConsumer) parseTime(val interface{}) time.Time {
+	str := c.parseString(val)
+	if str == "" {
+		return time.Time{}
+	}
+	loc := c.location
+	if loc == nil {
+		loc = time.Local
+	}
+	t, err := time.ParseInLocation(canalTimeFormat, str, loc)
+	if err != nil {
+		zap.L().Warn("解析Canal时间字段失败", zap.String("value", str), zap.Error(err))
+		return time.Time{}
+	}
+	return t
 }
 
 // parseInt64 辅助函数：安全地将interface{}转换为int64
@@ -252,6 +514,18 @@ func (c *ESConsumer) parseInt(val interface{}) int {
 	return int(c.parseInt64(val))
 }
 
+// parseBool 辅助函数：安全地将interface{}转换为bool（Canal中TINYINT(1)以"0"/"1"字符串表示）
+func (c *ESConsumer) parseBool(val interface{}) bool {
+	switch v := val.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "1" || v == "true"
+	default:
+		return c.parseInt64(val) != 0
+	}
+}
+
 // parseString 辅助函数：安全地将interface{}转换为string
 func (c *ESConsumer) parseString(val interface{}) string {
 	if val == nil {
@@ -265,14 +539,31 @@ func (c *ESConsumer) parseString(val interface{}) string {
 
 // Close 关闭消费者
 func (c *ESConsumer) Close() error {
+	if c.dlq != nil {
+		if err := c.dlq.Close(); err != nil {
+			zap.L().Warn("关闭死信主题写入器失败", zap.Error(err))
+		}
+	}
 	if c.reader != nil {
 		return c.reader.Close()
 	}
 	return nil
 }
 
-// StartESConsumer 启动ES同步消费者（供main.go调用）
-func StartESConsumer() {
+// RunESConsumer 运行ES同步消费者直到ctx取消（供main.go调用）
+func RunESConsumer(ctx context.Context) error {
 	consumer := NewESConsumer()
-	consumer.Start()
+	defer consumer.Close()
+
+	zap.L().Info("ES同步消费者已启动",
+		zap.Strings("brokers", viper.GetStringSlice("kafka.brokers")),
+		zap.String("topic", viper.GetString("kafka.topic")),
+		zap.String("group_id", viper.GetString("kafka.group_id")),
+		zap.String("dlq_topic", viper.GetString("kafka.dlq_topic")))
+
+	if reader, ok := consumer.reader.(statsReader); ok {
+		go exportReaderStats(ctx, reader, readerStatsInterval)
+	}
+	consumer.Run(ctx)
+	return nil
 }
@@ -0,0 +1,410 @@
+package consumers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"web_app/dao/elasticsearch"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReader 按顺序返回预置消息，读完后取消ctx（模拟关机）
+type fakeReader struct {
+	mu        sync.Mutex
+	messages  []kafka.Message
+	committed []kafka.Message
+	onDrained context.CancelFunc
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	r.mu.Lock()
+	if len(r.messages) > 0 {
+		msg := r.messages[0]
+		r.messages = r.messages[1:]
+		r.mu.Unlock()
+		return msg, nil
+	}
+	r.mu.Unlock()
+
+	if r.onDrained != nil {
+		r.onDrained()
+	}
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (r *fakeReader) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.committed = append(r.committed, msgs...)
+	return nil
+}
+
+func (r *fakeReader) Close() error { return nil }
+
+// fakeWriter 记录写入的消息，前 failures 次写入返回错误
+type fakeWriter struct {
+	mu       sync.Mutex
+	written  []kafka.Message
+	failures int
+}
+
+func (w *fakeWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.failures > 0 {
+		w.failures--
+		return errors.New("broker不可用")
+	}
+	w.written = append(w.written, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) Close() error { return nil }
+
+// newTestConsumer 使用假的读写器和处理函数创建消费者，运行到消息读完为止
+func newTestConsumer(messages []kafka.Message, dlq messageWriter, handle func([]byte) error) (*ESConsumer, *fakeReader, context.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	reader := &fakeReader{messages: messages, onDrained: cancel}
+	c := &ESConsumer{
+		reader:          reader,
+		dlq:             dlq,
+		handle:          handle,
+		maxRetries:      2,
+		retryBackoff:    time.Millisecond,
+		retryMaxBackoff: 4 * time.Millisecond,
+	}
+	return c, reader, ctx
+}
+
+func testMessage(offset int64, value string) kafka.Message {
+	return kafka.Message{Topic: "canal-topic", Partition: 1, Offset: offset, Key: []byte("k"), Value: []byte(value)}
+}
+
+// TestRun_CommitAfterSuccess 测试处理成功后逐条提交offset
+func TestRun_CommitAfterSuccess(t *testing.T) {
+	dlq := &fakeWriter{}
+	var handled []string
+	c, reader, ctx := newTestConsumer(
+		[]kafka.Message{testMessage(1, "a"), testMessage(2, "b")},
+		dlq,
+		func(data []byte) error {
+			handled = append(handled, string(data))
+			return nil
+		},
+	)
+
+	c.Run(ctx)
+
+	assert.Equal(t, []string{"a", "b"}, handled)
+	require.Len(t, reader.committed, 2)
+	assert.EqualValues(t, 1, reader.committed[0].Offset)
+	assert.EqualValues(t, 2, reader.committed[1].Offset)
+	assert.Empty(t, dlq.written)
+}
+
+// TestRun_RetryThenSuccess 测试临时失败重试成功后提交，不写入死信主题
+func TestRun_RetryThenSuccess(t *testing.T) {
+	dlq := &fakeWriter{}
+	attempts := 0
+	c, reader, ctx := newTestConsumer([]kafka.Message{testMessage(1, "a")}, dlq, func([]byte) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("ES暂时不可用")
+		}
+		return nil
+	})
+
+	c.Run(ctx)
+
+	assert.Equal(t, 3, attempts)
+	assert.Len(t, reader.committed, 1)
+	assert.Empty(t, dlq.written)
+}
+
+// TestRun_InfraErrorNotDeadLettered 测试基础设施故障超过 maxRetries 次后继续重试，恢复后提交，不写入死信主题
+func TestRun_InfraErrorNotDeadLettered(t *testing.T) {
+	dlq := &fakeWriter{}
+	attempts := 0
+	c, reader, ctx := newTestConsumer([]kafka.Message{testMessage(1, "a")}, dlq, func([]byte) error {
+		attempts++
+		if attempts <= 6 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	c.Run(ctx)
+
+	assert.Equal(t, 7, attempts)
+	assert.Len(t, reader.committed, 1)
+	assert.Empty(t, dlq.written)
+}
+
+// TestRun_RejectedToDeadLetter 测试被ES拒绝的消息不重试，写入死信主题并提交，后续消息继续处理
+func TestRun_RejectedToDeadLetter(t *testing.T) {
+	dlq := &fakeWriter{}
+	attempts := map[string]int{}
+	c, reader, ctx := newTestConsumer(
+		[]kafka.Message{testMessage(7, "poison"), testMessage(8, "ok")},
+		dlq,
+		func(data []byte) error {
+			attempts[string(data)]++
+			if string(data) == "poison" {
+				return fmt.Errorf("%w: mapping冲突", elasticsearch.ErrRejected)
+			}
+			return nil
+		},
+	)
+
+	c.Run(ctx)
+
+	assert.Equal(t, 1, attempts["poison"])
+	assert.Equal(t, 1, attempts["ok"])
+	assert.Len(t, reader.committed, 2)
+
+	require.Len(t, dlq.written, 1)
+	dead := dlq.written[0]
+	assert.Equal(t, "poison", string(dead.Value))
+	assert.Equal(t, "k", string(dead.Key))
+	assert.Equal(t, "canal-topic", messageHeader(dead, dlqHeaderTopic))
+	assert.Equal(t, "1", messageHeader(dead, dlqHeaderPartition))
+	assert.Equal(t, "7", messageHeader(dead, dlqHeaderOffset))
+	assert.Equal(t, "ES拒绝写入变更: mapping冲突", messageHeader(dead, dlqHeaderError))
+	assert.NotEmpty(t, messageHeader(dead, dlqHeaderFailedAt))
+}
+
+// TestRun_InvalidMessageNotRetried 测试无法解析的消息不重试，直接写入死信主题
+func TestRun_InvalidMessageNotRetried(t *testing.T) {
+	dlq := &fakeWriter{}
+	c, reader, ctx := newTestConsumer([]kafka.Message{testMessage(1, "{bad json")}, dlq, nil)
+	attempts := 0
+	c.handle = func(data []byte) error {
+		attempts++
+		return c.processMessage(data)
+	}
+
+	c.Run(ctx)
+
+	assert.Equal(t, 1, attempts)
+	assert.Len(t, dlq.written, 1)
+	assert.Len(t, reader.committed, 1)
+}
+
+// TestRun_DeadLetterWriteRetried 测试写入死信主题失败时重试，写入成功前不提交
+func TestRun_DeadLetterWriteRetried(t *testing.T) {
+	dlq := &fakeWriter{failures: 2}
+	c, reader, ctx := newTestConsumer([]kafka.Message{testMessage(1, "poison")}, dlq, func([]byte) error {
+		return errInvalidMessage
+	})
+
+	c.Run(ctx)
+
+	assert.Len(t, dlq.written, 1)
+	assert.Len(t, reader.committed, 1)
+}
+
+// TestRun_NoDeadLetterTopic 测试未配置死信主题时跳过失败消息并提交
+func TestRun_NoDeadLetterTopic(t *testing.T) {
+	c, reader, ctx := newTestConsumer([]kafka.Message{testMessage(1, "poison")}, nil, func([]byte) error {
+		return errInvalidMessage
+	})
+
+	c.Run(ctx)
+
+	assert.Len(t, reader.committed, 1)
+}
+
+// TestRun_ShutdownDuringRetry 测试重试期间关机时不提交，重启后重新消费
+func TestRun_ShutdownDuringRetry(t *testing.T) {
+	dlq := &fakeWriter{}
+	ctx, cancel := context.WithCancel(context.Background())
+	reader := &fakeReader{messages: []kafka.Message{testMessage(1, "a")}}
+	c := &ESConsumer{
+		reader:          reader,
+		dlq:             dlq,
+		maxRetries:      5,
+		retryBackoff:    time.Hour,
+		retryMaxBackoff: time.Hour,
+		handle: func([]byte) error {
+			cancel()
+			return errors.New("ES暂时不可用")
+		},
+	}
+
+	c.Run(ctx)
+
+	assert.Empty(t, reader.committed)
+	assert.Empty(t, dlq.written)
+}
+
+// TestRetryBackoff 测试指数退避及上限
+func TestRetryBackoff(t *testing.T) {
+	base, maxBackoff := 100*time.Millisecond, time.Second
+	assert.Equal(t, 100*time.Millisecond, retryBackoff(base, maxBackoff, 0))
+	assert.Equal(t, 200*time.Millisecond, retryBackoff(base, maxBackoff, 1))
+	assert.Equal(t, 800*time.Millisecond, retryBackoff(base, maxBackoff, 3))
+	assert.Equal(t, time.Second, retryBackoff(base, maxBackoff, 4))
+	assert.Equal(t, time.Second, retryBackoff(base, maxBackoff, 100))
+}
+
+// TestReplayDeadLetters 测试死信消息写回原主题并提交，没有新消息时结束
+func TestReplayDeadLetters(t *testing.T) {
+	dead := newDeadLetterMessage(testMessage(3, "a"), errors.New("处理失败"), time.Now())
+	noHeader := kafka.Message{Key: []byte("k2"), Value: []byte("b")}
+	reader := &fakeReader{messages: []kafka.Message{dead, noHeader}}
+	writer := &fakeWriter{}
+
+	replayed, err := replayDeadLetters(context.Background(), reader, writer, "default-topic", 0, 10*time.Millisecond)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, replayed)
+	require.Len(t, writer.written, 2)
+	assert.Equal(t, "canal-topic", writer.written[0].Topic)
+	assert.Equal(t, "a", string(writer.written[0].Value))
+	assert.Empty(t, writer.written[0].Headers)
+	assert.Equal(t, "default-topic", writer.written[1].Topic)
+	assert.Len(t, reader.committed, 2)
+}
+
+// TestReplayDeadLetters_Limit 测试按数量限制重放
+func TestReplayDeadLetters_Limit(t *testing.T) {
+	reader := &fakeReader{messages: []kafka.Message{testMessage(1, "a"), testMessage(2, "b")}}
+	writer := &fakeWriter{}
+
+	replayed, err := replayDeadLetters(context.Background(), reader, writer, "canal-topic", 1, 10*time.Millisecond)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, replayed)
+	assert.Len(t, reader.committed, 1)
+}
+
+// TestReplayDeadLetters_WriteFailed 测试写回失败时停止且不提交
+func TestReplayDeadLetters_WriteFailed(t *testing.T) {
+	reader := &fakeReader{messages: []kafka.Message{testMessage(1, "a")}}
+	writer := &fakeWriter{failures: 1}
+
+	replayed, err := replayDeadLetters(context.Background(), reader, writer, "canal-topic", 0, 10*time.Millisecond)
+
+	assert.Error(t, err)
+	assert.Equal(t, 0, replayed)
+	assert.Empty(t, reader.committed)
+}
+
+// newBatchTestConsumer 创建批量处理的消费者，读完消息后等待批量窗口结束
+func newBatchTestConsumer(t *testing.T, messages []kafka.Message, dlq messageWriter, handle func([]byte) error, handleBatch func([][]byte) error) (*ESConsumer, *fakeReader, context.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	t.Cleanup(cancel)
+	reader := &fakeReader{messages: messages}
+	c := &ESConsumer{
+		reader:          reader,
+		dlq:             dlq,
+		handle:          handle,
+		handleBatch:     handleBatch,
+		batchSize:       10,
+		batchWindow:     20 * time.Millisecond,
+		maxRetries:      1,
+		retryBackoff:    time.Millisecond,
+		retryMaxBackoff: time.Millisecond,
+	}
+	return c, reader, ctx
+}
+
+// TestRun_Batch 测试窗口内的消息合并为一批处理后一起提交
+func TestRun_Batch(t *testing.T) {
+	var batches [][]string
+	c, reader, ctx := newBatchTestConsumer(t,
+		[]kafka.Message{testMessage(1, "a"), testMessage(2, "b"), testMessage(3, "c")},
+		&fakeWriter{},
+		func([]byte) error { return errors.New("不应逐条处理") },
+		func(values [][]byte) error {
+			var batch []string
+			for _, v := range values {
+				batch = append(batch, string(v))
+			}
+			batches = append(batches, batch)
+			return nil
+		},
+	)
+
+	c.Run(ctx)
+
+	assert.Equal(t, [][]string{{"a", "b", "c"}}, batches)
+	assert.Len(t, reader.committed, 3)
+}
+
+// TestRun_BatchSizeLimit 测试每批不超过 batchSize 条
+func TestRun_BatchSizeLimit(t *testing.T) {
+	var sizes []int
+	c, reader, ctx := newBatchTestConsumer(t,
+		[]kafka.Message{testMessage(1, "a"), testMessage(2, "b"), testMessage(3, "c")},
+		&fakeWriter{},
+		func([]byte) error { return nil },
+		func(values [][]byte) error {
+			sizes = append(sizes, len(values))
+			return nil
+		},
+	)
+	c.batchSize = 2
+
+	c.Run(ctx)
+
+	// 最后一条单独处理，不经过批量处理函数
+	assert.Equal(t, []int{2}, sizes)
+	assert.Len(t, reader.committed, 3)
+}
+
+// TestRun_BatchFallback 测试批量处理失败后逐条处理，只有失败的消息写入死信主题
+func TestRun_BatchFallback(t *testing.T) {
+	dlq := &fakeWriter{}
+	var handled []string
+	c, reader, ctx := newBatchTestConsumer(t,
+		[]kafka.Message{testMessage(1, "a"), testMessage(2, "bad"), testMessage(3, "c")},
+		dlq,
+		func(data []byte) error {
+			if string(data) == "bad" {
+				return errInvalidMessage
+			}
+			handled = append(handled, string(data))
+			return nil
+		},
+		func([][]byte) error { return errInvalidMessage },
+	)
+
+	c.Run(ctx)
+
+	assert.Equal(t, []string{"a", "c"}, handled)
+	require.Len(t, dlq.written, 1)
+	assert.Equal(t, "bad", string(dlq.written[0].Value))
+	assert.Len(t, reader.committed, 3)
+}
+
+// TestRun_BatchInfraErrorRetried 测试批量处理遇到基础设施故障时整批重试，不逐条处理也不写入死信主题
+func TestRun_BatchInfraErrorRetried(t *testing.T) {
+	dlq := &fakeWriter{}
+	attempts := 0
+	c, reader, ctx := newBatchTestConsumer(t,
+		[]kafka.Message{testMessage(1, "a"), testMessage(2, "b")},
+		dlq,
+		func([]byte) error { return errors.New("不应逐条处理") },
+		func([][]byte) error {
+			attempts++
+			if attempts <= 3 {
+				return errors.New("ES 503")
+			}
+			return nil
+		},
+	)
+
+	c.Run(ctx)
+
+	assert.Equal(t, 4, attempts)
+	assert.Len(t, reader.committed, 2)
+	assert.Empty(t, dlq.written)
+}
@@ -0,0 +1,202 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/segmentio/kafka-go"
+)
+
+// readerStatsInterval 导出Kafka读取器统计的间隔
+const readerStatsInterval = 15 * time.Second
+
+// 消息处理结果
+const (
+	resultProcessed = "processed"
+	resultFailed    = "failed"
+)
+
+// 无法解析的消息使用的标签值
+const unknownLabel = "unknown"
+
+var (
+	// ES同步处理的Canal消息数（result=processed/failed，failed为无法解析或被ES拒绝而进入死信主题的消息）
+	esSyncMessages = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "es_sync_messages_total",
+			Help: "ES同步处理的Canal消息总数",
+		},
+		[]string{"table", "operation", "result"},
+	)
+
+	// 处理一批消息的耗时（包括重试和写入死信主题）
+	esSyncBatchDuration = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "es_sync_batch_duration_seconds",
+			Help:    "ES同步处理一批Kafka消息的耗时（秒）",
+			Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		},
+	)
+
+	// 同步延迟：最近写入ES的变更在MySQL中执行的时间（Canal的es字段）距今多久
+	esSyncLag = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "es_sync_lag_seconds",
+			Help: "最近写入ES的变更从MySQL执行到写入完成的延迟（秒）",
+		},
+		[]string{"table"},
+	)
+
+	// 以下为Kafka读取器统计（kafka.Reader.Stats）
+	kafkaReaderMessages = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kafka_reader_messages_total",
+			Help: "Kafka读取器读到的消息总数",
+		},
+		[]string{"topic"},
+	)
+
+	kafkaReaderBytes = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kafka_reader_bytes_total",
+			Help: "Kafka读取器读到的消息字节数",
+		},
+		[]string{"topic"},
+	)
+
+	kafkaReaderErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kafka_reader_errors_total",
+			Help: "Kafka读取器的错误次数",
+		},
+		[]string{"topic"},
+	)
+
+	kafkaReaderRebalances = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kafka_reader_rebalances_total",
+			Help: "Kafka消费组再均衡次数",
+		},
+		[]string{"topic"},
+	)
+
+	kafkaReaderTimeouts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kafka_reader_timeouts_total",
+			Help: "Kafka读取器的超时次数",
+		},
+		[]string{"topic"},
+	)
+
+	kafkaReaderLag = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kafka_reader_lag",
+			Help: "Kafka读取器落后分区最新offset的消息数",
+		},
+		[]string{"topic"},
+	)
+
+	kafkaReaderOffset = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kafka_reader_offset",
+			Help: "Kafka读取器当前读到的offset",
+		},
+		[]string{"topic"},
+	)
+
+	kafkaReaderQueueLength = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kafka_reader_queue_length",
+			Help: "Kafka读取器内部队列中等待处理的消息数",
+		},
+		[]string{"topic"},
+	)
+
+	// 事务发件箱积压
+	outboxPendingEvents = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "outbox_pending_events",
+			Help: "事务发件箱中尚未发布的记录数",
+		},
+	)
+)
+
+// statsReader 提供统计信息的Kafka读取器（*kafka.Reader 实现）
+type statsReader interface {
+	Stats() kafka.ReaderStats
+}
+
+// canalHeader 统计时只需要的Canal消息字段
+type canalHeader struct {
+	Type  string `json:"type"`
+	Table string `json:"table"`
+}
+
+// recordProcessed 记录一批处理成功的消息：按表和操作计数，按表更新同步延迟
+func recordProcessed(msgs []*CanalMessage, now time.Time) {
+	latest := make(map[string]int64)
+	for _, msg := range msgs {
+		esSyncMessages.WithLabelValues(labelOrUnknown(msg.Table), labelOrUnknown(msg.Type), resultProcessed).Inc()
+		if msg.Es > latest[msg.Table] {
+			latest[msg.Table] = msg.Es
+		}
+	}
+	for table, es := range latest {
+		esSyncLag.WithLabelValues(labelOrUnknown(table)).Set(syncLag(es, now).Seconds())
+	}
+}
+
+// recordFailed 记录一条处理失败的消息，无法解析的消息计入unknown
+func recordFailed(data []byte) {
+	var header canalHeader
+	_ = json.Unmarshal(data, &header) // 解析失败时标签为unknown
+	esSyncMessages.WithLabelValues(labelOrUnknown(header.Table), labelOrUnknown(header.Type), resultFailed).Inc()
+}
+
+// syncLag 变更执行时间（毫秒时间戳）到now的延迟，时钟偏差导致的负数按0计
+func syncLag(es int64, now time.Time) time.Duration {
+	lag := now.Sub(time.UnixMilli(es))
+	if lag < 0 {
+		return 0
+	}
+	return lag
+}
+
+// labelOrUnknown 空标签值替换为unknown
+func labelOrUnknown(value string) string {
+	if value == "" {
+		return unknownLabel
+	}
+	return value
+}
+
+// exportReaderStats 定期把Kafka读取器统计导出为Prometheus指标，直到ctx取消
+func exportReaderStats(ctx context.Context, reader statsReader, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			recordReaderStats(reader.Stats())
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// recordReaderStats 导出一次读取器统计
+// 计数类字段是上次调用Stats以来的增量（Stats会清零），累加到计数器上
+func recordReaderStats(stats kafka.ReaderStats) {
+	topic := stats.Topic
+	kafkaReaderMessages.WithLabelValues(topic).Add(float64(stats.Messages))
+	kafkaReaderBytes.WithLabelValues(topic).Add(float64(stats.Bytes))
+	kafkaReaderErrors.WithLabelValues(topic).Add(float64(stats.Errors))
+	kafkaReaderRebalances.WithLabelValues(topic).Add(float64(stats.Rebalances))
+	kafkaReaderTimeouts.WithLabelValues(topic).Add(float64(stats.Timeouts))
+	kafkaReaderLag.WithLabelValues(topic).Set(float64(stats.Lag))
+	kafkaReaderOffset.WithLabelValues(topic).Set(float64(stats.Offset))
+	kafkaReaderQueueLength.WithLabelValues(topic).Set(float64(stats.QueueLength))
+}
@@ -0,0 +1,59 @@
+package consumers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRecordProcessed 测试按表和操作计数，同步延迟取每张表最新的变更
+func TestRecordProcessed(t *testing.T) {
+	now := time.UnixMilli(1_700_000_010_000)
+	recordProcessed([]*CanalMessage{
+		{Table: "metrics_topics", Type: "INSERT", Es: 1_700_000_000_000},
+		{Table: "metrics_topics", Type: "UPDATE", Es: 1_700_000_008_000},
+		{Table: "metrics_topics", Type: "UPDATE", Es: 1_700_000_005_000},
+		{Table: "metrics_comments", Type: "DELETE", Es: 1_700_000_009_500},
+	}, now)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(esSyncMessages.WithLabelValues("metrics_topics", "INSERT", resultProcessed)))
+	assert.Equal(t, 2.0, testutil.ToFloat64(esSyncMessages.WithLabelValues("metrics_topics", "UPDATE", resultProcessed)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(esSyncMessages.WithLabelValues("metrics_comments", "DELETE", resultProcessed)))
+	assert.Equal(t, 2.0, testutil.ToFloat64(esSyncLag.WithLabelValues("metrics_topics")))
+	assert.Equal(t, 0.5, testutil.ToFloat64(esSyncLag.WithLabelValues("metrics_comments")))
+}
+
+// TestRecordFailed 测试失败计数，无法解析的消息计入unknown
+func TestRecordFailed(t *testing.T) {
+	failed := esSyncMessages.WithLabelValues("metrics_failed", "UPDATE", resultFailed)
+	unknown := esSyncMessages.WithLabelValues(unknownLabel, unknownLabel, resultFailed)
+	before, beforeUnknown := testutil.ToFloat64(failed), testutil.ToFloat64(unknown)
+
+	recordFailed([]byte(`{"type":"UPDATE","table":"metrics_failed"}`))
+	recordFailed([]byte(`not json`))
+
+	assert.Equal(t, before+1, testutil.ToFloat64(failed))
+	assert.Equal(t, beforeUnknown+1, testutil.ToFloat64(unknown))
+}
+
+// TestSyncLag 测试时钟偏差导致的负延迟按0计
+func TestSyncLag(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	assert.Equal(t, 3*time.Second, syncLag(1_699_999_997_000, now))
+	assert.Zero(t, syncLag(1_700_000_001_000, now))
+}
+
+// TestRecordReaderStats 测试读取器统计的计数增量累加，状态值直接覆盖
+func TestRecordReaderStats(t *testing.T) {
+	recordReaderStats(kafka.ReaderStats{Topic: "metrics-topic", Messages: 3, Errors: 1, Lag: 10, Offset: 100})
+	recordReaderStats(kafka.ReaderStats{Topic: "metrics-topic", Messages: 2, Lag: 4, Offset: 105, QueueLength: 7})
+
+	assert.Equal(t, 5.0, testutil.ToFloat64(kafkaReaderMessages.WithLabelValues("metrics-topic")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(kafkaReaderErrors.WithLabelValues("metrics-topic")))
+	assert.Equal(t, 4.0, testutil.ToFloat64(kafkaReaderLag.WithLabelValues("metrics-topic")))
+	assert.Equal(t, 105.0, testutil.ToFloat64(kafkaReaderOffset.WithLabelValues("metrics-topic")))
+	assert.Equal(t, 7.0, testutil.ToFloat64(kafkaReaderQueueLength.WithLabelValues("metrics-topic")))
+}
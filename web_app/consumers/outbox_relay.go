@@ -0,0 +1,195 @@
+package consumers
+
+import (
+	"context"
+	"strconv"
+	"time"
+	"web_app/dao/mysql"
+	"web_app/models"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 发件箱记录的转发目标
+const (
+	outboxPublishKafka  = "kafka"  // 发布到 kafka.topic，由ES同步消费者处理（与Canal相同的链路）
+	outboxPublishDirect = "direct" // 转发器直接写入ES，不依赖Kafka
+)
+
+const (
+	// outboxMaxBackoff 转发连续失败时的最长等待时间
+	outboxMaxBackoff = 30 * time.Second
+	// outboxCleanupInterval 清理已发布记录的间隔
+	outboxCleanupInterval = time.Hour
+	// outboxBacklogInterval 统计未发布记录数的间隔
+	outboxBacklogInterval = 15 * time.Second
+)
+
+// outboxRelay 事务发件箱转发器：按写入顺序读取未发布的记录，发布成功后标记为已发布
+type outboxRelay struct {
+	fetch        func(limit int, publish func([]*models.OutboxEvent) error) (int, error) // 读取并标记记录
+	publish      func(ctx context.Context, events []*models.OutboxEvent) error           // 发布一批记录
+	cleanup      func(before time.Time) (int64, error)                                   // 删除已发布的旧记录
+	countPending func() (int64, error)                                                   // 统计未发布的记录数
+	batchSize    int
+	pollInterval time.Duration
+	retention    time.Duration
+}
+
+// OutboxDirect 发件箱记录是否由转发器直接写入ES（此时不需要运行Kafka消费者）
+func OutboxDirect() bool {
+	return mysql.OutboxEnabled() && viper.GetString("outbox.publish_to") == outboxPublishDirect
+}
+
+// RunOutboxRelay 运行发件箱转发器直到ctx取消（outbox.enabled 为 true 时由main.go启动）
+func RunOutboxRelay(ctx context.Context) error {
+	relay := &outboxRelay{
+		fetch:        mysql.PublishOutboxEvents,
+		cleanup:      mysql.DeleteOutboxEventsBefore,
+		countPending: mysql.CountPendingOutboxEvents,
+		batchSize:    viper.GetInt("outbox.batch_size"),
+		pollInterval: viper.GetDuration("outbox.poll_interval"),
+		retention:    viper.GetDuration("outbox.retention"),
+	}
+	if relay.batchSize <= 0 {
+		relay.batchSize = 200
+	}
+	if relay.pollInterval <= 0 {
+		relay.pollInterval = 500 * time.Millisecond
+	}
+	if relay.retention <= 0 {
+		relay.retention = 72 * time.Hour
+	}
+
+	target := viper.GetString("outbox.publish_to")
+	switch target {
+	case outboxPublishDirect:
+		relay.publish = newChangeApplier().applyOutboxEvents
+	default:
+		target = outboxPublishKafka
+		writer := newKafkaWriter(viper.GetStringSlice("kafka.brokers"), viper.GetString("kafka.topic"))
+		defer func() {
+			if err := writer.Close(); err != nil {
+				zap.L().Warn("关闭发件箱Kafka写入器失败", zap.Error(err))
+			}
+		}()
+		relay.publish = kafkaOutboxPublisher(writer)
+	}
+
+	zap.L().Info("发件箱转发器已启动",
+		zap.String("publish_to", target),
+		zap.Int("batch_size", relay.batchSize),
+		zap.Duration("poll_interval", relay.pollInterval))
+
+	relay.run(ctx)
+	return nil
+}
+
+// run 循环转发直到ctx取消：有积压时连续转发，没有新记录时按间隔轮询，失败时指数退避
+func (r *outboxRelay) run(ctx context.Context) {
+	cleanupTicker := time.NewTicker(outboxCleanupInterval)
+	defer cleanupTicker.Stop()
+	backlogTicker := time.NewTicker(outboxBacklogInterval)
+	defer backlogTicker.Stop()
+	r.reportBacklog()
+
+	failures := 0
+	for {
+		published, err := r.fetch(r.batchSize, func(events []*models.OutboxEvent) error {
+			return r.publish(ctx, events)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		wait := r.pollInterval
+		switch {
+		case err != nil:
+			wait = retryBackoff(r.pollInterval, outboxMaxBackoff, failures)
+			failures++
+			zap.L().Error("转发发件箱记录失败，稍后重试", zap.Error(err), zap.Duration("backoff", wait))
+		case published >= r.batchSize:
+			// 还有积压，立即继续
+			failures = 0
+			wait = 0
+		default:
+			failures = 0
+		}
+
+		select {
+		case <-cleanupTicker.C:
+			r.cleanupPublished()
+		case <-backlogTicker.C:
+			r.reportBacklog()
+		default:
+		}
+
+		if wait > 0 && !sleepContext(ctx, wait) {
+			return
+		}
+	}
+}
+
+// cleanupPublished 删除超过保留时间的已发布记录
+func (r *outboxRelay) cleanupPublished() {
+	deleted, err := r.cleanup(time.Now().Add(-r.retention))
+	if err != nil {
+		zap.L().Warn("清理已发布的发件箱记录失败", zap.Error(err))
+		return
+	}
+	zap.L().Info("已清理发件箱记录", zap.Int64("deleted", deleted))
+}
+
+// reportBacklog 更新未发布记录数指标（转发停滞或跟不上写入时持续增长）
+func (r *outboxRelay) reportBacklog() {
+	if r.countPending == nil {
+		return
+	}
+	pending, err := r.countPending()
+	if err != nil {
+		zap.L().Warn("统计未发布的发件箱记录失败", zap.Error(err))
+		return
+	}
+	outboxPendingEvents.Set(float64(pending))
+}
+
+// kafkaOutboxPublisher 发布到Kafka：按表和主键分区，保证同一行的变更顺序
+func kafkaOutboxPublisher(writer messageWriter) func(ctx context.Context, events []*models.OutboxEvent) error {
+	return func(ctx context.Context, events []*models.OutboxEvent) error {
+		msgs := make([]kafka.Message, len(events))
+		for i, event := range events {
+			msgs[i] = kafka.Message{
+				Key:   []byte(event.TableName + ":" + strconv.FormatInt(event.RowID, 10)),
+				Value: []byte(event.Payload),
+			}
+		}
+		return writer.WriteMessages(ctx, msgs...)
+	}
+}
+
+// applyOutboxEvents 直接把一批发件箱记录写入ES
+// 批内有无效的记录（无法解析或被ES拒绝）时逐条处理并跳过这些记录，其他失败返回错误（记录不标记为已发布，下次重试）
+func (c *ESConsumer) applyOutboxEvents(_ context.Context, events []*models.OutboxEvent) error {
+	values := make([][]byte, len(events))
+	for i, event := range events {
+		values[i] = []byte(event.Payload)
+	}
+
+	err := c.handleBatch(values)
+	if err == nil || !isPermanent(err) {
+		return err
+	}
+
+	for i, value := range values {
+		if err := c.handle(value); err != nil {
+			if !isPermanent(err) {
+				return err
+			}
+			recordFailed(value)
+			zap.L().Error("发件箱记录无效，跳过", zap.Error(err), zap.Int64("outbox_id", events[i].ID))
+		}
+	}
+	return nil
+}
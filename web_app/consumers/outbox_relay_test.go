@@ -0,0 +1,159 @@
+package consumers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"web_app/models"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOutbox 按批返回预置的发件箱记录，publish成功后才移除（模拟标记为已发布）
+type fakeOutbox struct {
+	pending   []*models.OutboxEvent
+	published []*models.OutboxEvent
+	calls     int
+	onEmpty   context.CancelFunc
+}
+
+func (o *fakeOutbox) fetch(limit int, publish func([]*models.OutboxEvent) error) (int, error) {
+	o.calls++
+	if len(o.pending) == 0 {
+		if o.onEmpty != nil {
+			o.onEmpty()
+		}
+		return 0, nil
+	}
+	n := limit
+	if n > len(o.pending) {
+		n = len(o.pending)
+	}
+	events := o.pending[:n]
+	if err := publish(events); err != nil {
+		return 0, err
+	}
+	o.published = append(o.published, events...)
+	o.pending = o.pending[n:]
+	return n, nil
+}
+
+func outboxEvents(n int) []*models.OutboxEvent {
+	events := make([]*models.OutboxEvent, n)
+	for i := range events {
+		events[i] = &models.OutboxEvent{ID: int64(i + 1), TableName: "topics", RowID: int64(100 + i), Payload: `{}`}
+	}
+	return events
+}
+
+// TestOutboxRelay_DrainBacklog 测试有积压时连续转发，读完后按间隔轮询
+func TestOutboxRelay_DrainBacklog(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	outbox := &fakeOutbox{pending: outboxEvents(5), onEmpty: cancel}
+	var batches []int
+	relay := &outboxRelay{
+		fetch: outbox.fetch,
+		publish: func(_ context.Context, events []*models.OutboxEvent) error {
+			batches = append(batches, len(events))
+			return nil
+		},
+		batchSize:    2,
+		pollInterval: time.Millisecond,
+	}
+
+	relay.run(ctx)
+
+	assert.Equal(t, []int{2, 2, 1}, batches)
+	assert.Equal(t, 4, outbox.calls)
+	assert.Len(t, outbox.published, 5)
+	assert.Empty(t, outbox.pending)
+}
+
+// TestOutboxRelay_RetryAfterFailure 测试发布失败时不标记，退避后重试
+func TestOutboxRelay_RetryAfterFailure(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	outbox := &fakeOutbox{pending: outboxEvents(1), onEmpty: cancel}
+	attempts := 0
+	relay := &outboxRelay{
+		fetch: outbox.fetch,
+		publish: func(context.Context, []*models.OutboxEvent) error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("broker不可用")
+			}
+			return nil
+		},
+		batchSize:    10,
+		pollInterval: time.Millisecond,
+	}
+
+	relay.run(ctx)
+
+	assert.Equal(t, 3, attempts)
+	assert.Len(t, outbox.published, 1)
+}
+
+// TestKafkaOutboxPublisher 测试按表和主键设置消息key
+func TestKafkaOutboxPublisher(t *testing.T) {
+	writer := &fakeWriter{}
+	publish := kafkaOutboxPublisher(writer)
+
+	events := []*models.OutboxEvent{
+		{ID: 1, TableName: "topics", RowID: 42, Payload: `{"type":"UPDATE"}`},
+		{ID: 2, TableName: "comments", RowID: 7, Payload: `{"type":"INSERT"}`},
+	}
+	require.NoError(t, publish(context.Background(), events))
+
+	require.Len(t, writer.written, 2)
+	assert.Equal(t, "topics:42", string(writer.written[0].Key))
+	assert.Equal(t, `{"type":"UPDATE"}`, string(writer.written[0].Value))
+	assert.Equal(t, "comments:7", string(writer.written[1].Key))
+}
+
+// TestOutboxRelay_ReportBacklog 测试未发布记录数写入指标，统计失败时保留上次的值
+func TestOutboxRelay_ReportBacklog(t *testing.T) {
+	var err error
+	relay := &outboxRelay{countPending: func() (int64, error) { return 7, err }}
+
+	relay.reportBacklog()
+	assert.Equal(t, 7.0, testutil.ToFloat64(outboxPendingEvents))
+
+	err = errors.New("连接失败")
+	relay.countPending = func() (int64, error) { return 0, err }
+	relay.reportBacklog()
+	assert.Equal(t, 7.0, testutil.ToFloat64(outboxPendingEvents))
+}
+
+// TestApplyOutboxEvents_SkipInvalid 测试直接写入模式跳过无法解析的记录
+func TestApplyOutboxEvents_SkipInvalid(t *testing.T) {
+	var handled []string
+	c := &ESConsumer{
+		handleBatch: func([][]byte) error { return errInvalidMessage },
+		handle: func(data []byte) error {
+			if string(data) == "bad" {
+				return errInvalidMessage
+			}
+			handled = append(handled, string(data))
+			return nil
+		},
+	}
+	events := []*models.OutboxEvent{{ID: 1, Payload: "a"}, {ID: 2, Payload: "bad"}, {ID: 3, Payload: "c"}}
+
+	require.NoError(t, c.applyOutboxEvents(context.Background(), events))
+	assert.Equal(t, []string{"a", "c"}, handled)
+}
+
+// TestApplyOutboxEvents_Error 测试写入失败时返回错误（记录留待下次重试）
+func TestApplyOutboxEvents_Error(t *testing.T) {
+	c := &ESConsumer{
+		handleBatch: func([][]byte) error { return errors.New("ES不可用") },
+		handle:      func([]byte) error { t.Fatal("不应逐条处理"); return nil },
+	}
+	err := c.applyOutboxEvents(context.Background(), outboxEvents(2))
+	assert.EqualError(t, err, "ES不可用")
+}
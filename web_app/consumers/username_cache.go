@@ -0,0 +1,81 @@
+package consumers
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// usernameCacheTTL 用户名缓存时间（用户名注册后不可修改，过期只用于释放不再活跃的用户）
+	usernameCacheTTL = 30 * time.Minute
+	// usernameCacheSize 最多缓存的用户数，超出时清空重新缓存
+	usernameCacheSize = 10000
+)
+
+// usernameCache 话题作者用户名的进程内缓存（topics表的binlog中没有用户名，需要查询users表补充）
+type usernameCache struct {
+	mu      sync.Mutex
+	entries map[int64]usernameEntry
+	ttl     time.Duration
+	maxSize int
+	lookup  func(userIDs []int64) (map[int64]string, error) // 批量查询未命中的用户名
+}
+
+// usernameEntry 缓存的用户名及过期时间
+type usernameEntry struct {
+	username  string
+	expiresAt time.Time
+}
+
+// newUsernameCache 创建用户名缓存
+func newUsernameCache(ttl time.Duration, maxSize int, lookup func([]int64) (map[int64]string, error)) *usernameCache {
+	return &usernameCache{
+		entries: make(map[int64]usernameEntry),
+		ttl:     ttl,
+		maxSize: maxSize,
+		lookup:  lookup,
+	}
+}
+
+// resolve 返回用户ID对应的用户名，未命中缓存的用户一次批量查询（不存在的用户不在结果中）
+func (uc *usernameCache) resolve(userIDs []int64) (map[int64]string, error) {
+	result := make(map[int64]string, len(userIDs))
+	missing := make([]int64, 0)
+	seen := make(map[int64]bool, len(userIDs))
+	now := time.Now()
+
+	uc.mu.Lock()
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		if entry, ok := uc.entries[userID]; ok && now.Before(entry.expiresAt) {
+			result[userID] = entry.username
+			continue
+		}
+		missing = append(missing, userID)
+	}
+	uc.mu.Unlock()
+
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	found, err := uc.lookup(missing)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	if len(uc.entries)+len(found) > uc.maxSize {
+		uc.entries = make(map[int64]usernameEntry)
+	}
+	expiresAt := now.Add(uc.ttl)
+	for userID, username := range found {
+		uc.entries[userID] = usernameEntry{username: username, expiresAt: expiresAt}
+		result[userID] = username
+	}
+	return result, nil
+}
@@ -0,0 +1,48 @@
+package consumers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUsernameCache 测试缓存命中、去重、过期和容量上限
+func TestUsernameCache(t *testing.T) {
+	var lookups [][]int64
+	uc := newUsernameCache(time.Minute, 3, func(ids []int64) (map[int64]string, error) {
+		lookups = append(lookups, ids)
+		result := make(map[int64]string)
+		for _, id := range ids {
+			if id != 404 {
+				result[id] = "user" + string(rune('0'+id))
+			}
+		}
+		return result, nil
+	})
+
+	got, err := uc.resolve([]int64{1, 2, 1, 404})
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]string{1: "user1", 2: "user2"}, got)
+
+	// 已缓存的用户不再查询，不存在的用户每次重新查询
+	got, err = uc.resolve([]int64{2, 404})
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]string{2: "user2"}, got)
+	assert.Equal(t, [][]int64{{1, 2, 404}, {404}}, lookups)
+
+	// 超出容量时清空后重新缓存
+	_, err = uc.resolve([]int64{3, 4})
+	require.NoError(t, err)
+	assert.Len(t, uc.entries, 2)
+
+	// 过期后重新查询
+	uc.mu.Lock()
+	uc.entries[3] = usernameEntry{username: "user3", expiresAt: time.Now().Add(-time.Second)}
+	uc.mu.Unlock()
+	lookups = nil
+	_, err = uc.resolve([]int64{3})
+	require.NoError(t, err)
+	assert.Equal(t, [][]int64{{3}}, lookups)
+}
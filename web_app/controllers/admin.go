@@ -2,9 +2,10 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
-	"web_app/dao/elasticsearch"
-	"web_app/dao/mysql"
+	"strconv"
+	"web_app/logic"
 	"web_app/models"
 
 	"github.com/gin-gonic/gin"
@@ -21,42 +22,249 @@ func NewAdminController() *AdminController {
 
 // SyncToES 同步数据到Elasticsearch
 // @Summary 同步数据到ES
-// @Description 将MySQL中的所有话题同步到Elasticsearch
+// @Description 后台按ID分批将MySQL中的话题同步到Elasticsearch，进度保存在Redis中；上次同步未完成时从断点续传
 // @Tags 管理
 // @Produce json
-// @Success 200 {object} models.Response
+// @Security ApiKeyAuth
+// @Param restart query bool false "忽略断点从头同步"
+// @Success 200 {object} models.Response{data=models.ESSyncStatus}
+// @Failure 403 {object} models.Response "不是管理员"
+// @Failure 409 {object} models.Response
 // @Router /api/v1/admin/sync-es [post]
 func (ac *AdminController) SyncToES(c *gin.Context) {
-	zap.L().Info("开始同步数据到Elasticsearch")
+	restart, _ := strconv.ParseBool(c.Query("restart"))
+
+	status, err := logic.StartESSync(restart)
+	if err != nil {
+		if errors.Is(err, logic.ErrSyncRunning) {
+			c.JSON(http.StatusConflict, models.NewErrorResponse(models.CodeAlreadyExists, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(status))
+}
+
+// GetSyncStatus 查询同步进度
+// @Summary 查询ES同步进度
+// @Description 返回最近一次全量同步的状态、处理数、失败数、错误信息和预计剩余时间
+// @Tags 管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.Response{data=models.ESSyncStatus}
+// @Failure 403 {object} models.Response "不是管理员"
+// @Failure 404 {object} models.Response
+// @Router /api/v1/admin/sync-es/status [get]
+func (ac *AdminController) GetSyncStatus(c *gin.Context) {
+	status, err := logic.GetESSyncStatus()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, err.Error()))
+		return
+	}
+	if status == nil {
+		c.JSON(http.StatusNotFound, models.NewErrorResponse(models.CodeNotFound, "没有同步记录"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(status))
+}
+
+// ReindexES 零停机重建ES索引
+// @Summary 重建搜索索引
+// @Description 按当前分词和mapping创建新版本索引，从MySQL流式导入（期间Canal变更双写到新索引），完成后原子切换别名。任务在后台执行
+// @Tags 管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.Response
+// @Failure 403 {object} models.Response "不是管理员"
+// @Failure 409 {object} models.Response
+// @Router /api/v1/admin/es/reindex [post]
+func (ac *AdminController) ReindexES(c *gin.Context) {
+	target, err := logic.StartReindex()
+	if err != nil {
+		if errors.Is(err, logic.ErrReindexRunning) {
+			c.JSON(http.StatusConflict, models.NewErrorResponse(models.CodeAlreadyExists, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(gin.H{
+		"message": "重建索引任务已开始",
+		"index":   target,
+	}))
+}
+
+// GetTopSearchQueries 热门搜索词统计
+// @Summary 热门搜索词
+// @Description 统计最近一段时间搜索次数最多的搜索词（规范化后，只统计带关键词的第一页搜索）
+// @Tags 管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param hours query int false "统计最近多少小时（1-168）" default(24)
+// @Param limit query int false "返回条数（1-100）" default(20)
+// @Success 200 {object} models.Response{data=[]models.SearchQueryStat}
+// @Failure 403 {object} models.Response "不是管理员"
+// @Router /api/v1/admin/search/top-queries [get]
+func (ac *AdminController) GetTopSearchQueries(c *gin.Context) {
+	var req models.SearchQueryStatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, "参数错误"))
+		return
+	}
 
-	// 1. 从MySQL获取所有话题
-	topics, err := mysql.GetAllTopics()
+	stats, err := logic.GetTopSearchQueries(&req)
 	if err != nil {
-		zap.L().Error("获取话题失败", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, "获取话题失败"))
+		zap.L().Error("获取热门搜索词失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, "获取热门搜索词失败"))
 		return
 	}
 
-	if len(topics) == 0 {
-		c.JSON(http.StatusOK, models.NewSuccessResponse(gin.H{
-			"message": "没有话题需要同步",
-			"count":   0,
-		}))
+	c.JSON(http.StatusOK, models.NewSuccessResponse(stats))
+}
+
+// GetZeroResultQueries 零结果搜索词统计
+// @Summary 零结果搜索词
+// @Description 统计最近一段时间没有搜到结果次数最多的搜索词，用于发现缺失的内容和需要补充的同义词
+// @Tags 管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param hours query int false "统计最近多少小时（1-168）" default(24)
+// @Param limit query int false "返回条数（1-100）" default(20)
+// @Success 200 {object} models.Response{data=[]models.SearchQueryStat}
+// @Failure 403 {object} models.Response "不是管理员"
+// @Router /api/v1/admin/search/zero-queries [get]
+func (ac *AdminController) GetZeroResultQueries(c *gin.Context) {
+	var req models.SearchQueryStatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, "参数错误"))
 		return
 	}
 
-	// 2. 批量索引到ES
-	err = elasticsearch.BulkIndexTopics(topics)
+	stats, err := logic.GetZeroResultQueries(&req)
 	if err != nil {
-		zap.L().Error("批量索引失败", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, "同步失败"))
+		zap.L().Error("获取零结果搜索词失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, "获取零结果搜索词失败"))
 		return
 	}
 
-	zap.L().Info("同步完成", zap.Int("count", len(topics)))
+	c.JSON(http.StatusOK, models.NewSuccessResponse(stats))
+}
+
+// GetHourlySearchStats 每小时搜索统计
+// @Summary 每小时搜索统计
+// @Description 返回最近一段时间每小时的搜索次数、零结果次数、零结果率和平均耗时（当前小时在前）
+// @Tags 管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param hours query int false "统计最近多少小时（1-168）" default(24)
+// @Success 200 {object} models.Response{data=[]models.SearchHourlyStat}
+// @Failure 403 {object} models.Response "不是管理员"
+// @Router /api/v1/admin/search/hourly [get]
+func (ac *AdminController) GetHourlySearchStats(c *gin.Context) {
+	var req models.SearchQueryStatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, "参数错误"))
+		return
+	}
+
+	stats, err := logic.GetHourlySearchStats(&req)
+	if err != nil {
+		zap.L().Error("获取每小时搜索统计失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, "获取搜索统计失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(stats))
+}
+
+// PinTopic 置顶/取消置顶话题
+// @Summary 置顶话题
+// @Description 版主将话题置顶到列表顶部（global=全站置顶，category=分类置顶）
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "话题ID"
+// @Param request body models.PinTopicRequest true "置顶信息"
+// @Success 200 {object} models.Response
+// @Failure 403 {object} models.Response "不是版主或管理员"
+// @Router /api/v1/admin/topics/{id}/pin [post]
+func (ac *AdminController) PinTopic(c *gin.Context) {
+	// 1. 获取话题ID
+	topicID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, "无效的话题ID"))
+		return
+	}
+
+	// 2. 绑定并验证请求参数
+	var req models.PinTopicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, "参数错误: "+err.Error()))
+		return
+	}
+
+	// 3. 调用逻辑层
+	if err := logic.PinTopic(topicID, &req); err != nil {
+		if err.Error() == "话题不存在" {
+			c.JSON(http.StatusNotFound, models.NewErrorResponse(models.CodeNotFound, "话题不存在"))
+			return
+		}
+		zap.L().Error("置顶话题失败", zap.Error(err), zap.Int64("topic_id", topicID))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(gin.H{
+		"message": "操作成功",
+		"pinned":  req.Pinned,
+	}))
+}
+
+// LockTopic 锁定/解锁话题
+// @Summary 锁定话题
+// @Description 版主锁定话题，锁定后禁止发表新评论
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "话题ID"
+// @Param request body models.LockTopicRequest true "锁定信息"
+// @Success 200 {object} models.Response
+// @Failure 403 {object} models.Response "不是版主或管理员"
+// @Router /api/v1/admin/topics/{id}/lock [post]
+func (ac *AdminController) LockTopic(c *gin.Context) {
+	// 1. 获取话题ID
+	topicID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, "无效的话题ID"))
+		return
+	}
+
+	// 2. 绑定并验证请求参数
+	var req models.LockTopicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, "参数错误: "+err.Error()))
+		return
+	}
+
+	// 3. 调用逻辑层
+	if err := logic.LockTopic(topicID, &req); err != nil {
+		if err.Error() == "话题不存在" {
+			c.JSON(http.StatusNotFound, models.NewErrorResponse(models.CodeNotFound, "话题不存在"))
+			return
+		}
+		zap.L().Error("锁定话题失败", zap.Error(err), zap.Int64("topic_id", topicID))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, err.Error()))
+		return
+	}
 
 	c.JSON(http.StatusOK, models.NewSuccessResponse(gin.H{
-		"message": "同步成功",
-		"count":   len(topics),
+		"message": "操作成功",
+		"locked":  req.Locked,
 	}))
 }
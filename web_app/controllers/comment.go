@@ -2,6 +2,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"web_app/logic"
@@ -60,6 +61,15 @@ func (cc *CommentController) CreateComment(c *gin.Context) {
 
 	// 4. 调用逻辑层创建评论
 	if err := logic.CreateComment(userID, topicID, &req); err != nil {
+		if errors.Is(err, logic.ErrTopicLocked) {
+			zap.L().Warn("话题已锁定", zap.Int64("topic_id", topicID))
+			c.JSON(http.StatusForbidden, models.NewErrorResponse(models.CodeForbidden, err.Error()))
+			return
+		}
+		if errors.Is(err, logic.ErrAttachmentNotOwned) {
+			c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, err.Error()))
+			return
+		}
 		zap.L().Error("创建评论失败", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, err.Error()))
 		return
@@ -79,6 +89,7 @@ func (cc *CommentController) CreateComment(c *gin.Context) {
 // @Param id path int true "话题ID"
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
+// @Param format query string false "内容格式：raw/html，默认两者都返回"
 // @Success 200 {object} models.Response{data=models.CommentListResponse}
 // @Router /api/v1/topics/{id}/comments [get]
 func (cc *CommentController) GetComments(c *gin.Context) {
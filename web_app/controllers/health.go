@@ -0,0 +1,41 @@
+// Package controllers 健康检查控制器
+package controllers
+
+import (
+	"net/http"
+	"web_app/models"
+	"web_app/workers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthController 健康检查控制器
+type HealthController struct{}
+
+// NewHealthController 创建健康检查控制器
+func NewHealthController() *HealthController {
+	return &HealthController{}
+}
+
+// Ready 就绪检查
+// @Summary 就绪检查
+// @Description 返回各后台任务（Kafka消费者、定时任务等）的运行状态；关机中或关键任务（事件总线）等待重启时返回503，其他任务重启只体现在返回的状态中
+// @Tags 健康检查
+// @Produce json
+// @Success 200 {object} models.Response{data=models.ReadinessResponse}
+// @Failure 503 {object} models.Response{data=models.ReadinessResponse}
+// @Router /ready [get]
+func (hc *HealthController) Ready(c *gin.Context) {
+	ready, statuses := workers.Default.Health()
+	resp := models.ReadinessResponse{Ready: ready, Workers: statuses}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, models.Response{
+			Code:    models.CodeServerError,
+			Message: "服务未就绪",
+			Data:    resp,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, models.NewSuccessResponse(resp))
+}
@@ -0,0 +1,89 @@
+// Package controllers 处理HTTP请求的控制器
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"web_app/logic"
+	"web_app/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// PollController 话题投票控制器
+type PollController struct{}
+
+// NewPollController 创建话题投票控制器
+func NewPollController() *PollController {
+	return &PollController{}
+}
+
+// VotePoll 参与话题投票
+// @Summary 参与话题投票
+// @Description 对话题附带的投票进行投票（单选只能选一个选项，每人只能投一次）
+// @Tags 话题
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "话题ID"
+// @Param request body models.PollVoteRequest true "选择的选项"
+// @Success 200 {object} models.Response{data=models.Poll}
+// @Failure 400 {object} models.Response "无效的选项，或单选投票选择了多个选项"
+// @Failure 404 {object} models.Response "话题没有投票"
+// @Failure 409 {object} models.Response "投票已截止，或已参与过该投票"
+// @Router /api/v1/topics/{id}/poll/vote [post]
+func (pc *PollController) VotePoll(c *gin.Context) {
+	// 1. 获取话题ID
+	topicID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, "无效的话题ID"))
+		return
+	}
+
+	// 2. 绑定并验证请求参数
+	var req models.PollVoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		zap.L().Error("参数验证失败", zap.Error(err))
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, "参数错误: "+err.Error()))
+		return
+	}
+
+	// 3. 从context获取当前用户ID
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse(models.CodeUnauthorized, "用户未登录"))
+		return
+	}
+	userID, ok := userIDVal.(int64)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, "用户ID格式解析错误"))
+		return
+	}
+
+	// 4. 调用逻辑层投票
+	if err := logic.VotePoll(userID, topicID, &req); err != nil {
+		switch {
+		case errors.Is(err, logic.ErrPollNotFound):
+			c.JSON(http.StatusNotFound, models.NewErrorResponse(models.CodeNotFound, err.Error()))
+			return
+		case errors.Is(err, logic.ErrInvalidPollOption), errors.Is(err, logic.ErrPollSingleChoice):
+			c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, err.Error()))
+			return
+		case errors.Is(err, logic.ErrPollClosed), errors.Is(err, logic.ErrPollAlreadyVoted):
+			c.JSON(http.StatusConflict, models.NewErrorResponse(models.CodeAlreadyExists, err.Error()))
+			return
+		}
+		zap.L().Error("参与投票失败", zap.Error(err), zap.Int64("topic_id", topicID))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, err.Error()))
+		return
+	}
+
+	// 5. 返回最新投票结果（投票后结果可见）
+	poll, err := logic.GetTopicPoll(topicID, userID)
+	if err != nil {
+		zap.L().Warn("查询投票结果失败", zap.Error(err), zap.Int64("topic_id", topicID))
+	}
+	c.JSON(http.StatusOK, models.NewSuccessResponse(poll))
+}
@@ -2,6 +2,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"web_app/logic"
@@ -21,41 +22,79 @@ func NewSearchController() *SearchController {
 
 // SearchTopics 搜索话题
 // @Summary 搜索话题
-// @Description 全文搜索话题，支持关键词、分类筛选和排序
+// @Description 全文搜索话题，支持关键词、分类/作者/标签/时间/互动数筛选和排序（相关度、综合打分或字段排序），同时返回分类、标签和按天发布数的聚合
 // @Tags 搜索
 // @Produce json
 // @Param keyword query string false "搜索关键词"
 // @Param category query string false "分类筛选"
+// @Param author query string false "作者用户名"
+// @Param tags query []string false "标签（可重复传参或逗号分隔，需同时包含）" collectionFormat(multi)
+// @Param created_from query string false "发布时间下限：yyyy-MM-dd 或 yyyy-MM-dd HH:mm:ss"
+// @Param created_to query string false "发布时间上限（含），只有日期时包含当天"
+// @Param min_likes query int false "最少点赞数"
+// @Param min_comments query int false "最少评论数"
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
-// @Param sort_by query string false "排序方式：created_at/view_count/comment_count" default(created_at)
+// @Param sort_by query string false "排序方式：relevance(相关度)/combined(相关度+时效+热度)/created_at/view_count/comment_count，有关键词时默认relevance，否则默认created_at"
+// @Param with_comments query bool false "综合模式：同时返回评论命中关键词的话题，并附带命中的评论摘要"
 // @Success 200 {object} models.Response{data=models.SearchResponse}
+// @Failure 400 {object} models.Response
 // @Router /api/v1/search [get]
 func (sc *SearchController) SearchTopics(c *gin.Context) {
-	// 1. 获取查询参数
-	keyword := c.Query("keyword")
-	category := c.Query("category")
-	page := c.DefaultQuery("page", "1")
-	pageSize := c.DefaultQuery("page_size", "20")
-	sortBy := c.DefaultQuery("sort_by", "created_at")
-
-	// 参数转换
-	pageInt := 1
-	pageSizeInt := 20
-	if p, err := parseInt(page); err == nil {
-		pageInt = p
-	}
-	if ps, err := parseInt(pageSize); err == nil {
-		pageSizeInt = ps
+	// 1. 绑定查询参数
+	var req models.SearchTopicsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, "参数错误: "+err.Error()))
+		return
 	}
 
 	// 2. 调用logic层搜索
-	result, err := logic.SearchTopics(keyword, category, pageInt, pageSizeInt, sortBy)
+	result, err := logic.SearchTopics(&req)
 	if err != nil {
+		if errors.Is(err, logic.ErrInvalidSearchParams) {
+			c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, err.Error()))
+			return
+		}
 		zap.L().Error("搜索话题失败",
 			zap.Error(err),
-			zap.String("keyword", keyword),
-			zap.String("category", category))
+			zap.String("keyword", req.Keyword),
+			zap.String("category", req.Category))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, "搜索失败"))
+		return
+	}
+
+	// 3. 返回搜索结果
+	c.JSON(http.StatusOK, models.NewSuccessResponse(result))
+}
+
+// SearchComments 搜索评论
+// @Summary 搜索评论
+// @Description 全文搜索评论内容，返回评论摘要、评论者和所属话题
+// @Tags 搜索
+// @Produce json
+// @Param keyword query string true "搜索关键词"
+// @Param topic_id query string false "只搜索指定话题下的评论"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} models.Response{data=models.CommentSearchResponse}
+// @Failure 400 {object} models.Response
+// @Router /api/v1/search/comments [get]
+func (sc *SearchController) SearchComments(c *gin.Context) {
+	// 1. 绑定查询参数
+	var req models.SearchCommentsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, "参数错误: "+err.Error()))
+		return
+	}
+
+	// 2. 调用logic层搜索
+	result, err := logic.SearchComments(&req)
+	if err != nil {
+		if errors.Is(err, logic.ErrInvalidSearchParams) {
+			c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, err.Error()))
+			return
+		}
+		zap.L().Error("搜索评论失败", zap.Error(err), zap.String("keyword", req.Keyword))
 		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, "搜索失败"))
 		return
 	}
@@ -66,22 +105,20 @@ func (sc *SearchController) SearchTopics(c *gin.Context) {
 
 // SuggestTopics 搜索建议
 // @Summary 搜索建议
-// @Description 根据前缀提供搜索建议
+// @Description 根据输入返回热门搜索词和标题补全（标题补全容忍拼写误差，可按分类限定）
 // @Tags 搜索
 // @Produce json
 // @Param prefix query string true "搜索前缀"
-// @Success 200 {object} models.Response{data=[]string}
+// @Param category query string false "分类"
+// @Success 200 {object} models.Response{data=models.SuggestResponse}
 // @Router /api/v1/search/suggest [get]
 func (sc *SearchController) SuggestTopics(c *gin.Context) {
 	// 1. 获取前缀
 	prefix := c.Query("prefix")
-	if prefix == "" {
-		c.JSON(http.StatusOK, models.NewSuccessResponse([]string{}))
-		return
-	}
+	category := c.Query("category")
 
 	// 2. 调用logic层获取建议
-	suggestions, err := logic.SuggestTopics(prefix)
+	suggestions, err := logic.SuggestTopics(prefix, category)
 	if err != nil {
 		zap.L().Error("获取搜索建议失败", zap.Error(err), zap.String("prefix", prefix))
 		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, "获取建议失败"))
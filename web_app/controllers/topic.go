@@ -2,6 +2,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"web_app/logic"
@@ -52,6 +53,10 @@ func (tc *TopicController) CreateTopic(c *gin.Context) {
 
 	//3. 调用逻辑层插入话题
 	if err := logic.CreateTopic(userID, &req); err != nil {
+		if errors.Is(err, logic.ErrInvalidPollCloseAt) || errors.Is(err, logic.ErrAttachmentNotOwned) {
+			c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, err.Error()))
+			return
+		}
 		zap.L().Error("创建话题失败", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, err.Error()))
 		return
@@ -72,6 +77,7 @@ func (tc *TopicController) CreateTopic(c *gin.Context) {
 // @Param page_size query int false "每页数量" default(10)
 // @Param sort query string false "排序方式：hot/new/like" default(hot)
 // @Param category query string false "分类筛选"
+// @Param format query string false "内容格式：raw/html，默认两者都返回"
 // @Success 200 {object} models.Response{data=models.TopicListResponse}
 // @Router /api/v1/topics [get]
 func (tc *TopicController) GetTopics(c *gin.Context) {
@@ -123,10 +129,11 @@ func (tc *TopicController) GetTopics(c *gin.Context) {
 
 // GetTopicByID 获取话题详情
 // @Summary 获取话题详情
-// @Description 根据ID获取话题详细信息
+// @Description 根据ID获取话题详细信息（附带投票时返回投票结果，登录用户投票后可见计数）
 // @Tags 话题
 // @Produce json
 // @Param id path int true "话题ID"
+// @Param format query string false "内容格式：raw/html，默认两者都返回"
 // @Success 200 {object} models.Response{data=models.Topic}
 // @Router /api/v1/topics/{id} [get]
 func (tc *TopicController) GetTopicByID(c *gin.Context) {
@@ -137,8 +144,21 @@ func (tc *TopicController) GetTopicByID(c *gin.Context) {
 		return
 	}
 
-	// 2. 调用逻辑层查询话题详情
-	topic, err := logic.GetTopicByID(topicID)
+	// 2. 获取当前用户ID（可选登录，未登录为0）
+	var userID int64
+	if userIDVal, exists := c.Get("user_id"); exists {
+		userID, _ = userIDVal.(int64)
+	}
+
+	// 3. 获取内容格式
+	format := c.Query("format")
+	if format != "" && format != "raw" && format != "html" {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, "无效的内容格式"))
+		return
+	}
+
+	// 4. 调用逻辑层查询话题详情
+	topic, err := logic.GetTopicByID(topicID, userID, format)
 	if err != nil {
 		// 区分"话题不存在"和其他错误
 		if err.Error() == "话题不存在" {
@@ -151,10 +171,42 @@ func (tc *TopicController) GetTopicByID(c *gin.Context) {
 		return
 	}
 
-	// 3. 返回话题详情
+	// 5. 返回话题详情
 	c.JSON(http.StatusOK, models.NewSuccessResponse(topic))
 }
 
+// GetRelatedTopics 获取相关话题
+// @Summary 获取相关话题
+// @Description 根据标题、内容和分类推荐相似的话题（不含当前话题，不返回正文）
+// @Tags 话题
+// @Produce json
+// @Param id path int true "话题ID"
+// @Success 200 {object} models.Response{data=[]models.Topic}
+// @Router /api/v1/topics/{id}/related [get]
+func (tc *TopicController) GetRelatedTopics(c *gin.Context) {
+	// 1. 获取话题ID
+	topicID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, "无效的话题ID"))
+		return
+	}
+
+	// 2. 调用逻辑层查询相关话题
+	topics, err := logic.GetRelatedTopics(topicID)
+	if err != nil {
+		if err.Error() == "话题不存在" {
+			c.JSON(http.StatusNotFound, models.NewErrorResponse(models.CodeNotFound, "话题不存在"))
+			return
+		}
+		zap.L().Error("获取相关话题失败", zap.Error(err), zap.Int64("topic_id", topicID))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, "获取相关话题失败"))
+		return
+	}
+
+	// 3. 返回相关话题
+	c.JSON(http.StatusOK, models.NewSuccessResponse(topics))
+}
+
 // VoteTopic 给话题投票（点赞/点踩）
 // @Summary 给话题投票
 // @Description 对话题进行点赞或点踩
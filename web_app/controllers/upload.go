@@ -0,0 +1,71 @@
+// Package controllers 处理HTTP请求的控制器
+package controllers
+
+import (
+	"net/http"
+	"web_app/logic"
+	"web_app/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// uploadFormOverhead multipart表单除文件内容外的额外开销（边界、表单头等）
+const uploadFormOverhead = 1 << 20
+
+// UploadController 附件上传控制器
+type UploadController struct{}
+
+// NewUploadController 创建附件上传控制器
+func NewUploadController() *UploadController {
+	return &UploadController{}
+}
+
+// Upload 上传图片/附件
+// @Summary 上传附件
+// @Description 上传图片或附件（按文件内容识别类型，相同内容自动去重），返回的附件ID可在创建话题或评论时引用（只能引用自己上传的附件）
+// @Tags 附件
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param file formData file true "上传的文件"
+// @Success 200 {object} models.Response{data=models.Attachment}
+// @Router /api/v1/uploads [post]
+func (uc *UploadController) Upload(c *gin.Context) {
+	// 1. 限制请求体大小，避免超大文件占满磁盘/内存
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, logic.MaxUploadSize()+uploadFormOverhead)
+
+	// 2. 获取上传文件
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		zap.L().Warn("读取上传文件失败", zap.Error(err))
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, "请选择要上传的文件或文件过大"))
+		return
+	}
+
+	// 3. 从context获取当前用户ID
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse(models.CodeUnauthorized, "用户未登录"))
+		return
+	}
+	userID, ok := userIDVal.(int64)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, "用户ID格式解析错误"))
+		return
+	}
+
+	// 4. 调用逻辑层保存附件
+	attachment, err := logic.UploadAttachment(userID, fileHeader)
+	if err != nil {
+		if err.Error() == "上传失败" {
+			c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, err.Error()))
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, err.Error()))
+		return
+	}
+
+	// 5. 返回附件信息
+	c.JSON(http.StatusOK, models.NewSuccessResponse(attachment))
+}
@@ -110,3 +110,63 @@ func (uc *UserController) GetUserInfo(c *gin.Context) {
 	// 3. 返回用户信息
 	c.JSON(http.StatusOK, models.NewSuccessResponse(user))
 }
+
+// GetMentions 获取@我的消息
+// @Summary 获取@我的消息
+// @Description 分页获取当前用户在话题和评论中被@的记录（按时间倒序）
+// @Tags 用户
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} models.Response{data=models.MentionListResponse}
+// @Router /api/v1/user/mentions [get]
+func (uc *UserController) GetMentions(c *gin.Context) {
+	// 1. 绑定查询参数
+	var req models.GetMentionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(models.CodeInvalidParams, "参数错误"))
+		return
+	}
+
+	// 设置默认值
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	// 2. 从context中获取当前用户ID
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse(models.CodeUnauthorized, "用户未登录"))
+		return
+	}
+	userID, ok := userIDVal.(int64)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, "用户ID格式解析错误"))
+		return
+	}
+
+	// 3. 调用逻辑层查询提及列表
+	mentions, total, err := logic.GetUserMentions(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, err.Error()))
+		return
+	}
+	if mentions == nil {
+		mentions = []*models.Mention{}
+	}
+
+	// 4. 计算分页信息并返回
+	totalPages := int((total + int64(req.PageSize) - 1) / int64(req.PageSize))
+	c.JSON(http.StatusOK, models.NewSuccessResponse(models.MentionListResponse{
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+		HasMore:    req.Page < totalPages,
+		Mentions:   mentions,
+	}))
+}
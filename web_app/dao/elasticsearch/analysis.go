@@ -0,0 +1,236 @@
+// Package elasticsearch 中文分词与索引结构定义
+package elasticsearch
+
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 索引结构版本（修改mapping后递增，启动时与现有索引比较并提示重建）
+const (
+	mappingVersion        = 5 // 话题索引
+	commentMappingVersion = 1 // 评论索引
+)
+
+// 中文分词方案
+const (
+	AnalyzerAuto    = "auto"    // 自动检测：优先IK，其次smartcn，都没有时使用CJK二元切分
+	AnalyzerIK      = "ik"      // IK分词插件（analysis-ik），支持自定义词典
+	AnalyzerSmartCN = "smartcn" // 官方smartcn插件（analysis-smartcn）
+	AnalyzerCJK     = "cjk"     // 内置CJK二元切分，无需安装插件
+)
+
+// analyzerPlugins 分词方案依赖的ES插件
+var analyzerPlugins = map[string]string{
+	AnalyzerIK:      "analysis-ik",
+	AnalyzerSmartCN: "analysis-smartcn",
+}
+
+// 索引中使用的自定义分析器名称
+const (
+	topicIndexAnalyzer  = "topic_index"  // 写入时使用（细粒度切分，提高召回）
+	topicSearchAnalyzer = "topic_search" // 搜索时使用（粗粒度切分 + 同义词）
+	topicSynonymFilter  = "topic_synonym"
+)
+
+// AnalysisConfig 分词配置
+type AnalysisConfig struct {
+	Analyzer     string   // 分词方案：auto/ik/smartcn/cjk
+	SynonymsPath string   // 同义词文件路径（相对ES的config目录，需部署到每个节点）
+	Synonyms     []string // 内联同义词规则（Solr格式，如 "golang, go语言"）
+}
+
+// LoadAnalysisConfig 从配置文件读取分词配置
+func LoadAnalysisConfig() AnalysisConfig {
+	cfg := AnalysisConfig{
+		Analyzer:     strings.ToLower(viper.GetString("elasticsearch.analysis.analyzer")),
+		SynonymsPath: viper.GetString("elasticsearch.analysis.synonyms_path"),
+		Synonyms:     viper.GetStringSlice("elasticsearch.analysis.synonyms"),
+	}
+	if cfg.Analyzer == "" {
+		cfg.Analyzer = AnalyzerAuto
+	}
+	return cfg
+}
+
+// resolveAnalyzer 根据已安装的插件确定实际使用的分词方案
+// 指定的插件不存在时降级（不因为缺少插件导致服务无法启动），auto时按 IK > smartcn > CJK 选择
+func resolveAnalyzer(want string, hasPlugin func(name string) (bool, error)) string {
+	candidates := []string{AnalyzerIK, AnalyzerSmartCN}
+	switch want {
+	case AnalyzerCJK:
+		return AnalyzerCJK
+	case AnalyzerIK, AnalyzerSmartCN:
+		candidates = []string{want}
+	case AnalyzerAuto:
+	default:
+		zap.L().Warn("未知的分词方案，使用自动检测", zap.String("analyzer", want))
+	}
+
+	for _, analyzer := range candidates {
+		installed, err := hasPlugin(analyzerPlugins[analyzer])
+		if err != nil {
+			zap.L().Warn("检测ES分词插件失败", zap.String("plugin", analyzerPlugins[analyzer]), zap.Error(err))
+			continue
+		}
+		if installed {
+			return analyzer
+		}
+	}
+
+	if want != AnalyzerAuto {
+		zap.L().Warn("ES未安装指定的分词插件，降级为CJK二元切分", zap.String("analyzer", want))
+	}
+	return AnalyzerCJK
+}
+
+// buildAnalysisSettings 构建 analysis 配置（分析器、分词器、同义词过滤器）
+func buildAnalysisSettings(analyzer string, cfg AnalysisConfig) map[string]interface{} {
+	var indexAnalyzer, searchAnalyzer map[string]interface{}
+	switch analyzer {
+	case AnalyzerIK:
+		indexAnalyzer = customAnalyzer("ik_max_word", "lowercase")
+		searchAnalyzer = customAnalyzer("ik_smart", "lowercase")
+	case AnalyzerSmartCN:
+		indexAnalyzer = customAnalyzer("smartcn_tokenizer", "lowercase")
+		searchAnalyzer = customAnalyzer("smartcn_tokenizer", "lowercase")
+	default:
+		indexAnalyzer = customAnalyzer("standard", "cjk_width", "lowercase", "cjk_bigram")
+		searchAnalyzer = customAnalyzer("standard", "cjk_width", "lowercase", "cjk_bigram")
+	}
+
+	settings := map[string]interface{}{
+		"analyzer": map[string]interface{}{
+			topicIndexAnalyzer:  indexAnalyzer,
+			topicSearchAnalyzer: searchAnalyzer,
+		},
+	}
+
+	// 同义词只在搜索时展开（synonym_graph 只能用于搜索分析器），修改同义词无需重建索引
+	synonym := map[string]interface{}{
+		"type":    "synonym_graph",
+		"lenient": true,
+	}
+	switch {
+	case cfg.SynonymsPath != "":
+		synonym["synonyms_path"] = cfg.SynonymsPath
+		synonym["updateable"] = true
+	case len(cfg.Synonyms) > 0:
+		synonym["synonyms"] = cfg.Synonyms
+	default:
+		return settings
+	}
+	settings["filter"] = map[string]interface{}{topicSynonymFilter: synonym}
+
+	// 同义词需要放在二元切分之前，否则多字同义词无法匹配
+	filters := searchAnalyzer["filter"].([]string)
+	if filters[len(filters)-1] == "cjk_bigram" {
+		filters = append(filters[:len(filters)-1:len(filters)-1], topicSynonymFilter, "cjk_bigram")
+	} else {
+		filters = append(filters, topicSynonymFilter)
+	}
+	searchAnalyzer["filter"] = filters
+	return settings
+}
+
+// customAnalyzer 构建自定义分析器定义
+func customAnalyzer(tokenizer string, filters ...string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":      "custom",
+		"tokenizer": tokenizer,
+		"filter":    filters,
+	}
+}
+
+// buildIndexBody 构建创建索引的完整请求体（settings + mappings）
+func buildIndexBody(analyzer string, cfg AnalysisConfig) map[string]interface{} {
+	dateField := map[string]interface{}{
+		"type":   "date",
+		"format": "yyyy-MM-dd HH:mm:ss||yyyy-MM-dd||epoch_millis",
+	}
+	textField := map[string]interface{}{
+		"type":            "text",
+		"analyzer":        topicIndexAnalyzer,
+		"search_analyzer": topicSearchAnalyzer,
+	}
+	titleField := map[string]interface{}{
+		"type":            "text",
+		"analyzer":        topicIndexAnalyzer,
+		"search_analyzer": topicSearchAnalyzer,
+		"fields": map[string]interface{}{
+			"keyword": map[string]interface{}{
+				"type":         "keyword",
+				"ignore_above": 256,
+			},
+		},
+	}
+
+	return map[string]interface{}{
+		"settings": indexSettings(analyzer, cfg),
+		"mappings": map[string]interface{}{
+			"_meta": indexMeta(mappingVersion, analyzer),
+			"properties": map[string]interface{}{
+				"topic_id":      map[string]interface{}{"type": "keyword"},
+				"user_id":       map[string]interface{}{"type": "keyword"},
+				"username":      map[string]interface{}{"type": "keyword"},
+				"title":         titleField,
+				"content":       textField,
+				"category":      map[string]interface{}{"type": "keyword"},
+				"tags":          map[string]interface{}{"type": "keyword"},
+				"created_at":    dateField,
+				"updated_at":    dateField,
+				"like_count":    map[string]interface{}{"type": "integer"},
+				"dislike_count": map[string]interface{}{"type": "integer"},
+				"view_count":    map[string]interface{}{"type": "integer"},
+				"comment_count": map[string]interface{}{"type": "integer"},
+				"is_pinned":     map[string]interface{}{"type": "boolean"},
+				"pin_scope":     map[string]interface{}{"type": "keyword"},
+				"is_locked":     map[string]interface{}{"type": "boolean"},
+				suggestField:    suggestFieldMapping(),
+			},
+		},
+	}
+}
+
+// buildCommentIndexBody 构建评论索引的请求体（与话题索引使用相同的分词配置）
+func buildCommentIndexBody(analyzer string, cfg AnalysisConfig) map[string]interface{} {
+	return map[string]interface{}{
+		"settings": indexSettings(analyzer, cfg),
+		"mappings": map[string]interface{}{
+			"_meta": indexMeta(commentMappingVersion, analyzer),
+			"properties": map[string]interface{}{
+				"comment_id": map[string]interface{}{"type": "keyword"},
+				"topic_id":   map[string]interface{}{"type": "keyword"},
+				"user_id":    map[string]interface{}{"type": "keyword"},
+				"content": map[string]interface{}{
+					"type":            "text",
+					"analyzer":        topicIndexAnalyzer,
+					"search_analyzer": topicSearchAnalyzer,
+				},
+				"created_at": map[string]interface{}{
+					"type":   "date",
+					"format": "yyyy-MM-dd HH:mm:ss||yyyy-MM-dd||epoch_millis",
+				},
+			},
+		},
+	}
+}
+
+// indexSettings 索引的 settings（分片和分词配置）
+func indexSettings(analyzer string, cfg AnalysisConfig) map[string]interface{} {
+	return map[string]interface{}{
+		"number_of_shards":   1,
+		"number_of_replicas": 0,
+		"analysis":           buildAnalysisSettings(analyzer, cfg),
+	}
+}
+
+// indexMeta 记录结构版本和分词方案，启动时用于判断是否需要重建索引
+func indexMeta(version int, analyzer string) map[string]interface{} {
+	return map[string]interface{}{
+		"mapping_version": version,
+		"analyzer":        analyzer,
+	}
+}
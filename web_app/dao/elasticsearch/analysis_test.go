@@ -0,0 +1,154 @@
+package elasticsearch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePlugins 模拟ES已安装的插件
+func fakePlugins(installed ...string) func(string) (bool, error) {
+	return func(name string) (bool, error) {
+		for _, p := range installed {
+			if p == name {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// TestResolveAnalyzer 测试根据已安装插件选择分词方案
+func TestResolveAnalyzer(t *testing.T) {
+	tests := []struct {
+		name      string
+		want      string
+		installed []string
+		expected  string
+	}{
+		{"自动-IK优先", AnalyzerAuto, []string{"analysis-ik", "analysis-smartcn"}, AnalyzerIK},
+		{"自动-只有smartcn", AnalyzerAuto, []string{"analysis-smartcn"}, AnalyzerSmartCN},
+		{"自动-无插件降级", AnalyzerAuto, nil, AnalyzerCJK},
+		{"指定IK", AnalyzerIK, []string{"analysis-ik"}, AnalyzerIK},
+		{"指定IK但缺少插件", AnalyzerIK, []string{"analysis-smartcn"}, AnalyzerCJK},
+		{"指定smartcn", AnalyzerSmartCN, []string{"analysis-ik", "analysis-smartcn"}, AnalyzerSmartCN},
+		{"指定CJK", AnalyzerCJK, []string{"analysis-ik"}, AnalyzerCJK},
+		{"未知方案按自动处理", "jieba", []string{"analysis-ik"}, AnalyzerIK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, resolveAnalyzer(tt.want, fakePlugins(tt.installed...)))
+		})
+	}
+}
+
+// TestResolveAnalyzer_PluginCheckError 测试插件检测失败时降级
+func TestResolveAnalyzer_PluginCheckError(t *testing.T) {
+	failing := func(string) (bool, error) { return false, errors.New("connection refused") }
+	assert.Equal(t, AnalyzerCJK, resolveAnalyzer(AnalyzerAuto, failing))
+}
+
+// analyzerDef 从analysis配置中取出分析器定义
+func analyzerDef(t *testing.T, settings map[string]interface{}, name string) map[string]interface{} {
+	t.Helper()
+	analyzers, ok := settings["analyzer"].(map[string]interface{})
+	require.True(t, ok)
+	def, ok := analyzers[name].(map[string]interface{})
+	require.True(t, ok, "缺少分析器 %s", name)
+	return def
+}
+
+// TestBuildAnalysisSettings_Tokenizers 测试各分词方案使用的分词器
+func TestBuildAnalysisSettings_Tokenizers(t *testing.T) {
+	tests := []struct {
+		analyzer        string
+		indexTokenizer  string
+		searchTokenizer string
+	}{
+		{AnalyzerIK, "ik_max_word", "ik_smart"},
+		{AnalyzerSmartCN, "smartcn_tokenizer", "smartcn_tokenizer"},
+		{AnalyzerCJK, "standard", "standard"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.analyzer, func(t *testing.T) {
+			settings := buildAnalysisSettings(tt.analyzer, AnalysisConfig{})
+			assert.Equal(t, tt.indexTokenizer, analyzerDef(t, settings, topicIndexAnalyzer)["tokenizer"])
+			assert.Equal(t, tt.searchTokenizer, analyzerDef(t, settings, topicSearchAnalyzer)["tokenizer"])
+			assert.NotContains(t, settings, "filter", "未配置同义词时不应生成同义词过滤器")
+		})
+	}
+}
+
+// TestBuildAnalysisSettings_Synonyms 测试同义词只用于搜索分析器
+func TestBuildAnalysisSettings_Synonyms(t *testing.T) {
+	t.Run("同义词文件", func(t *testing.T) {
+		settings := buildAnalysisSettings(AnalyzerIK, AnalysisConfig{SynonymsPath: "analysis/synonyms.txt"})
+
+		filter := settings["filter"].(map[string]interface{})[topicSynonymFilter].(map[string]interface{})
+		assert.Equal(t, "synonym_graph", filter["type"])
+		assert.Equal(t, "analysis/synonyms.txt", filter["synonyms_path"])
+		assert.Equal(t, true, filter["updateable"])
+
+		assert.Equal(t, []string{"lowercase", topicSynonymFilter}, analyzerDef(t, settings, topicSearchAnalyzer)["filter"])
+		assert.Equal(t, []string{"lowercase"}, analyzerDef(t, settings, topicIndexAnalyzer)["filter"])
+	})
+
+	t.Run("内联同义词", func(t *testing.T) {
+		settings := buildAnalysisSettings(AnalyzerSmartCN, AnalysisConfig{Synonyms: []string{"golang, go语言"}})
+		filter := settings["filter"].(map[string]interface{})[topicSynonymFilter].(map[string]interface{})
+		assert.Equal(t, []string{"golang, go语言"}, filter["synonyms"])
+	})
+
+	t.Run("CJK同义词在二元切分之前", func(t *testing.T) {
+		settings := buildAnalysisSettings(AnalyzerCJK, AnalysisConfig{Synonyms: []string{"k8s, kubernetes"}})
+		assert.Equal(t,
+			[]string{"cjk_width", "lowercase", topicSynonymFilter, "cjk_bigram"},
+			analyzerDef(t, settings, topicSearchAnalyzer)["filter"])
+		assert.Equal(t,
+			[]string{"cjk_width", "lowercase", "cjk_bigram"},
+			analyzerDef(t, settings, topicIndexAnalyzer)["filter"])
+	})
+}
+
+// TestBuildIndexBody 测试索引结构记录版本信息并使用自定义分析器
+func TestBuildIndexBody(t *testing.T) {
+	body := buildIndexBody(AnalyzerIK, AnalysisConfig{})
+
+	mappings := body["mappings"].(map[string]interface{})
+	meta := mappings["_meta"].(map[string]interface{})
+	assert.Equal(t, mappingVersion, meta["mapping_version"])
+	assert.Equal(t, AnalyzerIK, meta["analyzer"])
+
+	properties := mappings["properties"].(map[string]interface{})
+	for _, field := range []string{"title", "content"} {
+		def := properties[field].(map[string]interface{})
+		assert.Equal(t, topicIndexAnalyzer, def["analyzer"])
+		assert.Equal(t, topicSearchAnalyzer, def["search_analyzer"])
+	}
+	assert.Contains(t, properties, "like_count")
+	assert.Contains(t, properties, "dislike_count")
+	assert.Equal(t, "keyword", properties["username"].(map[string]interface{})["type"])
+	assert.Equal(t, "completion", properties[suggestField].(map[string]interface{})["type"])
+}
+
+// TestBuildCommentIndexBody 测试评论索引与话题索引使用相同的分析器
+func TestBuildCommentIndexBody(t *testing.T) {
+	body := buildCommentIndexBody(AnalyzerCJK, AnalysisConfig{Synonyms: []string{"k8s, kubernetes"}})
+
+	settings := body["settings"].(map[string]interface{})
+	assert.Equal(t, buildAnalysisSettings(AnalyzerCJK, AnalysisConfig{Synonyms: []string{"k8s, kubernetes"}}), settings["analysis"])
+
+	mappings := body["mappings"].(map[string]interface{})
+	meta := mappings["_meta"].(map[string]interface{})
+	assert.Equal(t, commentMappingVersion, meta["mapping_version"])
+
+	properties := mappings["properties"].(map[string]interface{})
+	content := properties["content"].(map[string]interface{})
+	assert.Equal(t, topicIndexAnalyzer, content["analyzer"])
+	assert.Equal(t, topicSearchAnalyzer, content["search_analyzer"])
+	assert.Equal(t, map[string]interface{}{"type": "keyword"}, properties["topic_id"])
+}
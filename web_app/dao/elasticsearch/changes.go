@@ -0,0 +1,213 @@
+// Package elasticsearch 批量应用增量变更（Canal同步）
+package elasticsearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"web_app/models"
+
+	"github.com/olivere/elastic/v7"
+	"go.uber.org/zap"
+)
+
+// ErrRejected ES拒绝写入文档（mapping冲突等4xx错误），重试也不会成功
+var ErrRejected = errors.New("ES拒绝写入变更")
+
+// TopicChange 一条话题变更：写入完整文档或删除
+type TopicChange struct {
+	TopicID int64
+	Topic   *models.Topic // 删除时为nil
+	Deleted bool
+	Version int64 // 外部版本号（binlog执行时间，毫秒），<=0 时不做版本控制
+}
+
+// CommentChange 一条评论变更：写入完整文档或删除
+type CommentChange struct {
+	CommentID int64
+	Comment   *models.Comment // 删除时为nil
+	Deleted   bool
+	Version   int64 // 外部版本号（binlog执行时间，毫秒），<=0 时不做版本控制
+}
+
+// ChangeSet 一批合并后的变更，每个文档最多一条
+type ChangeSet struct {
+	Topics        []*TopicChange
+	Comments      []*CommentChange
+	CommentCounts map[int64]int // 话题ID -> 最新评论数
+}
+
+// ChangeResult 批量应用的结果
+type ChangeResult struct {
+	Applied int // 写入成功的文档数
+	Stale   int // 版本比索引中旧而被忽略的文档数（乱序或重复投递的消息）
+}
+
+// ApplyChanges 用一次bulk请求应用一批变更（重建索引期间再用一次bulk请求写入新索引）
+// 文档按外部版本号写入，旧版本的变更不会覆盖新数据，重复应用同一批变更结果不变
+func ApplyChanges(ctx context.Context, changes *ChangeSet) (ChangeResult, error) {
+	if err := checkAvailable(); err != nil {
+		return ChangeResult{}, err
+	}
+
+	requests := buildChangeRequests(changes, index, commentIndex)
+	if len(requests) == 0 {
+		return ChangeResult{}, nil
+	}
+
+	response, err := client.Bulk().
+		Add(requests...).
+		Refresh("wait_for"). // 返回时变更已可搜索
+		Do(ctx)
+	if err != nil {
+		return ChangeResult{}, err
+	}
+
+	result, err := checkChangeResponse(response)
+	if err != nil {
+		zap.L().Error("批量应用变更失败", zap.Error(err))
+		return result, err
+	}
+
+	if target := DualWriteTarget(); target != "" {
+		dualWriteChanges(ctx, changes, target)
+	}
+	return result, nil
+}
+
+// dualWriteChanges 将话题变更写入重建中的新索引
+// 新索引导入期间关闭了自动刷新，不能等待刷新（wait_for会一直阻塞到导入结束）；
+// 失败只记录日志，重建任务在切换别名前会补齐导入期间修改的话题
+func dualWriteChanges(ctx context.Context, changes *ChangeSet, target string) {
+	requests := buildChangeRequests(changes, target, "")
+	if len(requests) == 0 {
+		return
+	}
+
+	response, err := client.Bulk().Add(requests...).Do(ctx)
+	if err == nil {
+		_, err = checkChangeResponse(response)
+	}
+	if err != nil {
+		zap.L().Warn("双写变更失败", zap.Error(err), zap.String("index", target))
+	}
+}
+
+// buildChangeRequests 将变更转换为bulk请求
+// 话题写入/删除发往 topicIdx；评论数使用局部更新，文档不存在时跳过；commentIdx 为空时不写入评论
+func buildChangeRequests(changes *ChangeSet, topicIdx, commentIdx string) []elastic.BulkableRequest {
+	requests := make([]elastic.BulkableRequest, 0, len(changes.Topics)+len(changes.Comments)+len(changes.CommentCounts))
+
+	for _, change := range changes.Topics {
+		id := strconv.FormatInt(change.TopicID, 10)
+		if change.Deleted {
+			requests = append(requests, withVersion(elastic.NewBulkDeleteRequest().Index(topicIdx).Id(id), change.Version))
+			continue
+		}
+		doc := newTopicDocument(change.Topic)
+		// 评论数以本批统计的最新值为准
+		if count, ok := changes.CommentCounts[change.TopicID]; ok {
+			doc.CommentCount = count
+		}
+		requests = append(requests, withIndexVersion(elastic.NewBulkIndexRequest().Index(topicIdx).Id(id).Doc(doc), change.Version))
+	}
+
+	// 本批有完整写入或删除的话题已在上面处理评论数
+	changed := make(map[int64]bool, len(changes.Topics))
+	for _, change := range changes.Topics {
+		changed[change.TopicID] = true
+	}
+	for topicID, count := range changes.CommentCounts {
+		if changed[topicID] {
+			continue
+		}
+		requests = append(requests, elastic.NewBulkUpdateRequest().Index(topicIdx).Id(strconv.FormatInt(topicID, 10)).
+			Doc(map[string]interface{}{"comment_count": count}).
+			RetryOnConflict(3))
+	}
+
+	if commentIdx == "" {
+		return requests
+	}
+	for _, change := range changes.Comments {
+		id := strconv.FormatInt(change.CommentID, 10)
+		if change.Deleted {
+			requests = append(requests, withVersion(elastic.NewBulkDeleteRequest().Index(commentIdx).Id(id), change.Version))
+			continue
+		}
+		requests = append(requests, withIndexVersion(
+			elastic.NewBulkIndexRequest().Index(commentIdx).Id(id).Doc(newCommentDocument(change.Comment)),
+			change.Version))
+	}
+	return requests
+}
+
+// withIndexVersion 为写入请求设置外部版本号
+// 使用 external_gte：同一毫秒内的多次修改在跨批次时也能写入（同一批次内已按顺序合并）
+func withIndexVersion(req *elastic.BulkIndexRequest, version int64) *elastic.BulkIndexRequest {
+	if version <= 0 {
+		return req
+	}
+	return req.Version(version).VersionType("external_gte")
+}
+
+// withVersion 为删除请求设置外部版本号（删除后的墓碑会拒绝稍后到达的旧写入）
+func withVersion(req *elastic.BulkDeleteRequest, version int64) *elastic.BulkDeleteRequest {
+	if version <= 0 {
+		return req
+	}
+	return req.Version(version).VersionType("external_gte")
+}
+
+// checkChangeResponse 统计bulk结果：版本冲突视为旧消息被忽略，删除或更新不存在的文档不算失败
+// 其他失败返回错误（整批重试，已写入的文档因版本号相同不会再变化）
+// 失败全部是文档被拒绝（429以外的4xx）时错误包装 ErrRejected
+func checkChangeResponse(response *elastic.BulkResponse) (ChangeResult, error) {
+	var result ChangeResult
+	var failed []*elastic.BulkResponseItem
+	rejected := true
+	for _, item := range flattenBulkItems(response) {
+		switch {
+		case item.Status >= 200 && item.Status < 300:
+			result.Applied++
+		case item.Status == http.StatusConflict:
+			result.Stale++
+		case item.Status == http.StatusNotFound:
+			// 删除不存在的文档，或文档尚未写入时更新评论数
+		default:
+			failed = append(failed, item)
+			if item.Status < 400 || item.Status >= 500 || item.Status == http.StatusTooManyRequests {
+				rejected = false
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		reason := "未知错误"
+		if failed[0].Error != nil {
+			reason = failed[0].Error.Reason
+		}
+		err := fmt.Errorf("%d个变更写入失败，首个错误: %s/%s: %s", len(failed), failed[0].Index, failed[0].Id, reason)
+		if rejected {
+			err = fmt.Errorf("%w: %w", ErrRejected, err)
+		}
+		return result, err
+	}
+	return result, nil
+}
+
+// flattenBulkItems 展开bulk响应中的每条结果
+func flattenBulkItems(response *elastic.BulkResponse) []*elastic.BulkResponseItem {
+	if response == nil {
+		return nil
+	}
+	items := make([]*elastic.BulkResponseItem, 0, len(response.Items))
+	for _, m := range response.Items {
+		for _, item := range m {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,122 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"testing"
+	"web_app/models"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requestLines 解析bulk请求的动作行和文档行
+func requestLines(t *testing.T, req elastic.BulkableRequest) []map[string]interface{} {
+	source, err := req.Source()
+	require.NoError(t, err)
+	lines := make([]map[string]interface{}, 0, len(source))
+	for _, line := range source {
+		var m map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &m))
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+// TestBuildChangeRequests 测试变更转换为带外部版本号的bulk请求
+func TestBuildChangeRequests(t *testing.T) {
+	changes := &ChangeSet{
+		Topics: []*TopicChange{
+			{TopicID: 1, Topic: &models.Topic{ID: 1, Title: "a", CommentCount: 1}, Version: 1000},
+			{TopicID: 2, Deleted: true, Version: 2000},
+		},
+		Comments: []*CommentChange{
+			{CommentID: 10, Comment: &models.Comment{ID: 10, TopicID: 1}, Version: 3000},
+		},
+		CommentCounts: map[int64]int{1: 5, 2: 0, 3: 7},
+	}
+
+	requests := buildChangeRequests(changes, "topics_v1", "comments_v1")
+	// 话题1写入、话题2删除，话题3只更新评论数（话题2已删除，不再更新），评论1条
+	require.Len(t, requests, 4)
+
+	index := requestLines(t, requests[0])
+	action := index[0]["index"].(map[string]interface{})
+	assert.Equal(t, "topics_v1", action["_index"])
+	assert.Equal(t, "1", action["_id"])
+	assert.EqualValues(t, 1000, action["version"])
+	assert.Equal(t, "external_gte", action["version_type"])
+	// 评论数以本批统计值为准
+	assert.EqualValues(t, 5, index[1]["comment_count"])
+
+	del := requestLines(t, requests[1])[0]["delete"].(map[string]interface{})
+	assert.Equal(t, "2", del["_id"])
+	assert.EqualValues(t, 2000, del["version"])
+
+	update := requestLines(t, requests[2])
+	assert.Equal(t, "3", update[0]["update"].(map[string]interface{})["_id"])
+	assert.Equal(t, map[string]interface{}{"comment_count": float64(7)}, update[1]["doc"])
+
+	comment := requestLines(t, requests[3])[0]["index"].(map[string]interface{})
+	assert.Equal(t, "comments_v1", comment["_index"])
+	assert.EqualValues(t, 3000, comment["version"])
+
+	// 双写新索引时只写话题
+	dual := buildChangeRequests(changes, "topics_v2", "")
+	require.Len(t, dual, 3)
+	assert.Equal(t, "topics_v2", requestLines(t, dual[0])[0]["index"].(map[string]interface{})["_index"])
+}
+
+// TestBuildChangeRequests_NoVersion 测试没有版本号时不做版本控制
+func TestBuildChangeRequests_NoVersion(t *testing.T) {
+	changes := &ChangeSet{Topics: []*TopicChange{{TopicID: 1, Topic: &models.Topic{ID: 1}}}}
+	requests := buildChangeRequests(changes, "topics_v1", "comments_v1")
+	require.Len(t, requests, 1)
+
+	action := requestLines(t, requests[0])[0]["index"].(map[string]interface{})
+	assert.NotContains(t, action, "version")
+	assert.NotContains(t, action, "version_type")
+}
+
+func changeItem(op, idx, id string, status int) map[string]*elastic.BulkResponseItem {
+	item := &elastic.BulkResponseItem{Index: idx, Id: id, Status: status}
+	if status >= 300 {
+		item.Error = &elastic.ErrorDetails{Reason: "failed"}
+	}
+	return map[string]*elastic.BulkResponseItem{op: item}
+}
+
+// TestCheckChangeResponse 测试bulk结果统计
+func TestCheckChangeResponse(t *testing.T) {
+	t.Run("版本冲突视为旧消息", func(t *testing.T) {
+		resp := &elastic.BulkResponse{Errors: true, Items: []map[string]*elastic.BulkResponseItem{
+			changeItem("index", "topics_v1", "1", 201),
+			changeItem("index", "topics_v1", "2", 409),
+			changeItem("delete", "topics_v1", "3", 404),
+			changeItem("update", "topics_v1", "4", 404),
+		}}
+		result, err := checkChangeResponse(resp)
+		require.NoError(t, err)
+		assert.Equal(t, ChangeResult{Applied: 1, Stale: 1}, result)
+	})
+
+	t.Run("其他失败返回错误", func(t *testing.T) {
+		resp := &elastic.BulkResponse{Errors: true, Items: []map[string]*elastic.BulkResponseItem{
+			changeItem("index", "topics_v1", "1", 429),
+			changeItem("index", "comments_v1", "2", 400),
+		}}
+		_, err := checkChangeResponse(resp)
+		require.Error(t, err)
+		assert.Equal(t, "2个变更写入失败，首个错误: topics_v1/1: failed", err.Error())
+		assert.NotErrorIs(t, err, ErrRejected, "429需要重试")
+	})
+
+	t.Run("文档被拒绝", func(t *testing.T) {
+		resp := &elastic.BulkResponse{Errors: true, Items: []map[string]*elastic.BulkResponseItem{
+			changeItem("index", "topics_v1", "1", 200),
+			changeItem("index", "comments_v1", "2", 400),
+		}}
+		_, err := checkChangeResponse(resp)
+		assert.ErrorIs(t, err, ErrRejected)
+	})
+}
@@ -3,7 +3,9 @@ package elasticsearch
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/olivere/elastic/v7"
 	"github.com/spf13/viper"
@@ -11,18 +13,51 @@ import (
 )
 
 var (
-	client *elastic.Client
-	index  string
+	client       *elastic.Client
+	index        string         // 话题索引别名
+	commentIndex string         // 评论索引别名
+	scoring      ScoringConfig  // 综合排序打分配置
+	analysis     AnalysisConfig // 分词配置
+	analyzer     string         // 实际使用的分词方案（根据已安装插件确定）
 )
 
-// Init 初始化Elasticsearch客户端
+// connected 是否已连接（连接完成后才置为true，之后并发读取client等变量是安全的）
+var connected atomic.Bool
+
+// ErrUnavailable Elasticsearch未连接（启动时初始化失败且尚未重连成功），搜索降级为MySQL全文检索
+var ErrUnavailable = errors.New("Elasticsearch不可用")
+
+// Init 初始化Elasticsearch客户端，失败时客户端保持未连接状态（Available返回false），可以再次调用重试
+// 已连接时直接返回；重试时不能与其他Init调用并发执行
 func Init() error {
+	if connected.Load() {
+		return nil
+	}
+	if err := connect(); err != nil {
+		if client != nil {
+			client.Stop()
+			client = nil
+		}
+		return err
+	}
+	connected.Store(true)
+	return nil
+}
+
+// connect 连接Elasticsearch并确保索引可用
+func connect() error {
 	var err error
 
 	// 从配置读取ES地址
 	esURL := viper.GetString("elasticsearch.url")
 	index = viper.GetString("elasticsearch.index")
+	commentIndex = viper.GetString("elasticsearch.comment_index")
+	if commentIndex == "" {
+		commentIndex = index + "_comments"
+	}
 	sniff := viper.GetBool("elasticsearch.sniff")
+	scoring = LoadScoringConfig()
+	analysis = LoadAnalysisConfig()
 
 	zap.L().Info("正在连接Elasticsearch", zap.String("url", esURL), zap.String("index", index))
 
@@ -48,8 +83,12 @@ func Init() error {
 		zap.String("version", info.Version.Number),
 		zap.Int("code", code))
 
-	// 创建索引（如果不存在）
-	err = createIndex(ctx)
+	// 确定分词方案（缺少插件时自动降级）
+	analyzer = resolveAnalyzer(analysis.Analyzer, client.HasPlugin)
+	zap.L().Info("中文分词方案", zap.String("configured", analysis.Analyzer), zap.String("analyzer", analyzer))
+
+	// 确保索引别名可用（不存在时创建版本化索引）
+	err = ensureIndex(ctx)
 	if err != nil {
 		return fmt.Errorf("创建索引失败: %w", err)
 	}
@@ -57,102 +96,30 @@ func Init() error {
 	return nil
 }
 
-// createIndex 创建话题索引
-func createIndex(ctx context.Context) error {
-	// 检查索引是否存在
-	exists, err := client.IndexExists(index).Do(ctx)
-	if err != nil {
-		return err
-	}
+// Available 是否已连接Elasticsearch
+func Available() bool {
+	return connected.Load()
+}
 
-	if exists {
-		zap.L().Info("索引已存在", zap.String("index", index))
-		return nil
+// checkAvailable 未连接时返回ErrUnavailable（避免使用空客户端）
+func checkAvailable() error {
+	if !connected.Load() {
+		return ErrUnavailable
 	}
+	return nil
+}
 
-	// 定义索引mapping（优化中文搜索）
-	mapping := `{
-		"settings": {
-			"number_of_shards": 1,
-			"number_of_replicas": 0,
-			"analysis": {
-				"analyzer": {
-					"default": {
-						"type": "standard"
-					},
-					"ngram_analyzer": {
-						"type": "custom",
-						"tokenizer": "ngram_tokenizer",
-						"filter": ["lowercase"]
-					}
-				},
-				"tokenizer": {
-					"ngram_tokenizer": {
-						"type": "ngram",
-						"min_gram": 2,
-						"max_gram": 3,
-						"token_chars": ["letter", "digit"]
-					}
-				}
-			}
-		},
-		"mappings": {
-			"properties": {
-				"topic_id": {
-					"type": "keyword"
-				},
-				"user_id": {
-					"type": "keyword"
-				},
-				"title": {
-					"type": "text",
-					"analyzer": "ngram_analyzer",
-					"search_analyzer": "standard",
-					"fields": {
-						"keyword": {
-							"type": "keyword",
-							"ignore_above": 256
-						}
-					}
-				},
-				"content": {
-					"type": "text",
-					"analyzer": "ngram_analyzer",
-					"search_analyzer": "standard"
-				},
-				"category": {
-					"type": "keyword"
-				},
-				"created_at": {
-					"type": "date",
-					"format": "yyyy-MM-dd HH:mm:ss||yyyy-MM-dd||epoch_millis"
-				},
-				"updated_at": {
-					"type": "date",
-					"format": "yyyy-MM-dd HH:mm:ss||yyyy-MM-dd||epoch_millis"
-				},
-				"view_count": {
-					"type": "integer"
-				},
-				"comment_count": {
-					"type": "integer"
-				}
-			}
-		}
-	}`
-
-	// 创建索引
-	createIndex, err := client.CreateIndex(index).BodyString(mapping).Do(ctx)
-	if err != nil {
-		return err
+// IsUnavailable 错误是否说明ES本身不可用：未连接、网络错误、超时、限流或5xx
+// 查询本身有误等其他4xx错误换一个节点也不会成功，不算不可用
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
 	}
-
-	if !createIndex.Acknowledged {
-		return fmt.Errorf("索引创建未被确认")
+	var esErr *elastic.Error
+	if errors.As(err, &esErr) {
+		return esErr.Status >= 500 || esErr.Status == 429
 	}
-
-	zap.L().Info("索引创建成功", zap.String("index", index))
-	return nil
+	return true
 }
 
 // GetClient 获取ES客户端
@@ -160,7 +127,7 @@ func GetClient() *elastic.Client {
 	return client
 }
 
-// GetIndex 获取索引名称
+// GetIndex 获取索引名称（别名）
 func GetIndex() string {
 	return index
 }
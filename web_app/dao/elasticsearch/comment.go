@@ -0,0 +1,171 @@
+// Package elasticsearch 评论索引与搜索
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"web_app/models"
+
+	"github.com/olivere/elastic/v7"
+	"go.uber.org/zap"
+)
+
+// CommentDocument ES中的评论文档结构
+type CommentDocument struct {
+	CommentID string `json:"comment_id"`
+	TopicID   string `json:"topic_id"`
+	UserID    string `json:"user_id"`
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"` // 使用string以匹配ES的日期格式
+}
+
+// newCommentDocument 将评论转换为ES文档
+func newCommentDocument(comment *models.Comment) CommentDocument {
+	return CommentDocument{
+		CommentID: fmt.Sprintf("%d", comment.ID),
+		TopicID:   fmt.Sprintf("%d", comment.TopicID),
+		UserID:    fmt.Sprintf("%d", comment.UserID),
+		Content:   comment.Content,
+		CreatedAt: comment.CreatedAt.Format(esTimeFormat),
+	}
+}
+
+// DeleteCommentsByTopic 删除话题下的全部评论
+// 删除话题时评论由外键级联删除，级联删除不产生binlog，需要按话题清理
+func DeleteCommentsByTopic(topicID int64) error {
+	if err := checkAvailable(); err != nil {
+		return err
+	}
+	id := fmt.Sprintf("%d", topicID)
+	result, err := client.DeleteByQuery(commentIndex).
+		Query(elastic.NewTermQuery("topic_id", id)).
+		Conflicts("proceed").
+		Do(context.Background())
+	if err != nil {
+		zap.L().Error("删除话题评论索引失败", zap.Error(err), zap.String("topic_id", id))
+		return err
+	}
+	zap.L().Debug("话题评论索引已删除", zap.String("topic_id", id), zap.Int64("deleted", result.Deleted))
+	return nil
+}
+
+// CommentSearchRequest 评论搜索请求参数
+type CommentSearchRequest struct {
+	Keyword  string // 搜索关键词（必填）
+	TopicID  string // 只搜索指定话题下的评论（可选）
+	Page     int    // 页码
+	PageSize int    // 每页数量
+}
+
+// CommentHit 单条评论命中（文档不含content，内容以高亮摘要返回）
+type CommentHit struct {
+	Comment *CommentDocument // 评论文档
+	Snippet string           // 内容摘要（已HTML转义）
+}
+
+// CommentSearchResponse 评论搜索响应
+type CommentSearchResponse struct {
+	Total int64         // 总数
+	Hits  []*CommentHit // 命中列表
+	Took  int64         // 耗时(毫秒)
+}
+
+// newCommentMatchQuery 构建评论内容的全文匹配查询
+func newCommentMatchQuery(keyword string) *elastic.MatchQuery {
+	return elastic.NewMatchQuery("content", keyword).
+		Operator("OR").
+		MinimumShouldMatch("30%") // 与话题搜索保持一致
+}
+
+// newCommentHighlight 构建评论摘要高亮配置
+func newCommentHighlight() *elastic.Highlight {
+	return elastic.NewHighlight().
+		Encoder("html").
+		PreTags(highlightPreTag).
+		PostTags(highlightPostTag).
+		Fields(elastic.NewHighlighterField("content").FragmentSize(snippetSize).NumOfFragments(1).NoMatchSize(snippetSize))
+}
+
+// buildCommentSearchSource 构建评论搜索DSL
+func buildCommentSearchSource(req *CommentSearchRequest) *elastic.SearchSource {
+	boolQuery := elastic.NewBoolQuery().Must(newCommentMatchQuery(req.Keyword))
+	if req.TopicID != "" {
+		boolQuery = boolQuery.Filter(elastic.NewTermQuery("topic_id", req.TopicID))
+	}
+
+	return elastic.NewSearchSource().
+		Query(boolQuery).
+		From((req.Page-1)*req.PageSize).
+		Size(req.PageSize).
+		SortBy(elastic.NewScoreSort(), elastic.NewFieldSort("created_at").Desc()).
+		Highlight(newCommentHighlight()).
+		FetchSourceContext(elastic.NewFetchSourceContext(true).Exclude("content"))
+}
+
+// buildCommentTopicsSource 构建“通过评论命中话题”的DSL：按话题折叠，每个话题只取最相关的一条评论
+func buildCommentTopicsSource(keyword string, size int) *elastic.SearchSource {
+	return elastic.NewSearchSource().
+		Query(newCommentMatchQuery(keyword)).
+		Collapse(elastic.NewCollapseBuilder("topic_id")).
+		Size(size).
+		Highlight(newCommentHighlight()).
+		FetchSourceContext(elastic.NewFetchSourceContext(true).Exclude("content"))
+}
+
+// SearchComments 搜索评论
+func SearchComments(req *CommentSearchRequest) (*CommentSearchResponse, error) {
+	if err := checkAvailable(); err != nil {
+		return nil, err
+	}
+	result, err := client.Search().
+		Index(commentIndex).
+		SearchSource(buildCommentSearchSource(req)).
+		Do(context.Background())
+	if err != nil {
+		zap.L().Error("搜索评论失败", zap.Error(err), zap.String("keyword", req.Keyword))
+		return nil, err
+	}
+
+	return &CommentSearchResponse{
+		Total: result.TotalHits(),
+		Hits:  parseCommentHits(result),
+		Took:  result.TookInMillis,
+	}, nil
+}
+
+// MatchCommentTopics 搜索评论命中的话题，返回每个话题最相关的一条评论（按相关度排序）
+func MatchCommentTopics(keyword string, size int) ([]*CommentHit, error) {
+	if err := checkAvailable(); err != nil {
+		return nil, err
+	}
+	result, err := client.Search().
+		Index(commentIndex).
+		SearchSource(buildCommentTopicsSource(keyword, size)).
+		Do(context.Background())
+	if err != nil {
+		zap.L().Error("按评论搜索话题失败", zap.Error(err), zap.String("keyword", keyword))
+		return nil, err
+	}
+	return parseCommentHits(result), nil
+}
+
+// parseCommentHits 解析评论命中
+func parseCommentHits(result *elastic.SearchResult) []*CommentHit {
+	hits := make([]*CommentHit, 0)
+	if result.Hits == nil {
+		return hits
+	}
+	for _, hit := range result.Hits.Hits {
+		var doc CommentDocument
+		if err := json.Unmarshal(hit.Source, &doc); err != nil {
+			zap.L().Error("解析评论搜索结果失败", zap.Error(err))
+			continue
+		}
+		hits = append(hits, &CommentHit{
+			Comment: &doc,
+			Snippet: firstFragment(hit.Highlight, "content"),
+		})
+	}
+	return hits
+}
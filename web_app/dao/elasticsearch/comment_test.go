@@ -0,0 +1,70 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// searchSourceMap 将查询DSL序列化后再解析为map，便于断言
+func searchSourceMap(t *testing.T, source *elastic.SearchSource) map[string]interface{} {
+	t.Helper()
+	src, err := source.Source()
+	require.NoError(t, err)
+	data, err := json.Marshal(src)
+	require.NoError(t, err)
+
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &m))
+	return m
+}
+
+// TestBuildCommentSearchSource 测试评论搜索DSL
+func TestBuildCommentSearchSource(t *testing.T) {
+	m := searchSourceMap(t, buildCommentSearchSource(&CommentSearchRequest{
+		Keyword:  "并发",
+		TopicID:  "42",
+		Page:     2,
+		PageSize: 10,
+	}))
+
+	assert.EqualValues(t, 10, m["from"])
+	assert.EqualValues(t, 10, m["size"])
+	assert.Equal(t, map[string]interface{}{"excludes": []interface{}{"content"}}, m["_source"])
+	assert.Contains(t, m["highlight"].(map[string]interface{})["fields"], "content")
+
+	boolQuery := m["query"].(map[string]interface{})["bool"].(map[string]interface{})
+	match := boolQuery["must"].(map[string]interface{})["match"].(map[string]interface{})["content"].(map[string]interface{})
+	assert.Equal(t, "并发", match["query"])
+	assert.Equal(t, map[string]interface{}{"term": map[string]interface{}{"topic_id": "42"}}, boolQuery["filter"])
+	assert.Equal(t, []string{"_score", "created_at"}, sortFields(t, m))
+}
+
+// TestBuildCommentTopicsSource 测试按话题折叠的评论查询
+func TestBuildCommentTopicsSource(t *testing.T) {
+	m := searchSourceMap(t, buildCommentTopicsSource("并发", 100))
+
+	assert.EqualValues(t, 100, m["size"])
+	assert.Equal(t, map[string]interface{}{"field": "topic_id"}, m["collapse"])
+	assert.Contains(t, m["query"], "match")
+}
+
+// TestBuildSearchSource_WithComments 测试综合模式：正文命中或评论命中其一即可
+func TestBuildSearchSource_WithComments(t *testing.T) {
+	req := &SearchRequest{Keyword: "并发", CommentTopicIDs: []string{"1", "2"}, Page: 1, PageSize: 20, SortBy: SortByRelevance}
+	m := sourceMap(t, req, DefaultScoringConfig())
+
+	boolQuery := m["query"].(map[string]interface{})["bool"].(map[string]interface{})
+	assert.NotContains(t, boolQuery, "must")
+	assert.EqualValues(t, "1", boolQuery["minimum_should_match"])
+
+	should := boolQuery["should"].([]interface{})
+	require.Len(t, should, 2)
+	assert.Contains(t, should[0], "multi_match")
+	terms := should[1].(map[string]interface{})["terms"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"1", "2"}, terms["topic_id"])
+	assert.Equal(t, commentMatchBoost, terms["boost"])
+}
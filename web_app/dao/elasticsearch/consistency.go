@@ -0,0 +1,71 @@
+// Package elasticsearch MySQL与ES一致性检查使用的校验和
+package elasticsearch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"web_app/models"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// TopicChecksum 计算话题参与索引的字段的校验和（与ES中文档的校验和可直接比较）
+func TopicChecksum(topic *models.Topic) string {
+	return documentChecksum(newTopicDocument(topic))
+}
+
+// documentChecksum 计算文档的校验和
+// 标题补全字段由其他字段派生，不参与计算；空标签统一为nil（写入时可能是null或[]）
+func documentChecksum(doc TopicDocument) string {
+	doc.TitleSuggest = nil
+	if len(doc.Tags) == 0 {
+		doc.Tags = nil
+	}
+	data, _ := json.Marshal(doc) // 字段都是基本类型，不会失败
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// GetTopicChecksums 批量读取话题文档并计算校验和，不存在的文档不在结果中
+func GetTopicChecksums(ctx context.Context, topicIDs []int64) (map[int64]string, error) {
+	if err := checkAvailable(); err != nil {
+		return nil, err
+	}
+	checksums := make(map[int64]string, len(topicIDs))
+	if len(topicIDs) == 0 {
+		return checksums, nil
+	}
+
+	mget := client.Mget()
+	for _, id := range topicIDs {
+		mget = mget.Add(elastic.NewMultiGetItem().Index(index).Id(strconv.FormatInt(id, 10)))
+	}
+	result, err := mget.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, doc := range result.Docs {
+		if doc.Error != nil {
+			// 读取失败不能当作文档不存在，否则会误报缺失
+			return nil, fmt.Errorf("读取文档失败 %s: %s", doc.Id, doc.Error.Reason)
+		}
+		if !doc.Found {
+			continue
+		}
+		id, err := strconv.ParseInt(doc.Id, 10, 64)
+		if err != nil {
+			continue
+		}
+		var topic TopicDocument
+		if err := json.Unmarshal(doc.Source, &topic); err != nil {
+			return nil, err
+		}
+		checksums[id] = documentChecksum(topic)
+	}
+	return checksums, nil
+}
@@ -0,0 +1,47 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+	"web_app/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTopicChecksum 测试MySQL话题与ES中读回的文档校验和一致，索引字段变化时不一致
+func TestTopicChecksum(t *testing.T) {
+	topic := &models.Topic{
+		ID:        1747052461824102400,
+		UserID:    3,
+		Username:  "alice",
+		Title:     "Go 并发",
+		Content:   "goroutine",
+		Category:  "tech",
+		CreatedAt: time.Date(2024, 1, 15, 10, 30, 0, 0, time.Local),
+		UpdatedAt: time.Date(2024, 1, 15, 11, 0, 0, 0, time.Local),
+		LikeCount: 5,
+	}
+
+	// 写入ES后读回的文档（空标签写成[]，带标题补全字段）
+	data, err := json.Marshal(newTopicDocument(topic))
+	require.NoError(t, err)
+	var stored TopicDocument
+	require.NoError(t, json.Unmarshal(data, &stored))
+	stored.Tags = []string{}
+	assert.Equal(t, TopicChecksum(topic), documentChecksum(stored))
+
+	// 标题补全字段由其他字段派生，不影响校验和
+	stored.TitleSuggest = nil
+	assert.Equal(t, TopicChecksum(topic), documentChecksum(stored))
+
+	// 计数变化
+	stored.LikeCount = 6
+	assert.NotEqual(t, TopicChecksum(topic), documentChecksum(stored))
+
+	// 缺少用户名（新增字段前写入的文档）
+	stored.LikeCount = 5
+	stored.Username = ""
+	assert.NotEqual(t, TopicChecksum(topic), documentChecksum(stored))
+}
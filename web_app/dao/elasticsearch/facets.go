@@ -0,0 +1,66 @@
+// Package elasticsearch 搜索结果聚合（分类、标签分布和按天发布数）
+package elasticsearch
+
+import (
+	"web_app/models"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// 聚合名称
+const (
+	facetCategories = "categories" // 分类分布
+	facetTags       = "tags"       // 标签分布
+	facetDaily      = "daily"      // 按天发布数
+)
+
+// facetTermsSize 分类/标签聚合返回的桶数
+const facetTermsSize = 20
+
+// buildFacetAggregations 构建搜索结果的聚合
+func buildFacetAggregations() map[string]elastic.Aggregation {
+	return map[string]elastic.Aggregation{
+		facetCategories: elastic.NewTermsAggregation().Field("category").Size(facetTermsSize),
+		facetTags:       elastic.NewTermsAggregation().Field("tags").Size(facetTermsSize),
+		// created_at 存储的是不带时区的本地时间，按UTC分桶即为本地日期
+		facetDaily: elastic.NewDateHistogramAggregation().
+			Field("created_at").
+			CalendarInterval("day").
+			Format("yyyy-MM-dd").
+			MinDocCount(1),
+	}
+}
+
+// parseFacets 解析聚合结果（缺少的聚合返回空列表）
+func parseFacets(aggs elastic.Aggregations) *models.SearchFacets {
+	facets := &models.SearchFacets{
+		Categories: termsBuckets(aggs, facetCategories),
+		Tags:       termsBuckets(aggs, facetTags),
+		Daily:      []models.FacetBucket{},
+	}
+
+	if histogram, found := aggs.DateHistogram(facetDaily); found {
+		for _, bucket := range histogram.Buckets {
+			if bucket.KeyAsString == nil {
+				continue
+			}
+			facets.Daily = append(facets.Daily, models.FacetBucket{Key: *bucket.KeyAsString, Count: bucket.DocCount})
+		}
+	}
+	return facets
+}
+
+// termsBuckets 解析terms聚合的桶
+func termsBuckets(aggs elastic.Aggregations, name string) []models.FacetBucket {
+	buckets := []models.FacetBucket{}
+	terms, found := aggs.Terms(name)
+	if !found {
+		return buckets
+	}
+	for _, bucket := range terms.Buckets {
+		if key, ok := bucket.Key.(string); ok {
+			buckets = append(buckets, models.FacetBucket{Key: key, Count: bucket.DocCount})
+		}
+	}
+	return buckets
+}
@@ -0,0 +1,106 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"testing"
+	"web_app/models"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// boolFilters 提取bool查询中的filter条件（序列化为JSON字符串便于比较）
+func boolFilters(t *testing.T, m map[string]interface{}) []string {
+	t.Helper()
+	boolQuery := m["query"].(map[string]interface{})["bool"].(map[string]interface{})
+	var filters []interface{}
+	switch f := boolQuery["filter"].(type) {
+	case nil:
+	case []interface{}:
+		filters = f
+	default:
+		filters = []interface{}{f}
+	}
+
+	result := make([]string, 0, len(filters))
+	for _, f := range filters {
+		data, err := json.Marshal(f)
+		require.NoError(t, err)
+		result = append(result, string(data))
+	}
+	return result
+}
+
+// TestBuildSearchSource_Filters 测试筛选条件
+func TestBuildSearchSource_Filters(t *testing.T) {
+	req := &SearchRequest{
+		Category:    "tech",
+		UserID:      "42",
+		Tags:        []string{"go", "redis"},
+		CreatedFrom: "2024-01-01",
+		CreatedTo:   "2024-01-31",
+		MinLikes:    10,
+		MinComments: 3,
+		Page:        1,
+		PageSize:    20,
+	}
+	filters := boolFilters(t, sourceMap(t, req, DefaultScoringConfig()))
+
+	assert.Equal(t, []string{
+		`{"term":{"category":"tech"}}`,
+		`{"term":{"user_id":"42"}}`,
+		`{"term":{"tags":"go"}}`,
+		`{"term":{"tags":"redis"}}`,
+		`{"range":{"created_at":{"format":"yyyy-MM-dd HH:mm:ss||yyyy-MM-dd","from":"2024-01-01","include_lower":true,"include_upper":true,"to":"2024-01-31"}}}`,
+		`{"range":{"like_count":{"from":10,"include_lower":true,"include_upper":true,"to":null}}}`,
+		`{"range":{"comment_count":{"from":3,"include_lower":true,"include_upper":true,"to":null}}}`,
+	}, filters)
+}
+
+// TestBuildSearchSource_NoFilters 测试没有筛选条件时不生成filter
+func TestBuildSearchSource_NoFilters(t *testing.T) {
+	filters := boolFilters(t, sourceMap(t, &SearchRequest{Keyword: "go", Page: 1, PageSize: 20}, DefaultScoringConfig()))
+	assert.Empty(t, filters)
+}
+
+// TestBuildSearchSource_Aggregations 测试搜索结果附带聚合
+func TestBuildSearchSource_Aggregations(t *testing.T) {
+	m := sourceMap(t, &SearchRequest{Page: 1, PageSize: 20}, DefaultScoringConfig())
+	aggs := m["aggregations"].(map[string]interface{})
+
+	assert.Equal(t, "category", aggs[facetCategories].(map[string]interface{})["terms"].(map[string]interface{})["field"])
+	assert.Equal(t, "tags", aggs[facetTags].(map[string]interface{})["terms"].(map[string]interface{})["field"])
+	daily := aggs[facetDaily].(map[string]interface{})["date_histogram"].(map[string]interface{})
+	assert.Equal(t, "created_at", daily["field"])
+	assert.Equal(t, "day", daily["calendar_interval"])
+	assert.Equal(t, "yyyy-MM-dd", daily["format"])
+}
+
+// TestParseFacets 测试解析聚合结果
+func TestParseFacets(t *testing.T) {
+	raw := `{
+		"categories": {"buckets": [{"key": "tech", "doc_count": 5}, {"key": "share", "doc_count": 2}]},
+		"tags": {"buckets": [{"key": "go", "doc_count": 3}]},
+		"daily": {"buckets": [
+			{"key_as_string": "2024-01-01", "key": 1704067200000, "doc_count": 4},
+			{"key_as_string": "2024-01-03", "key": 1704240000000, "doc_count": 3}
+		]}
+	}`
+	var aggs elastic.Aggregations
+	require.NoError(t, json.Unmarshal([]byte(raw), &aggs))
+
+	facets := parseFacets(aggs)
+	assert.Equal(t, []models.FacetBucket{{Key: "tech", Count: 5}, {Key: "share", Count: 2}}, facets.Categories)
+	assert.Equal(t, []models.FacetBucket{{Key: "go", Count: 3}}, facets.Tags)
+	assert.Equal(t, []models.FacetBucket{{Key: "2024-01-01", Count: 4}, {Key: "2024-01-03", Count: 3}}, facets.Daily)
+}
+
+// TestParseFacets_Missing 测试没有聚合结果时返回空列表
+func TestParseFacets_Missing(t *testing.T) {
+	facets := parseFacets(nil)
+	assert.Empty(t, facets.Categories)
+	assert.NotNil(t, facets.Categories)
+	assert.NotNil(t, facets.Tags)
+	assert.NotNil(t, facets.Daily)
+}
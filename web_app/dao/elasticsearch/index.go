@@ -0,0 +1,170 @@
+// Package elasticsearch 版本化索引与别名管理
+package elasticsearch
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+	"go.uber.org/zap"
+)
+
+// 说明：配置中的 elasticsearch.index（以及评论索引 elasticsearch.comment_index）是别名，读写都通过别名进行；
+// 实际数据存放在 <别名>_v<时间戳> 的物理索引中。修改分词或mapping时创建新物理索引、
+// 导入数据后原子切换别名，整个过程搜索不中断。
+
+// ensureIndex 启动时确保话题索引和评论索引的别名可用
+func ensureIndex(ctx context.Context) error {
+	if err := ensureAlias(ctx, index, mappingVersion, buildIndexBody(analyzer, analysis)); err != nil {
+		return err
+	}
+	return ensureAlias(ctx, commentIndex, commentMappingVersion, buildCommentIndexBody(analyzer, analysis))
+}
+
+// ensureAlias 确保别名可用，body 为别名不存在时创建物理索引使用的请求体
+func ensureAlias(ctx context.Context, alias string, version int, body map[string]interface{}) error {
+	// 1. 别名已存在：直接使用，并检查结构是否需要重建
+	indices, err := aliasIndices(ctx, alias)
+	if err != nil {
+		return err
+	}
+	if len(indices) > 0 {
+		zap.L().Info("索引别名已存在", zap.String("alias", alias), zap.Strings("indices", indices))
+		for _, name := range indices {
+			checkIndexMeta(ctx, name, version)
+		}
+		return nil
+	}
+
+	// 2. 存在与别名同名的旧物理索引（升级前创建）：继续使用，重建索引时会被别名替换
+	exists, err := client.IndexExists(alias).Do(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		zap.L().Warn("使用旧版非别名索引，建议执行重建索引迁移到版本化索引", zap.String("index", alias))
+		return nil
+	}
+
+	// 3. 全新部署：创建版本化索引并指向别名
+	name, err := createVersionedIndex(ctx, alias, body)
+	if err != nil {
+		return err
+	}
+	_, err = swapAlias(ctx, alias, name)
+	return err
+}
+
+// aliasIndices 获取别名当前指向的物理索引（别名不存在时返回空）
+func aliasIndices(ctx context.Context, alias string) ([]string, error) {
+	result, err := client.Aliases().Alias(alias).Do(ctx)
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	indices := result.IndicesByAlias(alias)
+	sort.Strings(indices)
+	return indices, nil
+}
+
+// checkIndexMeta 比较物理索引记录的结构版本/分词方案与当前配置，不一致时提示重建
+func checkIndexMeta(ctx context.Context, name string, wantVersion int) {
+	mapping, err := client.GetMapping().Index(name).Do(ctx)
+	if err != nil {
+		zap.L().Warn("读取索引mapping失败", zap.String("index", name), zap.Error(err))
+		return
+	}
+
+	var version float64
+	var indexAnalyzer string
+	if m, ok := mapping[name].(map[string]interface{}); ok {
+		if mappings, ok := m["mappings"].(map[string]interface{}); ok {
+			if meta, ok := mappings["_meta"].(map[string]interface{}); ok {
+				version, _ = meta["mapping_version"].(float64)
+				indexAnalyzer, _ = meta["analyzer"].(string)
+			}
+		}
+	}
+
+	if int(version) != wantVersion || indexAnalyzer != analyzer {
+		zap.L().Warn("索引结构与当前配置不一致，请执行重建索引",
+			zap.String("index", name),
+			zap.Int("index_mapping_version", int(version)),
+			zap.Int("mapping_version", wantVersion),
+			zap.String("index_analyzer", indexAnalyzer),
+			zap.String("analyzer", analyzer))
+	}
+}
+
+// CreateVersionedIndex 按当前分词配置创建新的话题物理索引，返回索引名
+func CreateVersionedIndex(ctx context.Context) (string, error) {
+	if err := checkAvailable(); err != nil {
+		return "", err
+	}
+	return createVersionedIndex(ctx, index, buildIndexBody(analyzer, analysis))
+}
+
+// createVersionedIndex 创建 <别名>_v<时间戳> 物理索引
+func createVersionedIndex(ctx context.Context, alias string, body map[string]interface{}) (string, error) {
+	name := fmt.Sprintf("%s_v%s", alias, time.Now().Format("20060102150405"))
+
+	result, err := client.CreateIndex(name).BodyJson(body).Do(ctx)
+	if err != nil {
+		return "", fmt.Errorf("创建索引 %s 失败: %w", name, err)
+	}
+	if !result.Acknowledged {
+		return "", fmt.Errorf("索引创建未被确认: %s", name)
+	}
+
+	zap.L().Info("索引创建成功", zap.String("index", name), zap.String("analyzer", analyzer))
+	return name, nil
+}
+
+// SwapAlias 原子地将话题别名切换到新索引，返回切换前别名指向的索引
+func SwapAlias(ctx context.Context, newIndex string) ([]string, error) {
+	return swapAlias(ctx, index, newIndex)
+}
+
+// swapAlias 原子地将别名切换到新索引
+// 如果存在与别名同名的旧物理索引，会在同一个请求中删除它（别名不能与索引同名）
+func swapAlias(ctx context.Context, alias, newIndex string) ([]string, error) {
+	oldIndices, err := aliasIndices(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := []elastic.AliasAction{elastic.NewAliasAddAction(alias).Index(newIndex)}
+	for _, old := range oldIndices {
+		if old != newIndex {
+			actions = append(actions, elastic.NewAliasRemoveAction(alias).Index(old))
+		}
+	}
+	if len(oldIndices) == 0 {
+		legacy, err := client.IndexExists(alias).Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if legacy {
+			actions = append(actions, elastic.NewAliasRemoveIndexAction(alias))
+			oldIndices = []string{alias}
+		}
+	}
+
+	result, err := client.Alias().Action(actions...).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("切换索引别名失败: %w", err)
+	}
+	if !result.Acknowledged {
+		return nil, fmt.Errorf("切换索引别名未被确认")
+	}
+
+	zap.L().Info("索引别名已切换",
+		zap.String("alias", alias),
+		zap.String("index", newIndex),
+		zap.Strings("previous", oldIndices))
+	return oldIndices, nil
+}
@@ -0,0 +1,232 @@
+// Package elasticsearch 构建搜索查询DSL
+package elasticsearch
+
+import (
+	"github.com/olivere/elastic/v7"
+	"github.com/spf13/viper"
+)
+
+// 搜索排序方式
+const (
+	SortByCreatedAt    = "created_at"    // 最新发布
+	SortByViewCount    = "view_count"    // 浏览最多
+	SortByCommentCount = "comment_count" // 评论最多
+	SortByRelevance    = "relevance"     // 纯相关度（BM25）
+	SortByCombined     = "combined"      // 综合排序：相关度 × (时间衰减 + 热度)
+)
+
+// commentMatchBoost 只通过评论命中的话题的相关度得分（terms查询为常数分）
+const commentMatchBoost = 0.5
+
+// 关键词匹配要求
+const (
+	defaultMinimumShouldMatch = "30%" // 至少匹配30%的词（对中文更友好）
+	relaxedMinimumShouldMatch = "1"   // 放宽匹配：命中任意一个词即可
+)
+
+// ScoringConfig 综合排序的打分配置
+// 最终得分 = BM25相关度 (boost_mode) [时间衰减×RecencyWeight (score_mode) log1p(点赞)×LikeWeight ...]
+type ScoringConfig struct {
+	RecencyWeight float64 // 时间衰减函数权重
+	DecayScale    string  // 衰减尺度：距 DecayOffset 再过该时长时，衰减分降到 DecayRate
+	DecayOffset   string  // 衰减起点：该时长内发布的话题不衰减
+	DecayRate     float64 // 衰减率（0-1）
+	LikeWeight    float64 // 点赞数权重（log1p平滑）
+	CommentWeight float64 // 评论数权重（log1p平滑）
+	ViewWeight    float64 // 浏览数权重（log1p平滑）
+	ScoreMode     string  // 各函数分的合并方式：sum/multiply/avg/max...
+	BoostMode     string  // 函数分与相关度的合并方式：multiply/sum/replace...
+}
+
+// DefaultScoringConfig 默认打分配置
+func DefaultScoringConfig() ScoringConfig {
+	return ScoringConfig{
+		RecencyWeight: 1.0,
+		DecayScale:    "7d",
+		DecayOffset:   "1d",
+		DecayRate:     0.5,
+		LikeWeight:    0.5,
+		CommentWeight: 0.3,
+		ViewWeight:    0.1,
+		ScoreMode:     "sum",
+		BoostMode:     "multiply",
+	}
+}
+
+// LoadScoringConfig 从配置文件读取打分配置（未配置的项使用默认值）
+func LoadScoringConfig() ScoringConfig {
+	cfg := DefaultScoringConfig()
+	prefix := "elasticsearch.scoring."
+	if viper.IsSet(prefix + "recency_weight") {
+		cfg.RecencyWeight = viper.GetFloat64(prefix + "recency_weight")
+	}
+	if v := viper.GetString(prefix + "decay_scale"); v != "" {
+		cfg.DecayScale = v
+	}
+	if v := viper.GetString(prefix + "decay_offset"); v != "" {
+		cfg.DecayOffset = v
+	}
+	if v := viper.GetFloat64(prefix + "decay_rate"); v > 0 && v < 1 {
+		cfg.DecayRate = v
+	}
+	if viper.IsSet(prefix + "like_weight") {
+		cfg.LikeWeight = viper.GetFloat64(prefix + "like_weight")
+	}
+	if viper.IsSet(prefix + "comment_weight") {
+		cfg.CommentWeight = viper.GetFloat64(prefix + "comment_weight")
+	}
+	if viper.IsSet(prefix + "view_weight") {
+		cfg.ViewWeight = viper.GetFloat64(prefix + "view_weight")
+	}
+	if v := viper.GetString(prefix + "score_mode"); v != "" {
+		cfg.ScoreMode = v
+	}
+	if v := viper.GetString(prefix + "boost_mode"); v != "" {
+		cfg.BoostMode = v
+	}
+	return cfg
+}
+
+// buildSearchSource 根据搜索请求构建查询DSL（不依赖ES连接，便于测试）
+func buildSearchSource(req *SearchRequest, scoring ScoringConfig) *elastic.SearchSource {
+	// 构建查询
+	boolQuery := elastic.NewBoolQuery()
+
+	// 关键词搜索（标题和内容）
+	if req.Keyword != "" {
+		// 使用multi_match查询，支持中英文搜索
+		multiMatch := elastic.NewMultiMatchQuery(req.Keyword, "title", "content").
+			Type("best_fields"). // 最佳字段匹配
+			TieBreaker(0.3).     // 多字段匹配时的权重
+			Operator("OR").      // OR 操作符：任意一个词匹配即可
+			MinimumShouldMatch(defaultMinimumShouldMatch)
+		if req.Relaxed {
+			// 放宽匹配：任意一个词命中即可，并容忍拼写误差
+			multiMatch = multiMatch.MinimumShouldMatch(relaxedMinimumShouldMatch).Fuzziness("AUTO")
+		}
+
+		if len(req.CommentTopicIDs) > 0 {
+			// 综合模式：标题/内容命中或评论命中其一即可，评论命中的权重低于正文命中
+			commentMatch := elastic.NewTermsQueryFromStrings("topic_id", req.CommentTopicIDs...).Boost(commentMatchBoost)
+			boolQuery = boolQuery.Should(multiMatch, commentMatch).MinimumNumberShouldMatch(1)
+		} else {
+			boolQuery = boolQuery.Must(multiMatch)
+		}
+	}
+
+	// 筛选条件（filter上下文不参与打分，可被缓存）
+	boolQuery = boolQuery.Filter(buildSearchFilters(req)...)
+
+	source := elastic.NewSearchSource().
+		From((req.Page - 1) * req.PageSize).
+		Size(req.PageSize).
+		Highlight(newSearchHighlight()).
+		FetchSourceContext(elastic.NewFetchSourceContext(true).Exclude("content")) // 内容只返回摘要
+
+	// 聚合与结果使用同一个查询，统计的是当前筛选条件下的分布
+	for name, agg := range buildFacetAggregations() {
+		source = source.Aggregation(name, agg)
+	}
+
+	// 拼写纠正建议随搜索一起返回，没有结果时无需再请求一次（放宽匹配的重试不需要）
+	if req.Keyword != "" && !req.Relaxed {
+		source = source.Suggester(newSpellingSuggester(req.Keyword))
+	}
+
+	// 构建查询和排序
+	switch req.SortBy {
+	case SortByCombined:
+		source = source.Query(buildFunctionScoreQuery(boolQuery, scoring)).
+			SortBy(elastic.NewScoreSort(), elastic.NewFieldSort("created_at").Desc())
+	case SortByRelevance:
+		source = source.Query(boolQuery)
+		if req.Keyword != "" {
+			source = source.SortBy(elastic.NewScoreSort(), elastic.NewFieldSort("created_at").Desc())
+		} else {
+			// 没有关键词时相关度没有意义，按时间排序
+			source = source.Sort(SortByCreatedAt, false)
+		}
+	case SortByViewCount, SortByCommentCount:
+		source = source.Query(boolQuery).Sort(req.SortBy, false) // 降序
+	default:
+		source = source.Query(boolQuery).Sort(SortByCreatedAt, false) // 最新的在前
+	}
+
+	return source
+}
+
+// buildSearchFilters 构建筛选条件
+func buildSearchFilters(req *SearchRequest) []elastic.Query {
+	var filters []elastic.Query
+
+	if req.Category != "" {
+		filters = append(filters, elastic.NewTermQuery("category", req.Category))
+	}
+	if req.UserID != "" {
+		filters = append(filters, elastic.NewTermQuery("user_id", req.UserID))
+	}
+	// 每个标签一个term条件，要求同时包含全部标签
+	for _, tag := range req.Tags {
+		filters = append(filters, elastic.NewTermQuery("tags", tag))
+	}
+
+	if req.CreatedFrom != "" || req.CreatedTo != "" {
+		// 只有日期的上限按ES的日期取整规则扩展到当天最后一毫秒
+		rangeQuery := elastic.NewRangeQuery("created_at").Format("yyyy-MM-dd HH:mm:ss||yyyy-MM-dd")
+		if req.CreatedFrom != "" {
+			rangeQuery = rangeQuery.Gte(req.CreatedFrom)
+		}
+		if req.CreatedTo != "" {
+			rangeQuery = rangeQuery.Lte(req.CreatedTo)
+		}
+		filters = append(filters, rangeQuery)
+	}
+
+	if req.MinLikes > 0 {
+		filters = append(filters, elastic.NewRangeQuery("like_count").Gte(req.MinLikes))
+	}
+	if req.MinComments > 0 {
+		filters = append(filters, elastic.NewRangeQuery("comment_count").Gte(req.MinComments))
+	}
+
+	return filters
+}
+
+// buildFunctionScoreQuery 在相关度基础上叠加时间衰减和热度（权重为0的函数不参与打分）
+func buildFunctionScoreQuery(query elastic.Query, scoring ScoringConfig) *elastic.FunctionScoreQuery {
+	fsQuery := elastic.NewFunctionScoreQuery().
+		Query(query).
+		ScoreMode(scoring.ScoreMode).
+		BoostMode(scoring.BoostMode)
+
+	if scoring.RecencyWeight > 0 {
+		fsQuery = fsQuery.AddScoreFunc(elastic.NewGaussDecayFunction().
+			FieldName("created_at").
+			Origin("now").
+			Scale(scoring.DecayScale).
+			Offset(scoring.DecayOffset).
+			Decay(scoring.DecayRate).
+			Weight(scoring.RecencyWeight))
+	}
+
+	popularity := []struct {
+		field  string
+		weight float64
+	}{
+		{"like_count", scoring.LikeWeight},
+		{"comment_count", scoring.CommentWeight},
+		{"view_count", scoring.ViewWeight},
+	}
+	for _, p := range popularity {
+		if p.weight <= 0 {
+			continue
+		}
+		fsQuery = fsQuery.AddScoreFunc(elastic.NewFieldValueFactorFunction().
+			Field(p.field).
+			Modifier("log1p").
+			Missing(0).
+			Weight(p.weight))
+	}
+
+	return fsQuery
+}
@@ -0,0 +1,200 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sourceMap 将查询DSL序列化后再解析为map，便于断言
+func sourceMap(t *testing.T, req *SearchRequest, scoring ScoringConfig) map[string]interface{} {
+	t.Helper()
+	src, err := buildSearchSource(req, scoring).Source()
+	require.NoError(t, err)
+	data, err := json.Marshal(src)
+	require.NoError(t, err)
+
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &m))
+	return m
+}
+
+// sortFields 提取排序字段名（_score 或字段名）
+func sortFields(t *testing.T, m map[string]interface{}) []string {
+	t.Helper()
+	sorts, ok := m["sort"].([]interface{})
+	require.True(t, ok, "缺少sort")
+	var fields []string
+	for _, s := range sorts {
+		for field := range s.(map[string]interface{}) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// TestBuildSearchSource_Pagination 测试分页和高亮、source过滤
+func TestBuildSearchSource_Pagination(t *testing.T) {
+	m := sourceMap(t, &SearchRequest{Keyword: "golang", Page: 3, PageSize: 20}, DefaultScoringConfig())
+
+	assert.EqualValues(t, 40, m["from"])
+	assert.EqualValues(t, 20, m["size"])
+	assert.Contains(t, m, "highlight")
+	assert.Equal(t, map[string]interface{}{"excludes": []interface{}{"content"}}, m["_source"])
+}
+
+// TestBuildSearchSource_FieldSort 测试按字段排序（默认按创建时间）
+func TestBuildSearchSource_FieldSort(t *testing.T) {
+	tests := []struct {
+		sortBy string
+		want   string
+	}{
+		{"", "created_at"},
+		{SortByCreatedAt, "created_at"},
+		{SortByViewCount, "view_count"},
+		{SortByCommentCount, "comment_count"},
+		{"unknown", "created_at"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			m := sourceMap(t, &SearchRequest{Keyword: "go", Page: 1, PageSize: 10, SortBy: tt.sortBy}, DefaultScoringConfig())
+			assert.Equal(t, []string{tt.want}, sortFields(t, m))
+
+			query := m["query"].(map[string]interface{})
+			assert.Contains(t, query, "bool")
+		})
+	}
+}
+
+// TestBuildSearchSource_Relevance 测试按相关度排序
+func TestBuildSearchSource_Relevance(t *testing.T) {
+	m := sourceMap(t, &SearchRequest{Keyword: "go", Category: "tech", Page: 1, PageSize: 10, SortBy: SortByRelevance}, DefaultScoringConfig())
+
+	assert.Equal(t, []string{"_score", "created_at"}, sortFields(t, m))
+
+	boolQuery := m["query"].(map[string]interface{})["bool"].(map[string]interface{})
+	multiMatch := boolQuery["must"].(map[string]interface{})["multi_match"].(map[string]interface{})
+	assert.Equal(t, "go", multiMatch["query"])
+	assert.Equal(t, []interface{}{"title", "content"}, multiMatch["fields"])
+	assert.Equal(t, map[string]interface{}{"term": map[string]interface{}{"category": "tech"}}, boolQuery["filter"])
+}
+
+// TestBuildSearchSource_RelevanceWithoutKeyword 测试没有关键词时相关度排序退化为时间排序
+func TestBuildSearchSource_RelevanceWithoutKeyword(t *testing.T) {
+	m := sourceMap(t, &SearchRequest{Page: 1, PageSize: 10, SortBy: SortByRelevance}, DefaultScoringConfig())
+	assert.Equal(t, []string{"created_at"}, sortFields(t, m))
+}
+
+// TestBuildSearchSource_Spelling 测试有关键词时附带拼写纠正建议器，放宽匹配时不附带
+func TestBuildSearchSource_Spelling(t *testing.T) {
+	m := sourceMap(t, &SearchRequest{Keyword: "golnag", Page: 1, PageSize: 10, SortBy: SortByRelevance}, DefaultScoringConfig())
+	suggest := m["suggest"].(map[string]interface{})[spellingSuggesterName].(map[string]interface{})
+	assert.Equal(t, "golnag", suggest["text"])
+
+	phrase := suggest["phrase"].(map[string]interface{})
+	assert.Equal(t, "title", phrase["field"])
+	assert.Equal(t, topicIndexAnalyzer, phrase["analyzer"])
+	assert.Len(t, phrase["direct_generator"], 2)
+	collate := phrase["collate"].(map[string]interface{})
+	assert.Equal(t, false, collate["prune"])
+	assert.Contains(t, collate["query"].(map[string]interface{})["source"], "multi_match")
+
+	m = sourceMap(t, &SearchRequest{Page: 1, PageSize: 10}, DefaultScoringConfig())
+	assert.NotContains(t, m, "suggest")
+}
+
+// TestBuildSearchSource_Relaxed 测试放宽匹配：命中任意词、容忍拼写误差
+func TestBuildSearchSource_Relaxed(t *testing.T) {
+	multiMatch := func(m map[string]interface{}) map[string]interface{} {
+		boolQuery := m["query"].(map[string]interface{})["bool"].(map[string]interface{})
+		return boolQuery["must"].(map[string]interface{})["multi_match"].(map[string]interface{})
+	}
+
+	strict := multiMatch(sourceMap(t, &SearchRequest{Keyword: "go 并发", Page: 1, PageSize: 10, SortBy: SortByRelevance}, DefaultScoringConfig()))
+	assert.Equal(t, defaultMinimumShouldMatch, strict["minimum_should_match"])
+	assert.NotContains(t, strict, "fuzziness")
+
+	m := sourceMap(t, &SearchRequest{Keyword: "go 并发", Page: 1, PageSize: 10, SortBy: SortByRelevance, Relaxed: true}, DefaultScoringConfig())
+	relaxed := multiMatch(m)
+	assert.Equal(t, relaxedMinimumShouldMatch, relaxed["minimum_should_match"])
+	assert.Equal(t, "AUTO", relaxed["fuzziness"])
+	assert.NotContains(t, m, "suggest")
+}
+
+// TestBuildSearchSource_Combined 测试综合打分的function_score DSL
+func TestBuildSearchSource_Combined(t *testing.T) {
+	scoring := DefaultScoringConfig()
+	m := sourceMap(t, &SearchRequest{Keyword: "go", Page: 1, PageSize: 10, SortBy: SortByCombined}, scoring)
+
+	assert.Equal(t, []string{"_score", "created_at"}, sortFields(t, m))
+
+	fs := m["query"].(map[string]interface{})["function_score"].(map[string]interface{})
+	assert.Equal(t, "sum", fs["score_mode"])
+	assert.Equal(t, "multiply", fs["boost_mode"])
+	assert.Contains(t, fs["query"], "bool")
+
+	functions := fs["functions"].([]interface{})
+	require.Len(t, functions, 4)
+
+	// 时间衰减
+	decay := functions[0].(map[string]interface{})
+	assert.EqualValues(t, scoring.RecencyWeight, decay["weight"])
+	gauss := decay["gauss"].(map[string]interface{})["created_at"].(map[string]interface{})
+	assert.Equal(t, "now", gauss["origin"])
+	assert.Equal(t, "7d", gauss["scale"])
+	assert.Equal(t, "1d", gauss["offset"])
+	assert.EqualValues(t, 0.5, gauss["decay"])
+
+	// 热度
+	wantFields := []string{"like_count", "comment_count", "view_count"}
+	wantWeights := []float64{scoring.LikeWeight, scoring.CommentWeight, scoring.ViewWeight}
+	for i, field := range wantFields {
+		fn := functions[i+1].(map[string]interface{})
+		assert.EqualValues(t, wantWeights[i], fn["weight"])
+		fvf := fn["field_value_factor"].(map[string]interface{})
+		assert.Equal(t, field, fvf["field"])
+		assert.Equal(t, "log1p", fvf["modifier"])
+		assert.EqualValues(t, 0, fvf["missing"])
+	}
+}
+
+// TestBuildSearchSource_CombinedZeroWeights 测试权重为0的函数不参与打分
+func TestBuildSearchSource_CombinedZeroWeights(t *testing.T) {
+	scoring := DefaultScoringConfig()
+	scoring.LikeWeight = 0
+	scoring.ViewWeight = 0
+	scoring.BoostMode = "sum"
+
+	m := sourceMap(t, &SearchRequest{Keyword: "go", Page: 1, PageSize: 10, SortBy: SortByCombined}, scoring)
+	fs := m["query"].(map[string]interface{})["function_score"].(map[string]interface{})
+	assert.Equal(t, "sum", fs["boost_mode"])
+
+	functions := fs["functions"].([]interface{})
+	require.Len(t, functions, 2)
+	assert.Contains(t, functions[0], "gauss")
+	fvf := functions[1].(map[string]interface{})["field_value_factor"].(map[string]interface{})
+	assert.Equal(t, "comment_count", fvf["field"])
+}
+
+// TestLoadScoringConfig 测试从配置读取打分权重
+func TestLoadScoringConfig(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	viper.Set("elasticsearch.scoring.like_weight", 2.0)
+	viper.Set("elasticsearch.scoring.view_weight", 0)
+	viper.Set("elasticsearch.scoring.decay_scale", "30d")
+	viper.Set("elasticsearch.scoring.decay_rate", 1.5) // 非法值，使用默认
+
+	cfg := LoadScoringConfig()
+	def := DefaultScoringConfig()
+	assert.Equal(t, 2.0, cfg.LikeWeight)
+	assert.Equal(t, 0.0, cfg.ViewWeight)
+	assert.Equal(t, "30d", cfg.DecayScale)
+	assert.Equal(t, def.DecayRate, cfg.DecayRate)
+	assert.Equal(t, def.CommentWeight, cfg.CommentWeight)
+	assert.Equal(t, def.BoostMode, cfg.BoostMode)
+}
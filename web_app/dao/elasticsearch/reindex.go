@@ -0,0 +1,118 @@
+// Package elasticsearch 重建索引支持（双写、批量导入、清理）
+package elasticsearch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"web_app/models"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// dualWriteTarget 重建索引期间需要同时写入的新索引（空字符串表示没有进行中的重建）
+var dualWriteTarget atomic.Value
+
+// SetDualWriteTarget 设置双写目标，传入空字符串关闭双写
+func SetDualWriteTarget(target string) {
+	dualWriteTarget.Store(target)
+}
+
+// DualWriteTarget 获取当前的双写目标
+func DualWriteTarget() string {
+	target, _ := dualWriteTarget.Load().(string)
+	return target
+}
+
+// BulkIndexTopicsTo 批量写入指定的物理索引（不刷新，由调用方在导入结束后统一刷新）
+// version 为读取MySQL前的时间（毫秒），作为外部版本号：已双写过来的更新变更不会被导入的旧数据覆盖
+func BulkIndexTopicsTo(ctx context.Context, target string, topics []*models.Topic, version int64) error {
+	if len(topics) == 0 {
+		return nil
+	}
+
+	bulkRequest := client.Bulk().Index(target)
+	for _, topic := range topics {
+		doc := newTopicDocument(topic)
+		bulkRequest = bulkRequest.Add(withIndexVersion(elastic.NewBulkIndexRequest().Id(doc.TopicID).Doc(doc), version))
+	}
+
+	bulkResponse, err := bulkRequest.Do(ctx)
+	if err != nil {
+		return err
+	}
+	if failed := failedIgnoringConflicts(bulkResponse); len(failed) > 0 {
+		reason := ""
+		if failed[0].Error != nil {
+			reason = failed[0].Error.Reason
+		}
+		return fmt.Errorf("%d个文档写入失败，首个错误: %s", len(failed), reason)
+	}
+	return nil
+}
+
+// ScanTopicIDs 遍历指定索引中的全部文档ID（scroll分批，每批回调一次）
+func ScanTopicIDs(ctx context.Context, target string, batchSize int, fn func(ids []string) error) error {
+	scroll := client.Scroll(target).
+		Size(batchSize).
+		FetchSource(false)
+	defer scroll.Clear(context.Background()) //nolint:errcheck // 清理scroll上下文失败不影响结果
+
+	for {
+		result, err := scroll.Do(ctx)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		ids := make([]string, 0, len(result.Hits.Hits))
+		for _, hit := range result.Hits.Hits {
+			ids = append(ids, hit.Id)
+		}
+		if err := fn(ids); err != nil {
+			return err
+		}
+	}
+}
+
+// DeleteTopicsFrom 从指定索引批量删除文档
+func DeleteTopicsFrom(ctx context.Context, target string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	bulkRequest := client.Bulk().Index(target)
+	for _, id := range ids {
+		bulkRequest = bulkRequest.Add(elastic.NewBulkDeleteRequest().Id(id))
+	}
+	_, err := bulkRequest.Do(ctx)
+	return err
+}
+
+// SetRefreshInterval 设置索引刷新间隔（批量导入时设为 -1 关闭自动刷新以提高写入速度）
+func SetRefreshInterval(ctx context.Context, target, interval string) error {
+	_, err := client.IndexPutSettings(target).
+		BodyJson(map[string]interface{}{"index": map[string]interface{}{"refresh_interval": interval}}).
+		Do(ctx)
+	return err
+}
+
+// RefreshIndex 刷新指定索引，使写入的数据可被搜索
+func RefreshIndex(ctx context.Context, target string) error {
+	_, err := client.Refresh(target).Do(ctx)
+	return err
+}
+
+// DeletePhysicalIndex 删除指定的物理索引（用于清理失败的重建或过期的旧版本）
+func DeletePhysicalIndex(ctx context.Context, name string) error {
+	if name == index {
+		return fmt.Errorf("拒绝按别名删除索引: %s", name)
+	}
+	_, err := client.DeleteIndex(name).Do(ctx)
+	if err != nil && elastic.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
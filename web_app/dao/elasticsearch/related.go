@@ -0,0 +1,64 @@
+// Package elasticsearch 相关话题推荐（more_like_this）
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/olivere/elastic/v7"
+	"go.uber.org/zap"
+)
+
+// relatedFields 计算相似度使用的字段
+var relatedFields = []string{"title", "content", "category"}
+
+// buildRelatedSource 构建相关话题查询DSL：以话题文档本身为样本，排除该话题
+func buildRelatedSource(topicID string, size int) *elastic.SearchSource {
+	mlt := elastic.NewMoreLikeThisQuery().
+		Field(relatedFields...).
+		LikeItems(elastic.NewMoreLikeThisQueryItem().Id(topicID)).
+		MinTermFreq(1). // 话题通常较短，词在样本中出现一次即可
+		MinDocFreq(2).  // 忽略只出现在一篇话题中的词
+		MaxQueryTerms(25).
+		MinimumShouldMatch("30%").
+		Include(false)
+
+	query := elastic.NewBoolQuery().
+		Must(mlt).
+		MustNot(elastic.NewIdsQuery().Ids(topicID))
+
+	return elastic.NewSearchSource().
+		Query(query).
+		Size(size).
+		FetchSourceContext(elastic.NewFetchSourceContext(true).Include("topic_id"))
+}
+
+// RelatedTopics 获取与指定话题相似的话题ID（按相似度降序）
+func RelatedTopics(topicID string, size int) ([]string, error) {
+	if err := checkAvailable(); err != nil {
+		return nil, err
+	}
+	result, err := client.Search().
+		Index(index).
+		SearchSource(buildRelatedSource(topicID, size)).
+		Do(context.Background())
+	if err != nil {
+		zap.L().Error("查询相关话题失败", zap.Error(err), zap.String("topic_id", topicID))
+		return nil, err
+	}
+
+	ids := make([]string, 0)
+	if result.Hits == nil {
+		return ids, nil
+	}
+	for _, hit := range result.Hits.Hits {
+		var doc struct {
+			TopicID string `json:"topic_id"`
+		}
+		if err := json.Unmarshal(hit.Source, &doc); err != nil || doc.TopicID == "" {
+			doc.TopicID = hit.Id
+		}
+		ids = append(ids, doc.TopicID)
+	}
+	return ids, nil
+}
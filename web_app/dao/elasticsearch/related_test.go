@@ -0,0 +1,23 @@
+package elasticsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBuildRelatedSource 测试相关话题DSL以话题本身为样本并排除该话题
+func TestBuildRelatedSource(t *testing.T) {
+	m := searchSourceMap(t, buildRelatedSource("42", 5))
+
+	assert.EqualValues(t, 5, m["size"])
+	assert.Equal(t, map[string]interface{}{"includes": []interface{}{"topic_id"}}, m["_source"])
+
+	boolQuery := m["query"].(map[string]interface{})["bool"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"ids": map[string]interface{}{"values": []interface{}{"42"}}}, boolQuery["must_not"])
+
+	mlt := boolQuery["must"].(map[string]interface{})["more_like_this"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"title", "content", "category"}, mlt["fields"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"_id": "42"}}, mlt["like"])
+	assert.Equal(t, false, mlt["include"])
+}
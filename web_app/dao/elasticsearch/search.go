@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"web_app/models"
 
 	"github.com/olivere/elastic/v7"
 	"go.uber.org/zap"
@@ -12,74 +13,82 @@ import (
 
 // SearchRequest 搜索请求参数
 type SearchRequest struct {
-	Keyword  string // 搜索关键词
-	Category string // 分类筛选
-	Page     int    // 页码
-	PageSize int    // 每页数量
-	SortBy   string // 排序字段: created_at, view_count, comment_count
+	Keyword         string   // 搜索关键词
+	Category        string   // 分类筛选
+	UserID          string   // 作者ID筛选
+	Tags            []string // 标签筛选（需同时包含全部标签）
+	CreatedFrom     string   // 发布时间下限（含），yyyy-MM-dd 或 yyyy-MM-dd HH:mm:ss
+	CreatedTo       string   // 发布时间上限（含），只有日期时包含当天全天
+	MinLikes        int      // 最少点赞数
+	MinComments     int      // 最少评论数
+	CommentTopicIDs []string // 评论命中的话题ID（综合模式下即使标题/内容不匹配也返回）
+	Page            int      // 页码
+	PageSize        int      // 每页数量
+	SortBy          string   // 排序方式: created_at, view_count, comment_count, relevance, combined
+	Relaxed         bool     // 放宽关键词匹配（命中任意一个词、容忍拼写误差），用于零结果时重试
+}
+
+// SearchHit 单条搜索命中（文档不含content，内容以高亮摘要返回）
+type SearchHit struct {
+	Topic          *TopicDocument `json:"topic"`           // 话题文档
+	HighlightTitle string         `json:"highlight_title"` // 高亮标题（已HTML转义）
+	Snippet        string         `json:"snippet"`         // 内容摘要（已HTML转义）
 }
 
 // SearchResponse 搜索响应
 type SearchResponse struct {
-	Total    int64            `json:"total"`     // 总数
-	Topics   []*TopicDocument `json:"topics"`    // 话题列表
-	Page     int              `json:"page"`      // 当前页
-	PageSize int              `json:"page_size"` // 每页数量
-	Took     int64            `json:"took"`      // 耗时(毫秒)
+	Total      int64                `json:"total"`      // 总数
+	Hits       []*SearchHit         `json:"hits"`       // 命中列表
+	Facets     *models.SearchFacets `json:"facets"`     // 聚合结果
+	Suggestion string               `json:"suggestion"` // 拼写纠正建议（没有建议时为空）
+	Page       int                  `json:"page"`       // 当前页
+	PageSize   int                  `json:"page_size"`  // 每页数量
+	Took       int64                `json:"took"`       // 耗时(毫秒)
 }
 
-// SearchTopics 搜索话题
-func SearchTopics(req *SearchRequest) (*SearchResponse, error) {
-	ctx := context.Background()
-
-	// 构建查询
-	boolQuery := elastic.NewBoolQuery()
-
-	// 关键词搜索（标题和内容）
-	if req.Keyword != "" {
-		// 使用multi_match查询，支持中英文搜索
-		multiMatch := elastic.NewMultiMatchQuery(req.Keyword, "title", "content").
-			Type("best_fields").      // 最佳字段匹配
-			TieBreaker(0.3).          // 多字段匹配时的权重
-			Operator("OR").           // OR 操作符：任意一个词匹配即可
-			MinimumShouldMatch("30%") // 至少匹配30%的词（对中文更友好）
+const (
+	// highlightPreTag 高亮起始标签
+	highlightPreTag = "<em>"
+	// highlightPostTag 高亮结束标签
+	highlightPostTag = "</em>"
+	// snippetSize 内容摘要长度（字符）
+	snippetSize = 120
+	// titleNoMatchSize 标题未命中时返回的长度（覆盖标题最大长度）
+	titleNoMatchSize = 256
+)
 
-		boolQuery = boolQuery.Must(multiMatch)
-	}
+// newSearchHighlight 构建搜索高亮配置
+// 使用html编码器：片段中的原文会被转义，只有高亮标签是HTML，前端可以直接插入
+// 未命中的字段通过 no_match_size 返回开头部分，保证每条结果都有标题和摘要
+func newSearchHighlight() *elastic.Highlight {
+	return elastic.NewHighlight().
+		Encoder("html").
+		PreTags(highlightPreTag).
+		PostTags(highlightPostTag).
+		Fields(
+			elastic.NewHighlighterField("title").NumOfFragments(0).NoMatchSize(titleNoMatchSize),
+			elastic.NewHighlighterField("content").FragmentSize(snippetSize).NumOfFragments(1).NoMatchSize(snippetSize),
+		)
+}
 
-	// 分类筛选
-	if req.Category != "" {
-		termQuery := elastic.NewTermQuery("category", req.Category)
-		boolQuery = boolQuery.Filter(termQuery)
+// firstFragment 获取字段的第一个高亮片段
+func firstFragment(highlight elastic.SearchHitHighlight, field string) string {
+	if fragments := highlight[field]; len(fragments) > 0 {
+		return fragments[0]
 	}
+	return ""
+}
 
-	// 计算分页
-	from := (req.Page - 1) * req.PageSize
-
-	// 构建排序
-	var sortBy string
-	var sortOrder bool // true = 降序
-	switch req.SortBy {
-	case "view_count":
-		sortBy = "view_count"
-		sortOrder = false // 降序
-	case "comment_count":
-		sortBy = "comment_count"
-		sortOrder = false // 降序
-	case "created_at":
-		fallthrough
-	default:
-		sortBy = "created_at"
-		sortOrder = false // 最新的在前
+// SearchTopics 搜索话题（ctx 控制超时，ES无响应时返回超时错误）
+func SearchTopics(ctx context.Context, req *SearchRequest) (*SearchResponse, error) {
+	if err := checkAvailable(); err != nil {
+		return nil, err
 	}
 
 	// 执行搜索
 	searchResult, err := client.Search().
 		Index(index).
-		Query(boolQuery).
-		From(from).
-		Size(req.PageSize).
-		Sort(sortBy, sortOrder). // 排序
+		SearchSource(buildSearchSource(req, scoring)).
 		Pretty(true).
 		Do(ctx)
 
@@ -89,7 +98,7 @@ func SearchTopics(req *SearchRequest) (*SearchResponse, error) {
 	}
 
 	// 解析结果
-	topics := make([]*TopicDocument, 0)
+	hits := make([]*SearchHit, 0)
 	if searchResult.Hits != nil && searchResult.Hits.TotalHits.Value > 0 {
 		for _, hit := range searchResult.Hits.Hits {
 			var doc TopicDocument
@@ -99,67 +108,39 @@ func SearchTopics(req *SearchRequest) (*SearchResponse, error) {
 				continue
 			}
 
-			topics = append(topics, &doc)
+			hits = append(hits, &SearchHit{
+				Topic:          &doc,
+				HighlightTitle: firstFragment(hit.Highlight, "title"),
+				Snippet:        firstFragment(hit.Highlight, "content"),
+			})
 		}
 	}
 
 	response := &SearchResponse{
-		Total:    searchResult.Hits.TotalHits.Value,
-		Topics:   topics,
-		Page:     req.Page,
-		PageSize: req.PageSize,
-		Took:     searchResult.TookInMillis,
+		Total:      searchResult.Hits.TotalHits.Value,
+		Hits:       hits,
+		Facets:     parseFacets(searchResult.Aggregations),
+		Suggestion: parseSpellingSuggestion(searchResult.Suggest),
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		Took:       searchResult.TookInMillis,
 	}
 
 	zap.L().Info("搜索完成",
 		zap.String("keyword", req.Keyword),
+		zap.Bool("relaxed", req.Relaxed),
 		zap.Int64("total", response.Total),
-		zap.Int("results", len(topics)),
+		zap.Int("results", len(hits)),
 		zap.Int64("took_ms", response.Took))
 
 	return response, nil
 }
 
-// SuggestTopics 搜索建议（自动补全）
-func SuggestTopics(prefix string, size int) ([]string, error) {
-	ctx := context.Background()
-
-	if prefix == "" {
-		return []string{}, nil
-	}
-
-	// 使用前缀查询
-	prefixQuery := elastic.NewPrefixQuery("title.keyword", prefix)
-
-	searchResult, err := client.Search().
-		Index(index).
-		Query(prefixQuery).
-		Size(size).
-		FetchSourceContext(elastic.NewFetchSourceContext(true).Include("title")).
-		Do(ctx)
-
-	if err != nil {
-		return nil, err
-	}
-
-	suggestions := make([]string, 0)
-	if searchResult.Hits != nil {
-		for _, hit := range searchResult.Hits.Hits {
-			var doc struct {
-				Title string `json:"title"`
-			}
-			err := json.Unmarshal(hit.Source, &doc)
-			if err == nil && doc.Title != "" {
-				suggestions = append(suggestions, doc.Title)
-			}
-		}
-	}
-
-	return suggestions, nil
-}
-
 // GetTopicsByCategory 按分类获取热门话题
 func GetTopicsByCategory(category string, size int) ([]*TopicDocument, error) {
+	if err := checkAvailable(); err != nil {
+		return nil, err
+	}
 	ctx := context.Background()
 
 	termQuery := elastic.NewTermQuery("category", category)
@@ -191,6 +172,9 @@ func GetTopicsByCategory(category string, size int) ([]*TopicDocument, error) {
 
 // CountByCategory 统计各分类的话题数量
 func CountByCategory() (map[string]int64, error) {
+	if err := checkAvailable(); err != nil {
+		return nil, err
+	}
 	ctx := context.Background()
 
 	// 聚合查询
@@ -219,26 +203,32 @@ func CountByCategory() (map[string]int64, error) {
 	return result, nil
 }
 
-// DeleteIndex 删除索引（谨慎使用）
+// DeleteIndex 删除别名指向的全部物理索引（谨慎使用，修改mapping请使用重建索引）
 func DeleteIndex() error {
 	ctx := context.Background()
 
-	// 检查索引是否存在
-	exists, err := client.IndexExists(index).Do(ctx)
+	// 解析别名指向的物理索引（兼容升级前与别名同名的旧索引）
+	indices, err := aliasIndices(ctx, index)
 	if err != nil {
 		return err
 	}
-
-	if !exists {
-		return fmt.Errorf("索引不存在: %s", index)
+	if len(indices) == 0 {
+		exists, err := client.IndexExists(index).Do(ctx)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("索引不存在: %s", index)
+		}
+		indices = []string{index}
 	}
 
 	// 删除索引
-	_, err = client.DeleteIndex(index).Do(ctx)
+	_, err = client.DeleteIndex(indices...).Do(ctx)
 	if err != nil {
 		return err
 	}
 
-	zap.L().Warn("索引已删除", zap.String("index", index))
+	zap.L().Warn("索引已删除", zap.String("alias", index), zap.Strings("indices", indices))
 	return nil
 }
@@ -0,0 +1,212 @@
+// Package elasticsearch 标题自动补全（completion suggester）和拼写纠正（phrase suggester）
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/olivere/elastic/v7"
+	"go.uber.org/zap"
+)
+
+const (
+	// suggestField 标题补全字段
+	suggestField = "title_suggest"
+	// suggestContext 补全字段的分类上下文名称
+	suggestContext = "category"
+	// suggestAllCategories 所有文档都带有的上下文值，不限分类时用它查询
+	suggestAllCategories = "_all"
+	// suggesterName 补全建议器名称
+	suggesterName = "title_suggest"
+	// spellingSuggesterName 拼写纠正建议器名称
+	spellingSuggesterName = "did_you_mean"
+	// maxSuggestInputs 每个标题最多生成的补全输入（整个标题 + 从各个词开始的后缀）
+	maxSuggestInputs = 6
+	// maxSuggestInputLen 补全输入的最大长度（字符），与mapping中的 max_input_length 一致
+	maxSuggestInputLen = 50
+)
+
+// SuggestField 补全字段的文档结构
+type SuggestField struct {
+	Input    []string            `json:"input"`
+	Weight   int                 `json:"weight"`
+	Contexts map[string][]string `json:"contexts"`
+}
+
+// TopicSuggestion 标题补全结果
+type TopicSuggestion struct {
+	TopicID  string `json:"topic_id"`
+	Title    string `json:"title"`
+	Category string `json:"category"`
+}
+
+// suggestFieldMapping 补全字段的mapping
+// 使用simple分析器（按非字母切分并转小写，中文整段保留），补全只匹配输入的开头，因此按词生成多个输入
+func suggestFieldMapping() map[string]interface{} {
+	return map[string]interface{}{
+		"type":             "completion",
+		"analyzer":         "simple",
+		"max_input_length": maxSuggestInputLen,
+		"contexts": []map[string]interface{}{
+			{"name": suggestContext, "type": "category"},
+		},
+	}
+}
+
+// newSuggestField 根据话题标题、分类和互动数据生成补全字段
+func newSuggestField(title, category string, likeCount, commentCount int) *SuggestField {
+	inputs := suggestInputs(title)
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	contexts := []string{suggestAllCategories}
+	if category != "" {
+		contexts = append(contexts, category)
+	}
+	return &SuggestField{
+		Input:    inputs,
+		Weight:   suggestWeight(likeCount, commentCount),
+		Contexts: map[string][]string{suggestContext: contexts},
+	}
+}
+
+// suggestInputs 生成标题的补全输入：整个标题，以及从标题中每个词开始的后缀
+// 例如 "Go 并发编程：channel详解" 可以通过 "go"、"并发"、"channel" 补全
+func suggestInputs(title string) []string {
+	runes := []rune(strings.TrimSpace(title))
+	if len(runes) == 0 {
+		return nil
+	}
+
+	inputs := []string{truncateRunes(runes, maxSuggestInputLen)}
+	for i := 1; i < len(runes) && len(inputs) < maxSuggestInputs; i++ {
+		if isSuggestWordRune(runes[i]) && !isSuggestWordRune(runes[i-1]) {
+			inputs = append(inputs, truncateRunes(runes[i:], maxSuggestInputLen))
+		}
+	}
+	return inputs
+}
+
+// isSuggestWordRune 判断字符是否属于词（字母、数字，包括中文）
+func isSuggestWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// truncateRunes 截断到指定字符数
+func truncateRunes(runes []rune, n int) string {
+	if len(runes) > n {
+		runes = runes[:n]
+	}
+	return string(runes)
+}
+
+// suggestWeight 补全权重：互动越多的话题排得越靠前
+func suggestWeight(likeCount, commentCount int) int {
+	weight := 1 + int64(likeCount) + 2*int64(commentCount)
+	if weight < 1 {
+		return 1
+	}
+	if weight > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int(weight)
+}
+
+// buildSuggestSource 构建补全查询DSL：按分类上下文过滤，前缀允许拼写误差（按字符计算编辑距离）
+func buildSuggestSource(prefix, category string, size int) *elastic.SearchSource {
+	contextValue := suggestAllCategories
+	if category != "" {
+		contextValue = category
+	}
+
+	suggester := elastic.NewCompletionSuggester(suggesterName).
+		Field(suggestField).
+		Prefix(prefix).
+		FuzzyOptions(elastic.NewFuzzyCompletionSuggesterOptions().
+			EditDistance("AUTO").
+			UnicodeAware(true)).
+		SkipDuplicates(true).
+		ContextQuery(elastic.NewSuggesterCategoryQuery(suggestContext, contextValue)).
+		Size(size)
+
+	return elastic.NewSearchSource().
+		Size(0).
+		Suggester(suggester).
+		FetchSourceContext(elastic.NewFetchSourceContext(true).Include("topic_id", "title", "category"))
+}
+
+// SuggestTopics 标题自动补全，category 为空时不限分类
+func SuggestTopics(prefix, category string, size int) ([]*TopicSuggestion, error) {
+	if err := checkAvailable(); err != nil {
+		return nil, err
+	}
+	if prefix == "" {
+		return []*TopicSuggestion{}, nil
+	}
+
+	result, err := client.Search().
+		Index(index).
+		SearchSource(buildSuggestSource(prefix, category, size)).
+		Do(context.Background())
+	if err != nil {
+		zap.L().Error("获取补全建议失败", zap.Error(err), zap.String("prefix", prefix))
+		return nil, err
+	}
+	return parseSuggestions(result.Suggest), nil
+}
+
+// parseSuggestions 解析补全结果
+func parseSuggestions(suggest elastic.SearchSuggest) []*TopicSuggestion {
+	suggestions := make([]*TopicSuggestion, 0)
+	for _, entry := range suggest[suggesterName] {
+		for _, option := range entry.Options {
+			var doc TopicSuggestion
+			if err := json.Unmarshal(option.Source, &doc); err != nil {
+				zap.L().Error("解析补全结果失败", zap.Error(err))
+				continue
+			}
+			if doc.TopicID == "" {
+				doc.TopicID = option.Id
+			}
+			suggestions = append(suggestions, &doc)
+		}
+	}
+	return suggestions
+}
+
+// spellingCollateTemplate 校验纠正结果的查询模板：只返回能搜到话题的建议（不考虑筛选条件）
+const spellingCollateTemplate = `{"multi_match": {"query": "{{suggestion}}", "fields": ["title", "content"], "minimum_should_match": "` +
+	defaultMinimumShouldMatch + `"}}`
+
+// newSpellingSuggester 构建拼写纠正建议器：从标题和内容的词项中找相近的词替换，最多替换2个词
+// 使用写入分析器切分关键词（搜索分析器中的同义词会产生多路分词，不适合逐词纠正）
+func newSpellingSuggester(keyword string) *elastic.PhraseSuggester {
+	return elastic.NewPhraseSuggester(spellingSuggesterName).
+		Text(keyword).
+		Field("title").
+		Analyzer(topicIndexAnalyzer).
+		Size(1).
+		MaxErrors(2).
+		CandidateGenerators(
+			elastic.NewDirectCandidateGenerator("title").SuggestMode("always").MinWordLength(2),
+			elastic.NewDirectCandidateGenerator("content").SuggestMode("always").MinWordLength(2),
+		).
+		CollateQuery(elastic.NewScriptInline(spellingCollateTemplate).Lang("mustache")).
+		CollatePrune(false)
+}
+
+// parseSpellingSuggestion 解析拼写纠正建议，没有建议时返回空字符串
+func parseSpellingSuggestion(suggest elastic.SearchSuggest) string {
+	for _, entry := range suggest[spellingSuggesterName] {
+		for _, option := range entry.Options {
+			if option.Text != "" {
+				return option.Text
+			}
+		}
+	}
+	return ""
+}
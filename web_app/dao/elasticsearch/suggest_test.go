@@ -0,0 +1,119 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSuggestInputs 测试从标题的每个词开始生成补全输入
+func TestSuggestInputs(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  []string
+	}{
+		{"空标题", "  ", nil},
+		{"单个词", "并发编程", []string{"并发编程"}},
+		{"中英混合", "Go 并发编程：channel详解", []string{"Go 并发编程：channel详解", "并发编程：channel详解", "channel详解"}},
+		{"连续分隔符", "Redis -- 缓存", []string{"Redis -- 缓存", "缓存"}},
+		{"输入数量上限", "a b c d e f g h", []string{"a b c d e f g h", "b c d e f g h", "c d e f g h", "d e f g h", "e f g h", "f g h"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, suggestInputs(tt.title))
+		})
+	}
+}
+
+// TestSuggestInputs_Truncate 测试过长的输入按字符截断
+func TestSuggestInputs_Truncate(t *testing.T) {
+	inputs := suggestInputs(strings.Repeat("长", 80))
+	require.Len(t, inputs, 1)
+	assert.Equal(t, strings.Repeat("长", maxSuggestInputLen), inputs[0])
+}
+
+// TestNewSuggestField 测试补全字段的分类上下文和权重
+func TestNewSuggestField(t *testing.T) {
+	field := newSuggestField("Go 并发", "技术", 3, 2)
+	require.NotNil(t, field)
+	assert.Equal(t, []string{"Go 并发", "并发"}, field.Input)
+	assert.Equal(t, 8, field.Weight)
+	assert.Equal(t, map[string][]string{suggestContext: {suggestAllCategories, "技术"}}, field.Contexts)
+
+	assert.Nil(t, newSuggestField("", "技术", 0, 0))
+	assert.Equal(t, []string{suggestAllCategories}, newSuggestField("标题", "", 0, 0).Contexts[suggestContext])
+}
+
+// TestSuggestWeight 测试权重的上下限
+func TestSuggestWeight(t *testing.T) {
+	assert.Equal(t, 1, suggestWeight(0, 0))
+	assert.Equal(t, 1, suggestWeight(-5, 0))
+	assert.Equal(t, math.MaxInt32, suggestWeight(math.MaxInt32, math.MaxInt32))
+}
+
+// TestBuildSuggestSource 测试补全查询使用模糊匹配和分类上下文
+func TestBuildSuggestSource(t *testing.T) {
+	completion := func(m map[string]interface{}) map[string]interface{} {
+		suggest := m["suggest"].(map[string]interface{})[suggesterName].(map[string]interface{})
+		assert.Equal(t, "并法", suggest["prefix"])
+		return suggest["completion"].(map[string]interface{})
+	}
+
+	t.Run("指定分类", func(t *testing.T) {
+		m := searchSourceMap(t, buildSuggestSource("并法", "技术", 8))
+		assert.EqualValues(t, 0, m["size"])
+
+		c := completion(m)
+		assert.Equal(t, suggestField, c["field"])
+		assert.EqualValues(t, 8, c["size"])
+		assert.Equal(t, true, c["skip_duplicates"])
+		assert.Equal(t, map[string]interface{}{"fuzziness": "AUTO", "unicode_aware": true}, c["fuzzy"])
+		assert.Equal(t, map[string]interface{}{suggestContext: []interface{}{map[string]interface{}{"context": "技术"}}}, c["contexts"])
+	})
+
+	t.Run("不限分类", func(t *testing.T) {
+		c := completion(searchSourceMap(t, buildSuggestSource("并法", "", 8)))
+		assert.Equal(t, map[string]interface{}{suggestContext: []interface{}{map[string]interface{}{"context": suggestAllCategories}}}, c["contexts"])
+	})
+}
+
+// TestParseSuggestions 测试解析补全结果
+func TestParseSuggestions(t *testing.T) {
+	suggest := elastic.SearchSuggest{
+		suggesterName: {{
+			Options: []elastic.SearchSuggestionOption{
+				{Id: "1", Source: json.RawMessage(`{"topic_id":"1","title":"Go 并发","category":"技术"}`)},
+				{Id: "2", Source: json.RawMessage(`{"title":"没有topic_id"}`)},
+				{Id: "3", Source: json.RawMessage(`not json`)},
+			},
+		}},
+	}
+
+	suggestions := parseSuggestions(suggest)
+	require.Len(t, suggestions, 2)
+	assert.Equal(t, &TopicSuggestion{TopicID: "1", Title: "Go 并发", Category: "技术"}, suggestions[0])
+	assert.Equal(t, "2", suggestions[1].TopicID)
+
+	assert.Empty(t, parseSuggestions(nil))
+}
+
+// TestParseSpellingSuggestion 测试解析拼写纠正建议
+func TestParseSpellingSuggestion(t *testing.T) {
+	suggest := elastic.SearchSuggest{
+		spellingSuggesterName: {{
+			Text:    "golnag",
+			Options: []elastic.SearchSuggestionOption{{Text: "golang", Score: 0.8}},
+		}},
+	}
+	assert.Equal(t, "golang", parseSpellingSuggestion(suggest))
+
+	assert.Empty(t, parseSpellingSuggestion(elastic.SearchSuggest{spellingSuggesterName: {{Text: "golang"}}}))
+	assert.Empty(t, parseSpellingSuggestion(nil))
+}
@@ -0,0 +1,176 @@
+// Package elasticsearch 全量同步使用的批量处理器
+package elasticsearch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+	"web_app/models"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// maxSyncErrors 每次Flush最多保留的错误信息条数
+const maxSyncErrors = 20
+
+// BulkSyncConfig 批量处理器配置
+type BulkSyncConfig struct {
+	BulkActions int           // 累计多少个文档提交一次
+	BulkSize    int           // 累计多少字节提交一次
+	Workers     int           // 并发提交的worker数
+	RetryMax    time.Duration // 失败重试的最长退避时间
+}
+
+// LoadBulkSyncConfig 从配置文件读取批量处理器配置（未配置的项使用默认值）
+func LoadBulkSyncConfig() BulkSyncConfig {
+	cfg := BulkSyncConfig{
+		BulkActions: viper.GetInt("elasticsearch.sync.bulk_actions"),
+		BulkSize:    viper.GetInt("elasticsearch.sync.bulk_size_mb") << 20,
+		Workers:     viper.GetInt("elasticsearch.sync.workers"),
+		RetryMax:    viper.GetDuration("elasticsearch.sync.retry_max"),
+	}
+	if cfg.BulkActions <= 0 {
+		cfg.BulkActions = 500
+	}
+	if cfg.BulkSize <= 0 {
+		cfg.BulkSize = 5 << 20
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.RetryMax <= 0 {
+		cfg.RetryMax = 30 * time.Second
+	}
+	return cfg
+}
+
+// BulkSyncResult 两次Flush之间的写入结果
+type BulkSyncResult struct {
+	Indexed int64    // 写入成功的文档数
+	Failed  int64    // 重试后仍失败的文档数
+	Errors  []string // 失败原因（最多 maxSyncErrors 条）
+}
+
+// BulkSyncer 基于 BulkProcessor 的批量写入器
+// 按文档数或字节数自动提交，整体失败和可重试的单条失败（429/503等）按指数退避重试
+type BulkSyncer struct {
+	processor *elastic.BulkProcessor
+
+	mu     sync.Mutex
+	result BulkSyncResult
+}
+
+// NewBulkSyncer 创建并启动批量写入器，用完后需调用 Close
+func NewBulkSyncer(ctx context.Context, cfg BulkSyncConfig) (*BulkSyncer, error) {
+	if err := checkAvailable(); err != nil {
+		return nil, err
+	}
+	s := &BulkSyncer{}
+	processor, err := client.BulkProcessor().
+		Name("es-sync").
+		Workers(cfg.Workers).
+		BulkActions(cfg.BulkActions).
+		BulkSize(cfg.BulkSize).
+		Backoff(elastic.NewExponentialBackoff(200*time.Millisecond, cfg.RetryMax)).
+		After(s.after).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.processor = processor
+	return s, nil
+}
+
+// AddTopics 将话题加入待提交队列
+// version 为读取MySQL前的时间（毫秒），作为外部版本号：读取之后同步过来的变更版本更新，不会被旧数据覆盖
+func (s *BulkSyncer) AddTopics(topics []*models.Topic, version int64) {
+	for _, topic := range topics {
+		doc := newTopicDocument(topic)
+		s.processor.Add(withIndexVersion(elastic.NewBulkIndexRequest().Index(index).Id(doc.TopicID).Doc(doc), version))
+	}
+}
+
+// AddComments 将评论加入待提交队列（version 同 AddTopics）
+func (s *BulkSyncer) AddComments(comments []*models.Comment, version int64) {
+	for _, comment := range comments {
+		doc := newCommentDocument(comment)
+		s.processor.Add(withIndexVersion(elastic.NewBulkIndexRequest().Index(commentIndex).Id(doc.CommentID).Doc(doc), version))
+	}
+}
+
+// Flush 提交队列中剩余的文档并等待完成，返回上次Flush以来的写入结果
+// 返回后已加入的文档要么写入成功，要么已计入失败，可以安全地保存断点
+func (s *BulkSyncer) Flush() (BulkSyncResult, error) {
+	if err := s.processor.Flush(); err != nil {
+		return BulkSyncResult{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := s.result
+	s.result = BulkSyncResult{}
+	return result, nil
+}
+
+// Close 停止批量写入器
+func (s *BulkSyncer) Close() error {
+	return s.processor.Close()
+}
+
+// after 每次提交（含重试）结束后的回调，统计写入结果
+func (s *BulkSyncer) after(_ int64, requests []elastic.BulkableRequest, response *elastic.BulkResponse, err error) {
+	indexed, failed, errs := countBulkResult(len(requests), response, err)
+	if failed > 0 {
+		zap.L().Warn("批量写入部分失败", zap.Int("failed", failed), zap.Int("total", len(requests)), zap.Error(err))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.result.Indexed += int64(indexed)
+	s.result.Failed += int64(failed)
+	for _, e := range errs {
+		if len(s.result.Errors) >= maxSyncErrors {
+			break
+		}
+		s.result.Errors = append(s.result.Errors, e)
+	}
+}
+
+// countBulkResult 统计一次提交的成功/失败数
+// 重试时 response 只包含最后一次重试的文档，因此以“总数 - 最后仍失败的数量”计算成功数
+// 版本冲突说明ES中已是更新的数据，不算失败
+func countBulkResult(total int, response *elastic.BulkResponse, err error) (int, int, []string) {
+	if response == nil {
+		reason := "批量请求失败"
+		if err != nil {
+			reason = err.Error()
+		}
+		return 0, total, []string{fmt.Sprintf("%d个文档写入失败: %s", total, reason)}
+	}
+
+	failedItems := failedIgnoringConflicts(response)
+	errs := make([]string, 0, len(failedItems))
+	for _, item := range failedItems {
+		reason := "未知错误"
+		if item.Error != nil {
+			reason = item.Error.Reason
+		}
+		errs = append(errs, fmt.Sprintf("%s/%s: %s", item.Index, item.Id, reason))
+	}
+	return total - len(failedItems), len(failedItems), errs
+}
+
+// failedIgnoringConflicts 返回失败的文档，不含版本冲突（按外部版本号写入时旧数据被拒绝）
+func failedIgnoringConflicts(response *elastic.BulkResponse) []*elastic.BulkResponseItem {
+	var failed []*elastic.BulkResponseItem
+	for _, item := range response.Failed() {
+		if item.Status != http.StatusConflict {
+			failed = append(failed, item)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,59 @@
+package elasticsearch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+// bulkItem 构造批量响应中的单条结果
+func bulkItem(id string, status int, reason string) map[string]*elastic.BulkResponseItem {
+	item := &elastic.BulkResponseItem{Index: "bullbell_topics_v1", Id: id, Status: status}
+	if reason != "" {
+		item.Error = &elastic.ErrorDetails{Reason: reason}
+	}
+	return map[string]*elastic.BulkResponseItem{"index": item}
+}
+
+// TestCountBulkResult 测试批量提交结果统计
+func TestCountBulkResult(t *testing.T) {
+	t.Run("全部成功", func(t *testing.T) {
+		resp := &elastic.BulkResponse{Items: []map[string]*elastic.BulkResponseItem{
+			bulkItem("1", 201, ""), bulkItem("2", 200, ""),
+		}}
+		indexed, failed, errs := countBulkResult(2, resp, nil)
+		assert.Equal(t, 2, indexed)
+		assert.Equal(t, 0, failed)
+		assert.Empty(t, errs)
+	})
+
+	t.Run("重试后部分失败", func(t *testing.T) {
+		// 重试时响应只包含最后一次重试的文档
+		resp := &elastic.BulkResponse{Errors: true, Items: []map[string]*elastic.BulkResponseItem{
+			bulkItem("3", 429, "es_rejected_execution_exception"),
+		}}
+		indexed, failed, errs := countBulkResult(5, resp, elastic.ErrBulkItemRetry)
+		assert.Equal(t, 4, indexed)
+		assert.Equal(t, 1, failed)
+		assert.Equal(t, []string{"bullbell_topics_v1/3: es_rejected_execution_exception"}, errs)
+	})
+
+	t.Run("版本冲突不算失败", func(t *testing.T) {
+		resp := &elastic.BulkResponse{Errors: true, Items: []map[string]*elastic.BulkResponseItem{
+			bulkItem("1", 201, ""), bulkItem("2", 409, "version_conflict_engine_exception"),
+		}}
+		indexed, failed, errs := countBulkResult(2, resp, nil)
+		assert.Equal(t, 2, indexed)
+		assert.Equal(t, 0, failed)
+		assert.Empty(t, errs)
+	})
+
+	t.Run("请求整体失败", func(t *testing.T) {
+		indexed, failed, errs := countBulkResult(3, nil, errors.New("connection refused"))
+		assert.Equal(t, 0, indexed)
+		assert.Equal(t, 3, failed)
+		assert.Equal(t, []string{"3个文档写入失败: connection refused"}, errs)
+	})
+}
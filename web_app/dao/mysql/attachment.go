@@ -0,0 +1,123 @@
+package mysql
+
+import (
+	"database/sql"
+	"web_app/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// InsertAttachment 插入附件记录
+func InsertAttachment(attachment *models.Attachment) error {
+	sqlStr := `INSERT INTO attachments (id, user_id, sha256, storage_key, thumbnail_key, mime_type, size, width, height, original_name, created_at)
+	           VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := db.Exec(sqlStr, attachment.ID, attachment.UserID, attachment.SHA256, attachment.StorageKey, attachment.ThumbnailKey,
+		attachment.MimeType, attachment.Size, attachment.Width, attachment.Height, attachment.OriginalName, attachment.CreatedAt)
+	return err
+}
+
+// GetAttachmentBySHA256 根据内容哈希获取附件，不存在时返回nil
+func GetAttachmentBySHA256(sha256 string) (*models.Attachment, error) {
+	var attachment models.Attachment
+	if err := db.Get(&attachment, "SELECT * FROM attachments WHERE sha256 = ?", sha256); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// RecordAttachmentUpload 记录用户上传过该附件（同一文件只存一份，每个上传过的用户都可以引用）
+func RecordAttachmentUpload(userID, attachmentID int64) error {
+	_, err := db.Exec("INSERT IGNORE INTO attachment_uploads (user_id, attachment_id) VALUES (?, ?)", userID, attachmentID)
+	return err
+}
+
+// GetUserAttachmentIDs 返回ids中该用户上传过的附件ID
+func GetUserAttachmentIDs(userID int64, ids []int64) ([]int64, error) {
+	if len(ids) == 0 {
+		return []int64{}, nil
+	}
+
+	query, args, err := sqlx.In("SELECT attachment_id FROM attachment_uploads WHERE user_id = ? AND attachment_id IN (?)", userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var owned []int64
+	if err := db.Select(&owned, db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	return owned, nil
+}
+
+// GetTopicAttachments 获取话题的附件列表（按上传顺序）
+func GetTopicAttachments(topicID int64) ([]*models.Attachment, error) {
+	sqlStr := `
+		SELECT a.*
+		FROM topic_attachments ta
+		JOIN attachments a ON ta.attachment_id = a.id
+		WHERE ta.topic_id = ?
+		ORDER BY ta.position ASC
+	`
+	var attachments []*models.Attachment
+	if err := db.Select(&attachments, sqlStr, topicID); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// GetCommentAttachments 批量获取评论的附件（评论ID → 按上传顺序排列的附件）
+func GetCommentAttachments(commentIDs []int64) (map[int64][]*models.Attachment, error) {
+	result := make(map[int64][]*models.Attachment)
+	if len(commentIDs) == 0 {
+		return result, nil
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT ca.comment_id, a.*
+		FROM comment_attachments ca
+		JOIN attachments a ON ca.attachment_id = a.id
+		WHERE ca.comment_id IN (?)
+		ORDER BY ca.comment_id, ca.position ASC
+	`, commentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		CommentID int64 `db:"comment_id"`
+		models.Attachment
+	}
+	if err := db.Select(&rows, db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		attachment := rows[i].Attachment
+		result[rows[i].CommentID] = append(result[rows[i].CommentID], &attachment)
+	}
+	return result, nil
+}
+
+// insertCommentAttachments 写入评论附件关联（可在事务中执行）
+func insertCommentAttachments(execer sqlx.Execer, commentID int64, attachmentIDs []int64) error {
+	sqlStr := "INSERT INTO comment_attachments (comment_id, attachment_id, position) VALUES (?, ?, ?)"
+	for i, attachmentID := range attachmentIDs {
+		if _, err := execer.Exec(sqlStr, commentID, attachmentID, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertTopicAttachments 写入话题附件关联（可在事务中执行）
+func insertTopicAttachments(execer sqlx.Execer, topicID int64, attachmentIDs []int64) error {
+	sqlStr := "INSERT INTO topic_attachments (topic_id, attachment_id, position) VALUES (?, ?, ?)"
+	for i, attachmentID := range attachmentIDs {
+		if _, err := execer.Exec(sqlStr, topicID, attachmentID, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -4,13 +4,19 @@ import (
 	"database/sql"
 	"errors"
 	"web_app/models"
+
+	"github.com/jmoiron/sqlx"
 )
 
-// InsertComment 插入评论
-func InsertComment(comment *models.Comment) error {
-	sqlStr := "INSERT INTO comments (id, topic_id, user_id, content, parent_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
-	_, err := db.Exec(sqlStr, comment.ID, comment.TopicID, comment.UserID, comment.Content, comment.ParentID, comment.CreatedAt, comment.UpdatedAt)
-	return err
+// InsertComment 在同一事务中插入评论及其附件关联
+func InsertComment(comment *models.Comment, attachmentIDs []int64) error {
+	sqlStr := "INSERT INTO comments (id, topic_id, user_id, content, content_html, parent_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	return execWithOutbox(func(ext sqlx.Ext) error {
+		if _, err := ext.Exec(sqlStr, comment.ID, comment.TopicID, comment.UserID, comment.Content, comment.ContentHTML, comment.ParentID, comment.CreatedAt, comment.UpdatedAt); err != nil {
+			return err
+		}
+		return insertCommentAttachments(ext, comment.ID, attachmentIDs)
+	}, commentChange(outboxInsert, comment.ID))
 }
 
 // GetCommentsByTopicID 根据话题ID获取评论列表（分页）
@@ -61,29 +67,31 @@ func GetCommentByID(commentID int64) (*models.Comment, error) {
 
 // DeleteComment 删除评论
 func DeleteComment(commentID int64) error {
-	sqlStr := "DELETE FROM comments WHERE id = ?"
-	result, err := db.Exec(sqlStr, commentID)
-	if err != nil {
-		return err
-	}
+	return execWithOutbox(func(ext sqlx.Ext) error {
+		result, err := ext.Exec("DELETE FROM comments WHERE id = ?", commentID)
+		if err != nil {
+			return err
+		}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
 
-	if rowsAffected == 0 {
-		return errors.New("评论不存在")
-	}
+		if rowsAffected == 0 {
+			return errors.New("评论不存在")
+		}
 
-	return nil
+		return nil
+	}, commentChange(outboxDelete, commentID))
 }
 
-// UpdateTopicCommentCount 更新话题评论数
-func UpdateTopicCommentCount(topicID int64, delta int) error {
-	sqlStr := "UPDATE topics SET comment_count = comment_count + ? WHERE id = ?"
-	_, err := db.Exec(sqlStr, delta, topicID)
-	return err
+// RefreshTopicCommentCount 按评论表重新统计话题评论数（幂等，重复执行结果相同）
+func RefreshTopicCommentCount(topicID int64) error {
+	return execWithOutbox(func(ext sqlx.Ext) error {
+		_, err := ext.Exec("UPDATE topics SET comment_count = (SELECT COUNT(*) FROM comments WHERE topic_id = ?) WHERE id = ?", topicID, topicID)
+		return err
+	}, topicChange(outboxUpdate, topicID))
 }
 
 // CountCommentsByTopicID 统计话题的评论数
@@ -95,3 +103,75 @@ func CountCommentsByTopicID(topicID int64) (int64, error) {
 	}
 	return count, nil
 }
+
+// CountCommentsByTopicIDs 批量统计多个话题的评论数，没有评论的话题计为0
+func CountCommentsByTopicIDs(topicIDs []int64) (map[int64]int64, error) {
+	counts := make(map[int64]int64, len(topicIDs))
+	if len(topicIDs) == 0 {
+		return counts, nil
+	}
+	query, args, err := sqlx.In("SELECT topic_id, COUNT(*) AS count FROM comments WHERE topic_id IN (?) GROUP BY topic_id", topicIDs)
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		TopicID int64 `db:"topic_id"`
+		Count   int64 `db:"count"`
+	}
+	if err := db.Select(&rows, db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	for _, id := range topicIDs {
+		counts[id] = 0
+	}
+	for _, row := range rows {
+		counts[row.TopicID] = row.Count
+	}
+	return counts, nil
+}
+
+// GetCommentsAfterID 按ID游标分批读取评论（用于全量同步到ES）
+func GetCommentsAfterID(lastID int64, limit int) ([]*models.Comment, error) {
+	sqlStr := `
+		SELECT c.*, u.username
+		FROM comments c
+		LEFT JOIN users u ON c.user_id = u.id
+		WHERE c.id > ?
+		ORDER BY c.id ASC
+		LIMIT ?
+	`
+	var comments []*models.Comment
+	if err := db.Select(&comments, sqlStr, lastID, limit); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// CountCommentsAfterID 统计ID大于lastID的评论数（用于估算同步进度）
+func CountCommentsAfterID(lastID int64) (int64, error) {
+	var count int64
+	err := db.Get(&count, "SELECT COUNT(*) FROM comments WHERE id > ?", lastID)
+	return count, err
+}
+
+// GetCommentSearchResults 根据ID批量获取评论搜索结果需要的作者和话题信息（已删除的评论不返回）
+func GetCommentSearchResults(ids []int64) ([]*models.CommentSearchResult, error) {
+	if len(ids) == 0 {
+		return []*models.CommentSearchResult{}, nil
+	}
+	query, args, err := sqlx.In(`
+		SELECT c.id, c.topic_id, t.title AS topic_title, c.user_id, u.username, c.parent_id, c.created_at
+		FROM comments c
+		JOIN topics t ON c.topic_id = t.id
+		LEFT JOIN users u ON c.user_id = u.id
+		WHERE c.id IN (?)
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	var results []*models.CommentSearchResult
+	if err := db.Select(&results, db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
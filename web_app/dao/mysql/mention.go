@@ -0,0 +1,52 @@
+package mysql
+
+import (
+	"strings"
+	"web_app/models"
+)
+
+// InsertMentions 批量插入提及记录（ID已存在的记录跳过，重复写入同一批结果不变）
+func InsertMentions(mentions []*models.Mention) error {
+	if len(mentions) == 0 {
+		return nil
+	}
+	placeholders := make([]string, 0, len(mentions))
+	args := make([]interface{}, 0, len(mentions)*6)
+	for _, m := range mentions {
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?)")
+		args = append(args, m.ID, m.UserID, m.FromUserID, m.TopicID, m.CommentID, m.CreatedAt)
+	}
+	sqlStr := "INSERT IGNORE INTO mentions (id, user_id, from_user_id, topic_id, comment_id, created_at) VALUES " + strings.Join(placeholders, ", ")
+	_, err := db.Exec(sqlStr, args...)
+	return err
+}
+
+// GetMentionsByUserID 获取用户收到的提及（分页，按时间倒序）
+func GetMentionsByUserID(userID int64, page, pageSize int) ([]*models.Mention, int64, error) {
+	// 查询总数
+	var total int64
+	if err := db.Get(&total, "SELECT COUNT(*) FROM mentions WHERE user_id = ?", userID); err != nil {
+		return nil, 0, err
+	}
+
+	// 查询列表（JOIN 获取发起人用户名、话题标题以及所在内容）
+	offset := (page - 1) * pageSize
+	listSQL := `
+		SELECT m.id, m.user_id, m.from_user_id, m.topic_id, m.comment_id, m.created_at,
+		       COALESCE(u.username, '') AS from_username,
+		       t.title AS topic_title,
+		       COALESCE(c.content, t.content) AS excerpt
+		FROM mentions m
+		JOIN topics t ON m.topic_id = t.id
+		LEFT JOIN comments c ON m.comment_id = c.id
+		LEFT JOIN users u ON m.from_user_id = u.id
+		WHERE m.user_id = ?
+		ORDER BY m.created_at DESC, m.id DESC
+		LIMIT ? OFFSET ?
+	`
+	var mentions []*models.Mention
+	if err := db.Select(&mentions, listSQL, userID, pageSize, offset); err != nil {
+		return nil, 0, err
+	}
+	return mentions, total, nil
+}
@@ -52,12 +52,14 @@ func Init() (err error) {
 	// 配置连接池
 	db.SetMaxOpenConns(viper.GetInt("mysql.max_open_conns"))
 	db.SetMaxIdleConns(viper.GetInt("mysql.max_idle_conns"))
+	outboxEnabled = viper.GetBool("outbox.enabled")
 	zap.L().Info("MySQL 数据库连接成功",
 		zap.String("host", viper.GetString("mysql.host")),
 		zap.Int("port", viper.GetInt("mysql.port")),
 		zap.String("database", viper.GetString("mysql.database")),
 		zap.Int("max_open_conns", viper.GetInt("mysql.max_open_conns")),
 		zap.Int("max_idle_conns", viper.GetInt("mysql.max_idle_conns")),
+		zap.Bool("outbox_enabled", outboxEnabled),
 	)
 
 	return nil
@@ -105,7 +107,7 @@ func InsertUser(userID int64, username, email, hashedPassword string) error {
 // GetUserByUsername 根据用户名获取用户信息
 func GetUserByUsername(username string) (*models.User, error) {
 	var user models.User
-	err := db.Get(&user, "SELECT id, username, email, password, created_at, updated_at FROM users WHERE username = ?", username)
+	err := db.Get(&user, "SELECT id, username, email, password, role, created_at, updated_at FROM users WHERE username = ?", username)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("用户不存在")
@@ -118,7 +120,7 @@ func GetUserByUsername(username string) (*models.User, error) {
 // GetUserByID 根据用户ID获取用户信息
 func GetUserByID(userID int64) (*models.User, error) {
 	var user models.User
-	err := db.Get(&user, "SELECT id, username, email, password, created_at, updated_at FROM users WHERE id = ?", userID)
+	err := db.Get(&user, "SELECT id, username, email, password, role, created_at, updated_at FROM users WHERE id = ?", userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("用户不存在")
@@ -127,3 +129,46 @@ func GetUserByID(userID int64) (*models.User, error) {
 	}
 	return &user, nil
 }
+
+// GetUserRole 查询用户角色（用户不存在时返回 sql.ErrNoRows）
+func GetUserRole(userID int64) (string, error) {
+	var role string
+	err := db.Get(&role, "SELECT role FROM users WHERE id = ?", userID)
+	return role, err
+}
+
+// GetUsernamesByIDs 根据用户ID批量查询用户名（不存在的用户不在结果中）
+func GetUsernamesByIDs(userIDs []int64) (map[int64]string, error) {
+	usernames := make(map[int64]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return usernames, nil
+	}
+	query, args, err := sqlx.In("SELECT id, username FROM users WHERE id IN (?)", userIDs)
+	if err != nil {
+		return nil, err
+	}
+	var users []*models.User
+	if err := db.Select(&users, db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+	return usernames, nil
+}
+
+// GetUsersByUsernames 根据用户名批量查询用户（仅返回ID和用户名，用于解析@提及）
+func GetUsersByUsernames(usernames []string) ([]*models.User, error) {
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+	query, args, err := sqlx.In("SELECT id, username FROM users WHERE username IN (?)", usernames)
+	if err != nil {
+		return nil, err
+	}
+	var users []*models.User
+	if err := db.Select(&users, db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,255 @@
+package mysql
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	"web_app/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/viper"
+)
+
+// 发件箱记录的变更类型（与Canal消息的type一致）
+const (
+	outboxInsert = "INSERT"
+	outboxUpdate = "UPDATE"
+	outboxDelete = "DELETE"
+)
+
+// outboxTimeFormat 行数据中DATETIME字段的格式（与Canal一致）
+const outboxTimeFormat = "2006-01-02 15:04:05"
+
+// outboxEnabled 是否启用事务发件箱（启动时从配置读取）
+var outboxEnabled bool
+
+// OutboxEnabled 是否启用事务发件箱（代替Canal同步ES）
+func OutboxEnabled() bool {
+	return outboxEnabled
+}
+
+// rowChange 事务中需要同步的一行变更
+type rowChange struct {
+	table string // 表名（topics/comments）
+	op    string // INSERT/UPDATE/DELETE
+	id    int64  // 主键
+}
+
+// outboxMessage 发件箱消息体，与Canal flatMessage格式相同，ES同步消费者可直接处理
+type outboxMessage struct {
+	Type     string                   `json:"type"`
+	Database string                   `json:"database"`
+	Table    string                   `json:"table"`
+	PkNames  []string                 `json:"pkNames"`
+	Data     []map[string]interface{} `json:"data"`
+	Old      []map[string]interface{} `json:"old"`
+	IsDdl    bool                     `json:"isDdl"`
+	Es       int64                    `json:"es"`
+	Ts       int64                    `json:"ts"`
+}
+
+// execWithOutbox 在事务中执行写操作，启用发件箱时在同一事务中记录变更的行
+func execWithOutbox(write func(ext sqlx.Ext) error, changes ...rowChange) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // 提交后回滚为空操作
+
+	if !outboxEnabled {
+		if err := write(tx); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	before, err := snapshotRows(tx, changes, func(ch rowChange) bool { return ch.op != outboxInsert })
+	if err != nil {
+		return err
+	}
+	if err := write(tx); err != nil {
+		return err
+	}
+	if err := insertOutboxEvents(tx, changes, before); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// recordChanges 在调用方的事务中记录写入后的行（用于已有事务的INSERT，未启用发件箱时不做任何事）
+func recordChanges(tx *sqlx.Tx, changes ...rowChange) error {
+	if !outboxEnabled {
+		return nil
+	}
+	return insertOutboxEvents(tx, changes, make([]map[string]interface{}, len(changes)))
+}
+
+// snapshotRows 读取满足条件的变更行当前的数据（UPDATE/DELETE在写入前读取旧值）
+func snapshotRows(q sqlx.Queryer, changes []rowChange, want func(rowChange) bool) ([]map[string]interface{}, error) {
+	rows := make([]map[string]interface{}, len(changes))
+	for i, ch := range changes {
+		if !want(ch) {
+			continue
+		}
+		row, err := snapshotRow(q, ch)
+		if err != nil {
+			return nil, err
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// snapshotRow 读取一行数据并转换为Canal格式，行不存在时返回nil
+func snapshotRow(q sqlx.Queryer, ch rowChange) (map[string]interface{}, error) {
+	// 表名来自代码中的常量，不是用户输入
+	rows, err := q.Queryx(fmt.Sprintf("SELECT * FROM `%s` WHERE id = ?", ch.table), ch.id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	raw := make(map[string]interface{})
+	if err := rows.MapScan(raw); err != nil {
+		return nil, err
+	}
+	row := make(map[string]interface{}, len(raw))
+	for column, value := range raw {
+		row[column] = canalValue(value)
+	}
+	return row, nil
+}
+
+// insertOutboxEvents 写入发件箱记录：DELETE使用删除前的行，INSERT/UPDATE读取写入后的行，UPDATE的old只包含变化的列
+// 行不存在（如更新或删除了不存在的记录）时跳过
+func insertOutboxEvents(tx *sqlx.Tx, changes []rowChange, before []map[string]interface{}) error {
+	now := time.Now().UnixMilli()
+	database := viper.GetString("mysql.database")
+	for i, ch := range changes {
+		msg := outboxMessage{Type: ch.op, Database: database, Table: ch.table, PkNames: []string{"id"}, Es: now, Ts: now}
+		switch ch.op {
+		case outboxDelete:
+			if before[i] == nil {
+				continue
+			}
+			msg.Data = []map[string]interface{}{before[i]}
+		default:
+			after, err := snapshotRow(tx, ch)
+			if err != nil {
+				return err
+			}
+			if after == nil {
+				continue
+			}
+			msg.Data = []map[string]interface{}{after}
+			if ch.op == outboxUpdate {
+				msg.Old = []map[string]interface{}{changedColumns(before[i], after)}
+			}
+		}
+
+		payload, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		sqlStr := "INSERT INTO outbox_events (table_name, row_id, operation, payload) VALUES (?, ?, ?, ?)"
+		if _, err := tx.Exec(sqlStr, ch.table, ch.id, ch.op, string(payload)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// canalValue 把列值转换为Canal消息中的表示：NULL为nil，其他值都是字符串
+func canalValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(outboxTimeFormat)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// changedColumns 返回值发生变化的列及其旧值（与Canal UPDATE消息的old一致）
+func changedColumns(before, after map[string]interface{}) map[string]interface{} {
+	old := make(map[string]interface{})
+	for column, value := range before {
+		if after[column] != value {
+			old[column] = value
+		}
+	}
+	return old
+}
+
+// PublishOutboxEvents 按写入顺序取出最多limit条未发布的记录交给publish，成功后标记为已发布
+// 记录在事务中加锁（SKIP LOCKED），多个实例同时转发时不会重复发布；publish失败时不标记，下次重试
+func PublishOutboxEvents(limit int, publish func(events []*models.OutboxEvent) error) (int, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck // 提交后回滚为空操作
+
+	var events []*models.OutboxEvent
+	sqlStr := `SELECT id, table_name, row_id, operation, payload, created_at FROM outbox_events
+		WHERE published_at IS NULL ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED`
+	if err := tx.Select(&events, sqlStr, limit); err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err := publish(events); err != nil {
+		return 0, err
+	}
+
+	ids := make([]int64, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	query, args, err := sqlx.In("UPDATE outbox_events SET published_at = NOW(3) WHERE id IN (?)", ids)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(tx.Rebind(query), args...); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+// DeleteOutboxEventsBefore 删除指定时间之前已发布的记录，返回删除的行数
+func DeleteOutboxEventsBefore(before time.Time) (int64, error) {
+	result, err := db.Exec("DELETE FROM outbox_events WHERE published_at IS NOT NULL AND published_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// CountPendingOutboxEvents 统计未发布的记录数
+func CountPendingOutboxEvents() (int64, error) {
+	var count int64
+	err := db.Get(&count, "SELECT COUNT(*) FROM outbox_events WHERE published_at IS NULL")
+	return count, err
+}
+
+// topicChange 构造话题表的行变更
+func topicChange(op string, id int64) rowChange {
+	return rowChange{table: "topics", op: op, id: id}
+}
+
+// commentChange 构造评论表的行变更
+func commentChange(op string, id int64) rowChange {
+	return rowChange{table: "comments", op: op, id: id}
+}
@@ -0,0 +1,59 @@
+package mysql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCanalValue 测试列值转换为Canal消息中的字符串表示
+func TestCanalValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  interface{}
+	}{
+		{"NULL", nil, nil},
+		{"字符串列", []byte("Go 并发"), "Go 并发"},
+		{"整数列", int64(1747052461824102400), "1747052461824102400"},
+		{"DATETIME", time.Date(2024, 1, 15, 10, 30, 0, 0, time.Local), "2024-01-15 10:30:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, canalValue(tt.value))
+		})
+	}
+}
+
+// TestChangedColumns 测试UPDATE消息的old只包含变化的列
+func TestChangedColumns(t *testing.T) {
+	before := map[string]interface{}{"id": "1", "like_count": "11", "title": "a", "parent_id": nil}
+	after := map[string]interface{}{"id": "1", "like_count": "12", "title": "a", "parent_id": nil}
+
+	assert.Equal(t, map[string]interface{}{"like_count": "11"}, changedColumns(before, after))
+	assert.Empty(t, changedColumns(nil, after))
+}
+
+// TestVoteCountDelta 测试投票变化对点赞/点踩数的影响
+func TestVoteCountDelta(t *testing.T) {
+	tests := []struct {
+		name             string
+		oldType, newType int
+		like, dislike    int
+	}{
+		{"首次点赞", 0, 1, 1, 0},
+		{"首次点踩", 0, -1, 0, 1},
+		{"取消点赞", 1, 0, -1, 0},
+		{"取消点踩", -1, 0, 0, -1},
+		{"点赞改点踩", 1, -1, -1, 1},
+		{"点踩改点赞", -1, 1, 1, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			like, dislike := voteCountDelta(tt.oldType, tt.newType)
+			assert.Equal(t, tt.like, like)
+			assert.Equal(t, tt.dislike, dislike)
+		})
+	}
+}
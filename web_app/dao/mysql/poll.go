@@ -0,0 +1,84 @@
+package mysql
+
+import (
+	"database/sql"
+	"web_app/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// insertPoll 插入投票及其选项（可在事务中执行）
+func insertPoll(execer sqlx.Execer, poll *models.Poll) error {
+	sqlStr := "INSERT INTO polls (id, topic_id, multi_choice, close_at, created_at) VALUES (?, ?, ?, ?, ?)"
+	if _, err := execer.Exec(sqlStr, poll.ID, poll.TopicID, poll.MultiChoice, poll.CloseAt, poll.CreatedAt); err != nil {
+		return err
+	}
+
+	optionSQL := "INSERT INTO poll_options (id, poll_id, content, position) VALUES (?, ?, ?, ?)"
+	for _, option := range poll.Options {
+		if _, err := execer.Exec(optionSQL, option.ID, poll.ID, option.Content, option.Position); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPollByTopicID 根据话题ID获取投票（含选项），话题没有投票时返回nil
+func GetPollByTopicID(topicID int64) (*models.Poll, error) {
+	var poll models.Poll
+	sqlStr := "SELECT id, topic_id, multi_choice, close_at, created_at FROM polls WHERE topic_id = ?"
+	if err := db.Get(&poll, sqlStr, topicID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // 没有投票
+		}
+		return nil, err
+	}
+
+	optionSQL := "SELECT id, poll_id, content, position, vote_count FROM poll_options WHERE poll_id = ? ORDER BY position ASC"
+	if err := db.Select(&poll.Options, optionSQL, poll.ID); err != nil {
+		return nil, err
+	}
+	return &poll, nil
+}
+
+// GetUserPollOptionIDs 获取用户在某个投票中选择的选项ID
+func GetUserPollOptionIDs(pollID, userID int64) ([]int64, error) {
+	var optionIDs []int64
+	sqlStr := "SELECT option_id FROM poll_votes WHERE poll_id = ? AND user_id = ?"
+	if err := db.Select(&optionIDs, sqlStr, pollID, userID); err != nil {
+		return nil, err
+	}
+	return optionIDs, nil
+}
+
+// InsertPollVotes 在同一事务中写入投票记录并累加选项得票数
+func InsertPollVotes(votes []*models.PollVote) error {
+	if len(votes) == 0 {
+		return nil
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // 提交后回滚为空操作
+
+	voteSQL := "INSERT INTO poll_votes (id, poll_id, option_id, user_id, created_at) VALUES (?, ?, ?, ?, ?)"
+	optionIDs := make([]int64, 0, len(votes))
+	for _, vote := range votes {
+		if _, err := tx.Exec(voteSQL, vote.ID, vote.PollID, vote.OptionID, vote.UserID, vote.CreatedAt); err != nil {
+			return err
+		}
+		optionIDs = append(optionIDs, vote.OptionID)
+	}
+
+	query, args, err := sqlx.In("UPDATE poll_options SET vote_count = vote_count + 1 WHERE poll_id = ? AND id IN (?)", votes[0].PollID, optionIDs)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(tx.Rebind(query), args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,49 @@
+package mysql
+
+import (
+	"strings"
+	"time"
+	"web_app/models"
+)
+
+// InsertSearchLogs 批量插入搜索日志
+func InsertSearchLogs(logs []*models.SearchLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	placeholders := make([]string, 0, len(logs))
+	args := make([]interface{}, 0, len(logs)*7)
+	for _, l := range logs {
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?, ?)")
+		args = append(args, l.ID, l.Keyword, l.Filters, l.ResultCount, l.LatencyMs, l.Relaxed, l.CreatedAt)
+	}
+	sqlStr := "INSERT INTO search_logs (id, keyword, filters, result_count, latency_ms, relaxed, created_at) VALUES " + strings.Join(placeholders, ", ")
+	_, err := db.Exec(sqlStr, args...)
+	return err
+}
+
+// DeleteSearchLogsBefore 删除指定时间之前的搜索日志，返回删除条数
+func DeleteSearchLogsBefore(before time.Time) (int64, error) {
+	result, err := db.Exec("DELETE FROM search_logs WHERE created_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// UpsertSearchQueryStats 写入某个小时的搜索词统计（重复执行时覆盖，保证幂等）
+func UpsertSearchQueryStats(hour time.Time, searches, zeros map[string]int64) error {
+	if len(searches) == 0 {
+		return nil
+	}
+	placeholders := make([]string, 0, len(searches))
+	args := make([]interface{}, 0, len(searches)*4)
+	for keyword, count := range searches {
+		placeholders = append(placeholders, "(?, ?, ?, ?)")
+		args = append(args, hour, keyword, count, zeros[keyword])
+	}
+	sqlStr := "INSERT INTO search_query_stats (hour, keyword, search_count, zero_count) VALUES " + strings.Join(placeholders, ", ") +
+		" ON DUPLICATE KEY UPDATE search_count = VALUES(search_count), zero_count = VALUES(zero_count)"
+	_, err := db.Exec(sqlStr, args...)
+	return err
+}
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 	"web_app/models"
 
 	"github.com/jmoiron/sqlx"
@@ -11,8 +13,41 @@ import (
 
 // InsertTopic 插入话题
 func InsertTopic(topic *models.Topic) error {
-	sqlStr := "INSERT INTO topics (id, user_id, title, content, category, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
-	_, err := db.Exec(sqlStr, topic.ID, topic.UserID, topic.Title, topic.Content, topic.Category, topic.CreatedAt, topic.UpdatedAt)
+	return execWithOutbox(func(ext sqlx.Ext) error {
+		return insertTopic(ext, topic)
+	}, topicChange(outboxInsert, topic.ID))
+}
+
+// InsertTopicWithRelations 在同一事务中插入话题及其附带的投票、附件关联
+// poll为nil表示没有投票
+func InsertTopicWithRelations(topic *models.Topic, poll *models.Poll, attachmentIDs []int64) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // 提交后回滚为空操作
+
+	if err := insertTopic(tx, topic); err != nil {
+		return err
+	}
+	if poll != nil {
+		if err := insertPoll(tx, poll); err != nil {
+			return err
+		}
+	}
+	if err := insertTopicAttachments(tx, topic.ID, attachmentIDs); err != nil {
+		return err
+	}
+	if err := recordChanges(tx, topicChange(outboxInsert, topic.ID)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertTopic 插入话题（可在事务中执行）
+func insertTopic(execer sqlx.Execer, topic *models.Topic) error {
+	sqlStr := "INSERT INTO topics (id, user_id, title, content, content_html, category, tags, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	_, err := execer.Exec(sqlStr, topic.ID, topic.UserID, topic.Title, topic.Content, topic.ContentHTML, topic.Category, topic.Tags, topic.CreatedAt, topic.UpdatedAt)
 	return err
 }
 
@@ -27,18 +62,18 @@ func GetTopics(req *models.GetTopicsRequest) ([]*models.Topic, int64, error) {
 		args = append(args, req.Category)
 	}
 
-	// 构建 ORDER BY 子句
-	var orderBy string
+	// 构建 ORDER BY 子句（置顶话题始终排在最前，其后才按sort参数排序）
+	orderBy := "ORDER BY " + buildPinnedOrder(req.Category)
 	switch req.Sort {
 	case "new":
-		orderBy = "ORDER BY t.created_at DESC"
+		orderBy += ", t.created_at DESC"
 	case "like":
-		orderBy = "ORDER BY t.like_count DESC"
+		orderBy += ", t.like_count DESC"
 	case "hot":
 		fallthrough
 	default:
 		// 热度算法：综合点赞数、评论数、浏览数
-		orderBy = "ORDER BY (t.like_count * 3 + t.comment_count * 2 + t.view_count) DESC"
+		orderBy += ", (t.like_count * 3 + t.comment_count * 2 + t.view_count) DESC"
 	}
 
 	// 查询总数
@@ -69,6 +104,15 @@ func GetTopics(req *models.GetTopicsRequest) ([]*models.Topic, int64, error) {
 	return topicList, total, nil
 }
 
+// buildPinnedOrder 构建置顶排序表达式
+// 不带分类筛选时只有全站置顶的话题靠前；按分类筛选时该分类下所有置顶话题（含全站置顶）靠前
+func buildPinnedOrder(category string) string {
+	if category == "" {
+		return fmt.Sprintf("(t.is_pinned = 1 AND t.pin_scope = '%s') DESC", models.PinScopeGlobal)
+	}
+	return "t.is_pinned DESC"
+}
+
 // GetTopicByID 根据ID获取话题详情
 func GetTopicByID(topicID int64) (*models.Topic, error) {
 	sqlStr := `
@@ -87,26 +131,51 @@ func GetTopicByID(topicID int64) (*models.Topic, error) {
 	return &topic, nil
 }
 
-// GetAllTopics 获取所有话题（用于同步到ES）
-func GetAllTopics() ([]*models.Topic, error) {
-	sqlStr := `
-		SELECT t.*, u.username 
-		FROM topics t
-		LEFT JOIN users u ON t.user_id = u.id
-		ORDER BY t.created_at DESC
-	`
-	var topics []*models.Topic
-	if err := db.Select(&topics, sqlStr); err != nil {
-		return nil, err
+// AddTopicViewCounts 批量累加话题浏览数（浏览先在Redis中汇总，由定时任务按批写入）
+// 启用发件箱时每批每个话题只记录一条变更，不会每次浏览都生成一条整行记录
+func AddTopicViewCounts(counts map[int64]int64) error {
+	if len(counts) == 0 {
+		return nil
 	}
-	return topics, nil
+
+	// 按ID顺序更新，多个实例同时写入时加锁顺序一致，避免死锁
+	topicIDs := make([]int64, 0, len(counts))
+	for topicID := range counts {
+		topicIDs = append(topicIDs, topicID)
+	}
+	slices.Sort(topicIDs)
+	changes := make([]rowChange, 0, len(topicIDs))
+	for _, topicID := range topicIDs {
+		changes = append(changes, topicChange(outboxUpdate, topicID))
+	}
+
+	return execWithOutbox(func(ext sqlx.Ext) error {
+		for _, topicID := range topicIDs {
+			if _, err := ext.Exec("UPDATE topics SET view_count = view_count + ? WHERE id = ?", counts[topicID], topicID); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, changes...)
 }
 
-// UpdateTopicViewCount 增加话题浏览数
-func UpdateTopicViewCount(topicID int64) error {
-	sqlStr := "UPDATE topics SET view_count = view_count + 1 WHERE id = ?"
-	_, err := db.Exec(sqlStr, topicID)
-	return err
+// UpdateTopicPinned 更新话题置顶状态
+func UpdateTopicPinned(topicID int64, pinned bool, scope string) error {
+	if !pinned {
+		scope = ""
+	}
+	return execWithOutbox(func(ext sqlx.Ext) error {
+		_, err := ext.Exec("UPDATE topics SET is_pinned = ?, pin_scope = ? WHERE id = ?", pinned, scope, topicID)
+		return err
+	}, topicChange(outboxUpdate, topicID))
+}
+
+// UpdateTopicLocked 更新话题锁定状态
+func UpdateTopicLocked(topicID int64, locked bool) error {
+	return execWithOutbox(func(ext sqlx.Ext) error {
+		_, err := ext.Exec("UPDATE topics SET is_locked = ? WHERE id = ?", locked, topicID)
+		return err
+	}, topicChange(outboxUpdate, topicID))
 }
 
 // GetUserVote 获取用户对话题的投票状态
@@ -122,46 +191,47 @@ func GetUserVote(userID, topicID int64) (*models.Vote, error) {
 	return &vote, nil
 }
 
-// InsertVote 插入投票记录
-func InsertVote(vote *models.Vote) error {
-	sqlStr := "INSERT INTO votes (id, user_id, topic_id, vote_type) VALUES (?, ?, ?, ?)"
-	_, err := db.Exec(sqlStr, vote.ID, vote.UserID, vote.TopicID, vote.VoteType)
-	return err
-}
-
-// UpdateVote 更新投票记录
-func UpdateVote(userID, topicID int64, voteType int) error {
-	sqlStr := "UPDATE votes SET vote_type = ? WHERE user_id = ? AND topic_id = ?"
-	_, err := db.Exec(sqlStr, voteType, userID, topicID)
-	return err
-}
-
-// DeleteVote 删除投票记录
-func DeleteVote(userID, topicID int64) error {
-	sqlStr := "DELETE FROM votes WHERE user_id = ? AND topic_id = ?"
-	_, err := db.Exec(sqlStr, userID, topicID)
-	return err
-}
-
-// UpdateTopicLikeCount 更新话题点赞数
-func UpdateTopicLikeCount(topicID int64, delta int) error {
-	sqlStr := "UPDATE topics SET like_count = like_count + ? WHERE id = ?"
-	_, err := db.Exec(sqlStr, delta, topicID)
-	return err
+// ApplyVote 在同一事务中写入投票记录并更新话题的点赞/点踩数
+// oldType为0表示之前未投票（新增记录），newType为0表示取消投票（删除记录），否则修改投票类型
+func ApplyVote(voteID, userID, topicID int64, oldType, newType int) error {
+	likeDelta, dislikeDelta := voteCountDelta(oldType, newType)
+	return execWithOutbox(func(ext sqlx.Ext) error {
+		var err error
+		switch {
+		case oldType == 0:
+			_, err = ext.Exec("INSERT INTO votes (id, user_id, topic_id, vote_type) VALUES (?, ?, ?, ?)", voteID, userID, topicID, newType)
+		case newType == 0:
+			_, err = ext.Exec("DELETE FROM votes WHERE user_id = ? AND topic_id = ?", userID, topicID)
+		default:
+			_, err = ext.Exec("UPDATE votes SET vote_type = ? WHERE user_id = ? AND topic_id = ?", newType, userID, topicID)
+		}
+		if err != nil {
+			return err
+		}
+		_, err = ext.Exec("UPDATE topics SET like_count = like_count + ?, dislike_count = dislike_count + ? WHERE id = ?",
+			likeDelta, dislikeDelta, topicID)
+		return err
+	}, topicChange(outboxUpdate, topicID))
 }
 
-// UpdateTopicDislikeCount 更新话题点踩数
-func UpdateTopicDislikeCount(topicID int64, delta int) error {
-	sqlStr := "UPDATE topics SET dislike_count = dislike_count + ? WHERE id = ?"
-	_, err := db.Exec(sqlStr, delta, topicID)
-	return err
+// voteCountDelta 计算投票变化对点赞数和点踩数的影响（1=点赞，-1=点踩，0=未投票）
+func voteCountDelta(oldType, newType int) (likeDelta, dislikeDelta int) {
+	for _, change := range []struct{ voteType, delta int }{{oldType, -1}, {newType, 1}} {
+		switch change.voteType {
+		case 1:
+			likeDelta += change.delta
+		case -1:
+			dislikeDelta += change.delta
+		}
+	}
+	return likeDelta, dislikeDelta
 }
 
 // GetRecentTopics 获取最近的话题列表（用于热度排名计算）
 func GetRecentTopics(limit int) ([]*models.Topic, error) {
-	sqlStr := `SELECT t.id, t.user_id, u.username, t.title, t.content, t.category, 
+	sqlStr := `SELECT t.id, t.user_id, u.username, t.title, t.content, t.content_html, t.category, 
 	                  t.like_count, t.dislike_count, t.comment_count, t.view_count,
-	                  t.created_at, t.updated_at
+	                  t.is_pinned, t.pin_scope, t.is_locked, t.created_at, t.updated_at
 	           FROM topics t
 	           LEFT JOIN users u ON t.user_id = u.id
 	           ORDER BY t.created_at DESC
@@ -183,9 +253,9 @@ func GetTopicsByIDs(ids []int64) ([]*models.Topic, error) {
 	}
 
 	// 构建IN查询
-	query, args, err := sqlx.In(`SELECT t.id, t.user_id, u.username, t.title, t.content, t.category,
+	query, args, err := sqlx.In(`SELECT t.id, t.user_id, u.username, t.title, t.content, t.content_html, t.category, t.tags,
 	                                    t.like_count, t.dislike_count, t.comment_count, t.view_count,
-	                                    t.created_at, t.updated_at
+	                                    t.is_pinned, t.pin_scope, t.is_locked, t.created_at, t.updated_at
 	                             FROM topics t
 	                             LEFT JOIN users u ON t.user_id = u.id
 	                             WHERE t.id IN (?)`, ids)
@@ -202,3 +272,64 @@ func GetTopicsByIDs(ids []int64) ([]*models.Topic, error) {
 
 	return topics, nil
 }
+
+// GetTopicsAfterID 按ID游标分批读取话题（用于流式重建索引，避免一次性加载全部数据）
+func GetTopicsAfterID(lastID int64, limit int) ([]*models.Topic, error) {
+	sqlStr := `
+		SELECT t.*, u.username
+		FROM topics t
+		LEFT JOIN users u ON t.user_id = u.id
+		WHERE t.id > ?
+		ORDER BY t.id ASC
+		LIMIT ?
+	`
+	var topics []*models.Topic
+	if err := db.Select(&topics, sqlStr, lastID, limit); err != nil {
+		return nil, err
+	}
+	return topics, nil
+}
+
+// CountTopicsAfterID 统计ID大于lastID的话题数（用于估算同步进度）
+func CountTopicsAfterID(lastID int64) (int64, error) {
+	var count int64
+	err := db.Get(&count, "SELECT COUNT(*) FROM topics WHERE id > ?", lastID)
+	return count, err
+}
+
+// GetTopicsUpdatedSince 按ID游标分批读取指定时间之后更新过的话题
+func GetTopicsUpdatedSince(since time.Time, lastID int64, limit int) ([]*models.Topic, error) {
+	sqlStr := `
+		SELECT t.*, u.username
+		FROM topics t
+		LEFT JOIN users u ON t.user_id = u.id
+		WHERE t.updated_at >= ? AND t.id > ?
+		ORDER BY t.id ASC
+		LIMIT ?
+	`
+	var topics []*models.Topic
+	if err := db.Select(&topics, sqlStr, since, lastID, limit); err != nil {
+		return nil, err
+	}
+	return topics, nil
+}
+
+// GetExistingTopicIDs 返回给定ID中仍然存在的话题ID
+func GetExistingTopicIDs(ids []int64) (map[int64]bool, error) {
+	existing := make(map[int64]bool, len(ids))
+	if len(ids) == 0 {
+		return existing, nil
+	}
+	query, args, err := sqlx.In("SELECT id FROM topics WHERE id IN (?)", ids)
+	if err != nil {
+		return nil, err
+	}
+	var found []int64
+	if err := db.Select(&found, db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	for _, id := range found {
+		existing[id] = true
+	}
+	return existing, nil
+}
@@ -0,0 +1,160 @@
+package mysql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"web_app/models"
+)
+
+// 排序方式（与ES搜索的sort_by取值一致）
+const (
+	fullTextSortCreatedAt    = "created_at"
+	fullTextSortViewCount    = "view_count"
+	fullTextSortCommentCount = "comment_count"
+)
+
+// dateOnlyLen 只有日期（yyyy-MM-dd）的时间筛选参数长度
+const dateOnlyLen = len("2006-01-02")
+
+// matchExpr 标题和内容的全文匹配表达式（依赖 ft_title_content 索引，ngram分词）
+const matchExpr = "MATCH(t.title, t.content) AGAINST(? IN BOOLEAN MODE)"
+
+// FullTextSearchRequest MySQL全文检索参数（Elasticsearch不可用时的降级搜索）
+type FullTextSearchRequest struct {
+	Keyword     string   // 搜索关键词
+	Category    string   // 分类筛选
+	UserID      int64    // 作者ID筛选，0表示不限
+	Tags        []string // 标签筛选（需同时包含全部标签）
+	CreatedFrom string   // 发布时间下限（含），yyyy-MM-dd 或 yyyy-MM-dd HH:mm:ss
+	CreatedTo   string   // 发布时间上限（含），只有日期时包含当天全天
+	MinLikes    int      // 最少点赞数
+	MinComments int      // 最少评论数
+	Page        int      // 页码
+	PageSize    int      // 每页数量
+	SortBy      string   // 排序方式: created_at, view_count, comment_count，其他按相关度
+	MatchAny    bool     // 命中任意一个词即可（零结果时放宽匹配）
+	SnippetSize int      // 只返回内容的前N个字符（用于摘要），不返回渲染后的HTML
+}
+
+// fullTextColumns 搜索结果的列：不查询渲染后的HTML，内容只取开头用于摘要
+const fullTextColumns = `t.id, t.user_id, t.title, LEFT(t.content, ?) AS content, t.category, t.tags,
+		t.like_count, t.dislike_count, t.comment_count, t.view_count,
+		t.is_pinned, t.pin_scope, t.is_locked, t.created_at, t.updated_at, u.username`
+
+// SearchTopicsFullText 使用FULLTEXT索引搜索话题，返回当前页的话题（内容只有摘要部分）和总数
+func SearchTopicsFullText(req *FullTextSearchRequest) ([]*models.Topic, int64, error) {
+	where, args := buildFullTextWhere(req)
+	if where == "" {
+		// 关键词只有运算符等无效字符
+		return []*models.Topic{}, 0, nil
+	}
+
+	var total int64
+	if err := db.Get(&total, "SELECT COUNT(*) FROM topics t "+where, args...); err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return []*models.Topic{}, 0, nil
+	}
+
+	orderBy, orderArgs := buildFullTextOrder(req)
+	listSQL := fmt.Sprintf(`
+		SELECT %s
+		FROM topics t
+		LEFT JOIN users u ON t.user_id = u.id
+		%s
+		%s
+		LIMIT ? OFFSET ?
+	`, fullTextColumns, where, orderBy)
+	listArgs := append([]interface{}{req.SnippetSize}, args...)
+	listArgs = append(listArgs, orderArgs...)
+	listArgs = append(listArgs, req.PageSize, (req.Page-1)*req.PageSize)
+
+	var topics []*models.Topic
+	if err := db.Select(&topics, listSQL, listArgs...); err != nil {
+		return nil, 0, err
+	}
+	return topics, total, nil
+}
+
+// buildFullTextWhere 构建WHERE子句，关键词不为空但没有可检索的词时返回空字符串
+func buildFullTextWhere(req *FullTextSearchRequest) (string, []interface{}) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+
+	if req.Keyword != "" {
+		against := booleanModeQuery(req.Keyword, req.MatchAny)
+		if against == "" {
+			return "", nil
+		}
+		conditions = append(conditions, matchExpr)
+		args = append(args, against)
+	}
+	if req.Category != "" {
+		conditions = append(conditions, "t.category = ?")
+		args = append(args, req.Category)
+	}
+	if req.UserID != 0 {
+		conditions = append(conditions, "t.user_id = ?")
+		args = append(args, req.UserID)
+	}
+	for _, tag := range req.Tags {
+		conditions = append(conditions, "FIND_IN_SET(?, t.tags) > 0")
+		args = append(args, tag)
+	}
+	if req.CreatedFrom != "" {
+		conditions = append(conditions, "t.created_at >= ?")
+		args = append(args, req.CreatedFrom)
+	}
+	if req.CreatedTo != "" {
+		if len(req.CreatedTo) == dateOnlyLen {
+			conditions = append(conditions, "t.created_at < DATE_ADD(?, INTERVAL 1 DAY)")
+		} else {
+			conditions = append(conditions, "t.created_at <= ?")
+		}
+		args = append(args, req.CreatedTo)
+	}
+	if req.MinLikes > 0 {
+		conditions = append(conditions, "t.like_count >= ?")
+		args = append(args, req.MinLikes)
+	}
+	if req.MinComments > 0 {
+		conditions = append(conditions, "t.comment_count >= ?")
+		args = append(args, req.MinComments)
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// buildFullTextOrder 构建ORDER BY子句：按字段排序，或有关键词时按相关度（相同时新的在前）
+func buildFullTextOrder(req *FullTextSearchRequest) (string, []interface{}) {
+	switch req.SortBy {
+	case fullTextSortViewCount:
+		return "ORDER BY t.view_count DESC, t.created_at DESC", nil
+	case fullTextSortCommentCount:
+		return "ORDER BY t.comment_count DESC, t.created_at DESC", nil
+	case fullTextSortCreatedAt:
+		return "ORDER BY t.created_at DESC", nil
+	}
+	if req.Keyword == "" {
+		return "ORDER BY t.created_at DESC", nil
+	}
+	return "ORDER BY " + matchExpr + " DESC, t.created_at DESC", []interface{}{booleanModeQuery(req.Keyword, req.MatchAny)}
+}
+
+// booleanModeQuery 把关键词转换为BOOLEAN MODE查询：每个词作为短语匹配，默认需要全部命中
+// 去掉关键词中的全文检索运算符，避免用户输入改变查询语义或导致语法错误
+func booleanModeQuery(keyword string, matchAny bool) string {
+	words := strings.FieldsFunc(keyword, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(`+-<>()~*"@`, r)
+	})
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		term := `"` + word + `"`
+		if !matchAny {
+			term = "+" + term
+		}
+		terms = append(terms, term)
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,65 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBooleanModeQuery 测试关键词转换为BOOLEAN MODE查询并去掉运算符
+func TestBooleanModeQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		keyword  string
+		matchAny bool
+		want     string
+	}{
+		{"全部命中", "Go 并发", false, `+"Go" +"并发"`},
+		{"任意命中", "Go 并发", true, `"Go" "并发"`},
+		{"去掉运算符", `+go -"java" (c++)*`, false, `+"go" +"java" +"c"`},
+		{"只有运算符", `+- "" *`, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, booleanModeQuery(tt.keyword, tt.matchAny))
+		})
+	}
+}
+
+// TestBuildFullTextWhere 测试筛选条件与参数一一对应，只有日期的上限包含当天
+func TestBuildFullTextWhere(t *testing.T) {
+	where, args := buildFullTextWhere(&FullTextSearchRequest{
+		Keyword:     "并发",
+		Category:    "tech",
+		UserID:      3,
+		Tags:        []string{"go", "redis"},
+		CreatedFrom: "2024-01-01",
+		CreatedTo:   "2024-01-31",
+		MinLikes:    5,
+	})
+	assert.Equal(t, "WHERE 1=1 AND "+matchExpr+" AND t.category = ? AND t.user_id = ?"+
+		" AND FIND_IN_SET(?, t.tags) > 0 AND FIND_IN_SET(?, t.tags) > 0"+
+		" AND t.created_at >= ? AND t.created_at < DATE_ADD(?, INTERVAL 1 DAY) AND t.like_count >= ?", where)
+	assert.Equal(t, []interface{}{`+"并发"`, "tech", int64(3), "go", "redis", "2024-01-01", "2024-01-31", 5}, args)
+
+	where, args = buildFullTextWhere(&FullTextSearchRequest{CreatedTo: "2024-01-31 12:00:00"})
+	assert.Equal(t, "WHERE 1=1 AND t.created_at <= ?", where)
+	assert.Equal(t, []interface{}{"2024-01-31 12:00:00"}, args)
+
+	where, _ = buildFullTextWhere(&FullTextSearchRequest{Keyword: "***"})
+	assert.Empty(t, where, "没有可检索的词")
+}
+
+// TestBuildFullTextOrder 测试排序：指定字段优先，有关键词时默认按相关度
+func TestBuildFullTextOrder(t *testing.T) {
+	order, args := buildFullTextOrder(&FullTextSearchRequest{Keyword: "go", SortBy: "relevance"})
+	assert.Equal(t, "ORDER BY "+matchExpr+" DESC, t.created_at DESC", order)
+	assert.Equal(t, []interface{}{`+"go"`}, args)
+
+	order, args = buildFullTextOrder(&FullTextSearchRequest{Keyword: "go", SortBy: "view_count"})
+	assert.Equal(t, "ORDER BY t.view_count DESC, t.created_at DESC", order)
+	assert.Nil(t, args)
+
+	order, _ = buildFullTextOrder(&FullTextSearchRequest{SortBy: "combined"})
+	assert.Equal(t, "ORDER BY t.created_at DESC", order)
+}
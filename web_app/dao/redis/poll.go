@@ -0,0 +1,89 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// pollCountsPrefix 投票计数哈希键前缀（field=选项ID，value=得票数）
+	pollCountsPrefix = "poll:counts:"
+
+	// pollCountsTTL 投票计数缓存过期时间，过期后从MySQL重建
+	pollCountsTTL = 24 * time.Hour
+)
+
+// incrPollCountsScript 仅在哈希存在时累加计数
+// 哈希不存在时跳过，避免只写入部分选项导致其余选项被误读为0，下次读取时会从MySQL完整重建
+const incrPollCountsScript = `
+	if redis.call("EXISTS", KEYS[1]) == 0 then
+		return 0
+	end
+	for i = 1, #ARGV do
+		redis.call("HINCRBY", KEYS[1], ARGV[i], 1)
+	end
+	return 1
+`
+
+// GetPollCounts 获取投票各选项的得票数，缓存不存在时found为false
+func GetPollCounts(pollID int64) (counts map[int64]int64, found bool, err error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("%s%d", pollCountsPrefix, pollID)
+
+	values, err := rdb.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(values) == 0 {
+		return nil, false, nil
+	}
+
+	counts = make(map[int64]int64, len(values))
+	for field, value := range values {
+		optionID, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			continue
+		}
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		counts[optionID] = count
+	}
+	return counts, true, nil
+}
+
+// SetPollCounts 写入投票各选项的得票数（从MySQL重建缓存）
+func SetPollCounts(pollID int64, counts map[int64]int64) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	key := fmt.Sprintf("%s%d", pollCountsPrefix, pollID)
+
+	fields := make(map[string]interface{}, len(counts))
+	for optionID, count := range counts {
+		fields[strconv.FormatInt(optionID, 10)] = count
+	}
+
+	pipe := rdb.TxPipeline()
+	pipe.HSet(ctx, key, fields)
+	pipe.Expire(ctx, key, pollCountsTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// IncrPollCounts 为选中的选项各加一票
+func IncrPollCounts(pollID int64, optionIDs []int64) error {
+	ctx := context.Background()
+	key := fmt.Sprintf("%s%d", pollCountsPrefix, pollID)
+
+	args := make([]interface{}, 0, len(optionIDs))
+	for _, optionID := range optionIDs {
+		args = append(args, strconv.FormatInt(optionID, 10))
+	}
+	return rdb.Eval(ctx, incrPollCountsScript, []string{key}, args...).Err()
+}
@@ -0,0 +1,127 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+	"unicode/utf8"
+	"web_app/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// reindexTargetKey 重建索引期间需要双写的新索引名（所有实例的Canal消费者共享）
+	reindexTargetKey = "es:reindex:target"
+	// syncStatusKey 全量同步任务的进度和断点
+	syncStatusKey = "es:sync:status"
+	// searchQueryKeyPrefix 按天统计的搜索词次数（ZSET，后缀为 yyyyMMdd）
+	searchQueryKeyPrefix = "search:queries:"
+	// popularQueriesKey 近几天搜索词次数的合并结果（短期缓存，避免每次补全都合并）
+	popularQueriesKey = "search:popular_queries"
+	// searchQueryDays 热门搜索词统计的天数
+	searchQueryDays = 7
+	// popularQueriesTTL 合并结果的缓存时间
+	popularQueriesTTL = 10 * time.Minute
+	// popularQueriesLimit 合并结果保留的搜索词数
+	popularQueriesLimit = 500
+	// dailyQueriesLimit 每天的搜索词统计最多保留的搜索词数（超出时删除次数最少的，防止大量随机搜索词占满内存）
+	dailyQueriesLimit = 10000
+	// maxPopularQueryLen 计入热门搜索词的最大长度（字符），更长的搜索词不适合作为补全建议
+	maxPopularQueryLen = 30
+)
+
+// SetReindexTarget 设置重建索引的双写目标（带过期时间，防止任务异常退出后一直双写）
+func SetReindexTarget(target string, ttl time.Duration) error {
+	return rdb.Set(context.Background(), reindexTargetKey, target, ttl).Err()
+}
+
+// GetReindexTarget 获取重建索引的双写目标，没有进行中的重建时返回空字符串
+func GetReindexTarget() (string, error) {
+	target, err := rdb.Get(context.Background(), reindexTargetKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return target, err
+}
+
+// ClearReindexTarget 清除重建索引的双写目标
+func ClearReindexTarget() error {
+	return rdb.Del(context.Background(), reindexTargetKey).Err()
+}
+
+// SaveSyncStatus 保存全量同步进度（不过期，断点需要在重启后仍然可用）
+func SaveSyncStatus(status *models.ESSyncStatus) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return rdb.Set(context.Background(), syncStatusKey, data, 0).Err()
+}
+
+// GetSyncStatus 获取全量同步进度，从未同步过时返回 nil
+func GetSyncStatus() (*models.ESSyncStatus, error) {
+	data, err := rdb.Get(context.Background(), syncStatusKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var status models.ESSyncStatus
+	if err := json.Unmarshal([]byte(data), &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// IncrSearchQuery 搜索词当天的次数加1（保留 searchQueryDays 天，每天最多保留 dailyQueriesLimit 个搜索词）
+// 为空或超过 maxPopularQueryLen 的搜索词不计入
+func IncrSearchQuery(query string) error {
+	if query == "" || utf8.RuneCountInString(query) > maxPopularQueryLen {
+		return nil
+	}
+
+	ctx := context.Background()
+	key := searchQueryKeyPrefix + time.Now().Format("20060102")
+
+	pipe := rdb.TxPipeline()
+	pipe.ZIncrBy(ctx, key, 1, query)
+	pipe.ZRemRangeByRank(ctx, key, 0, -dailyQueriesLimit-1) // 只保留次数最多的部分
+	pipe.Expire(ctx, key, (searchQueryDays+1)*24*time.Hour)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetPopularQueries 获取近 searchQueryDays 天搜索次数不少于 minCount 的搜索词（按次数降序）
+func GetPopularQueries(minCount int64) ([]string, error) {
+	ctx := context.Background()
+
+	exists, err := rdb.Exists(ctx, popularQueriesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		now := time.Now()
+		keys := make([]string, searchQueryDays)
+		for i := range keys {
+			keys[i] = searchQueryKeyPrefix + now.AddDate(0, 0, -i).Format("20060102")
+		}
+
+		pipe := rdb.TxPipeline()
+		pipe.ZUnionStore(ctx, popularQueriesKey, &redis.ZStore{Keys: keys, Aggregate: "SUM"})
+		pipe.ZRemRangeByRank(ctx, popularQueriesKey, 0, -popularQueriesLimit-1) // 只保留次数最多的部分
+		pipe.Expire(ctx, popularQueriesKey, popularQueriesTTL)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return rdb.ZRevRangeByScore(ctx, popularQueriesKey, &redis.ZRangeBy{
+		Min: strconv.FormatInt(minCount, 10),
+		Max: "+inf",
+	}).Result()
+}
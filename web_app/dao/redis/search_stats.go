@@ -0,0 +1,152 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+	"web_app/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// searchStatsPrefix 按小时统计的搜索数据（后缀为 yyyyMMddHH）
+	searchStatsPrefix = "search:stats:"
+	// searchStatsTTL 小时统计的保留时间（超过的部分只在MySQL中保存）
+	searchStatsTTL = 8 * 24 * time.Hour
+	// SearchStatsMaxHours 可以从Redis查询的最大小时数
+	SearchStatsMaxHours = 7 * 24
+	// hourlyQueriesLimit 每小时的搜索词统计最多保留的搜索词数（超出时删除次数最少的）
+	hourlyQueriesLimit = 5000
+)
+
+// 小时统计中的字段
+const (
+	searchStatsTotal   = "total"      // 搜索次数
+	searchStatsZero    = "zero"       // 零结果次数
+	searchStatsLatency = "latency_ms" // 累计耗时（毫秒）
+)
+
+// searchStatsKey 某个小时某类统计的key：summary（计数hash）、queries（搜索词ZSET）、zero（零结果搜索词ZSET）
+func searchStatsKey(hour time.Time, kind string) string {
+	return searchStatsPrefix + hour.Format("2006010215") + ":" + kind
+}
+
+// recentHours 最近 n 个小时的整点时间（从当前小时开始倒序）
+func recentHours(now time.Time, n int) []time.Time {
+	current := now.Truncate(time.Hour)
+	hours := make([]time.Time, n)
+	for i := range hours {
+		hours[i] = current.Add(-time.Duration(i) * time.Hour)
+	}
+	return hours
+}
+
+// IncrSearchStats 将一次搜索计入所在小时的统计（每小时最多保留 hourlyQueriesLimit 个搜索词）
+func IncrSearchStats(log *models.SearchLog) error {
+	ctx := context.Background()
+	hour := log.CreatedAt.Truncate(time.Hour)
+	summaryKey := searchStatsKey(hour, "summary")
+	queriesKey := searchStatsKey(hour, "queries")
+	zeroKey := searchStatsKey(hour, "zero")
+
+	pipe := rdb.TxPipeline()
+	pipe.HIncrBy(ctx, summaryKey, searchStatsTotal, 1)
+	pipe.HIncrBy(ctx, summaryKey, searchStatsLatency, log.LatencyMs)
+	pipe.ZIncrBy(ctx, queriesKey, 1, log.Keyword)
+	pipe.ZRemRangeByRank(ctx, queriesKey, 0, -hourlyQueriesLimit-1)
+	if log.ResultCount == 0 {
+		pipe.HIncrBy(ctx, summaryKey, searchStatsZero, 1)
+		pipe.ZIncrBy(ctx, zeroKey, 1, log.Keyword)
+		pipe.ZRemRangeByRank(ctx, zeroKey, 0, -hourlyQueriesLimit-1)
+		pipe.Expire(ctx, zeroKey, searchStatsTTL)
+	}
+	pipe.Expire(ctx, summaryKey, searchStatsTTL)
+	pipe.Expire(ctx, queriesKey, searchStatsTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetTopSearchQueries 获取最近 hours 小时搜索次数最多的搜索词，zeroOnly 为 true 时只统计零结果的搜索
+func GetTopSearchQueries(hours, limit int, zeroOnly bool) ([]models.SearchQueryStat, error) {
+	kind := "queries"
+	if zeroOnly {
+		kind = "zero"
+	}
+	keys := make([]string, 0, hours)
+	for _, hour := range recentHours(time.Now(), hours) {
+		keys = append(keys, searchStatsKey(hour, kind))
+	}
+
+	// 在Redis中合并到临时key后只取前 limit 个（同一事务内写入和删除，并发查询互不影响）
+	ctx := context.Background()
+	tmpKey := searchStatsPrefix + "top:" + kind
+	pipe := rdb.TxPipeline()
+	pipe.ZUnionStore(ctx, tmpKey, &redis.ZStore{Keys: keys, Aggregate: "SUM"})
+	rangeCmd := pipe.ZRevRangeWithScores(ctx, tmpKey, 0, int64(limit)-1)
+	pipe.Del(ctx, tmpKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	members := rangeCmd.Val()
+	stats := make([]models.SearchQueryStat, 0, len(members))
+	for _, member := range members {
+		keyword, _ := member.Member.(string)
+		stats = append(stats, models.SearchQueryStat{Keyword: keyword, Count: int64(member.Score)})
+	}
+	return stats, nil
+}
+
+// GetHourlySearchStats 获取最近 hours 小时每小时的搜索次数、零结果率和平均耗时（当前小时在前）
+func GetHourlySearchStats(hours int) ([]*models.SearchHourlyStat, error) {
+	ctx := context.Background()
+	hourList := recentHours(time.Now(), hours)
+
+	pipe := rdb.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(hourList))
+	for i, hour := range hourList {
+		cmds[i] = pipe.HGetAll(ctx, searchStatsKey(hour, "summary"))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	stats := make([]*models.SearchHourlyStat, 0, len(hourList))
+	for i, hour := range hourList {
+		fields := cmds[i].Val()
+		total, _ := strconv.ParseInt(fields[searchStatsTotal], 10, 64)
+		zero, _ := strconv.ParseInt(fields[searchStatsZero], 10, 64)
+		latency, _ := strconv.ParseInt(fields[searchStatsLatency], 10, 64)
+
+		stat := &models.SearchHourlyStat{Hour: hour, Total: total, ZeroResults: zero}
+		if total > 0 {
+			stat.ZeroRate = float64(zero) / float64(total)
+			stat.AvgLatencyMs = float64(latency) / float64(total)
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+// GetHourSearchQueryCounts 获取某个小时每个搜索词的搜索次数和零结果次数（用于持久化到MySQL）
+func GetHourSearchQueryCounts(hour time.Time) (searches, zeros map[string]int64, err error) {
+	ctx := context.Background()
+	pipe := rdb.Pipeline()
+	queriesCmd := pipe.ZRangeWithScores(ctx, searchStatsKey(hour, "queries"), 0, -1)
+	zeroCmd := pipe.ZRangeWithScores(ctx, searchStatsKey(hour, "zero"), 0, -1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	toMap := func(members []redis.Z) map[string]int64 {
+		counts := make(map[string]int64, len(members))
+		for _, z := range members {
+			if keyword, ok := z.Member.(string); ok {
+				counts[keyword] = int64(z.Score)
+			}
+		}
+		return counts
+	}
+	return toMap(queriesCmd.Val()), toMap(zeroCmd.Val()), nil
+}
@@ -10,13 +10,15 @@ import (
 
 const (
 	// 缓存键前缀
-	topicDetailPrefix = "topic:detail:"  // 话题详情缓存键前缀
-	topicListPrefix   = "topic:list:"    // 话题列表缓存键前缀
-	hotTopicsKey      = "topic:list:hot" // 热门话题列表缓存键
+	topicDetailPrefix  = "topic:detail:"  // 话题详情缓存键前缀
+	topicListPrefix    = "topic:list:"    // 话题列表缓存键前缀
+	hotTopicsKey       = "topic:list:hot" // 热门话题列表缓存键
+	topicRelatedPrefix = "topic:related:" // 相关话题ID缓存键前缀
 
 	// 缓存过期时间
-	topicDetailTTL = 10 * time.Minute // 话题详情缓存10分钟
-	topicListTTL   = 5 * time.Minute  // 话题列表缓存5分钟
+	topicDetailTTL  = 10 * time.Minute // 话题详情缓存10分钟
+	topicListTTL    = 5 * time.Minute  // 话题列表缓存5分钟
+	topicRelatedTTL = 30 * time.Minute // 相关话题缓存30分钟（新发布的话题在过期后才会出现）
 )
 
 // CacheTopicDetail 缓存话题详情
@@ -61,6 +63,42 @@ func DeleteTopicCache(topicID int64) error {
 	return rdb.Del(ctx, key).Err()
 }
 
+// CacheRelatedTopicIDs 缓存相关话题ID（只缓存ID，话题数据读取时从数据库获取，避免内容过期）
+func CacheRelatedTopicIDs(topicID int64, ids []int64) error {
+	ctx := context.Background()
+	key := fmt.Sprintf("%s%d", topicRelatedPrefix, topicID)
+
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	return rdb.Set(ctx, key, data, topicRelatedTTL).Err()
+}
+
+// GetRelatedTopicIDsCache 获取相关话题ID缓存
+func GetRelatedTopicIDsCache(topicID int64) ([]int64, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("%s%d", topicRelatedPrefix, topicID)
+
+	data, err := rdb.Get(ctx, key).Result()
+	if err != nil {
+		return nil, err // 包括redis.Nil（缓存未命中）
+	}
+
+	var ids []int64
+	if err := json.Unmarshal([]byte(data), &ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// DeleteRelatedTopicsCache 删除相关话题缓存
+func DeleteRelatedTopicsCache(topicID int64) error {
+	ctx := context.Background()
+	key := fmt.Sprintf("%s%d", topicRelatedPrefix, topicID)
+	return rdb.Del(ctx, key).Err()
+}
+
 // CacheTopicList 缓存话题列表
 func CacheTopicList(cacheKey string, topics []*models.Topic, total int64) error {
 	ctx := context.Background()
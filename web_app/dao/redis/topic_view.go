@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	topicViewedPrefix     = "topic:viewed:"        // 已计入浏览数的浏览事件ID（事件重复投递时跳过）
+	topicViewsPendingKey  = "topic:views:pending"  // 待写入MySQL的浏览数增量（hash：话题ID → 增量）
+	topicViewsFlushingKey = "topic:views:flushing" // 正在写入MySQL的浏览数增量（写入成功后删除）
+
+	topicViewedTTL = 24 * time.Hour // 浏览事件去重标记保留24小时（超过事件可能重复投递的时间）
+)
+
+// recordTopicViewScript 浏览事件去重并累加浏览数增量，两步原子执行，重复投递的事件不会重复计数
+// KEYS[1]=去重key KEYS[2]=增量hash ARGV[1]=话题ID ARGV[2]=去重标记过期时间（秒）
+const recordTopicViewScript = `
+if redis.call("SET", KEYS[1], 1, "NX", "EX", ARGV[2]) then
+	redis.call("HINCRBY", KEYS[2], ARGV[1], 1)
+	return 1
+end
+return 0
+`
+
+// takeTopicViewsScript 上次取出的增量未写入完成时继续返回它，否则把当前增量整体移到flushing后返回
+// KEYS[1]=增量hash KEYS[2]=正在写入的hash
+const takeTopicViewsScript = `
+if redis.call("EXISTS", KEYS[2]) == 0 and redis.call("EXISTS", KEYS[1]) == 1 then
+	redis.call("RENAME", KEYS[1], KEYS[2])
+end
+return redis.call("HGETALL", KEYS[2])
+`
+
+// RecordTopicView 累加一次浏览（viewID为0的旧事件不去重）
+func RecordTopicView(viewID, topicID int64) error {
+	ctx := context.Background()
+	field := strconv.FormatInt(topicID, 10)
+	if viewID == 0 {
+		return rdb.HIncrBy(ctx, topicViewsPendingKey, field, 1).Err()
+	}
+
+	dedupKey := fmt.Sprintf("%s%d", topicViewedPrefix, viewID)
+	keys := []string{dedupKey, topicViewsPendingKey}
+	return rdb.Eval(ctx, recordTopicViewScript, keys, field, int(topicViewedTTL.Seconds())).Err()
+}
+
+// TakePendingTopicViews 取出待写入MySQL的浏览数增量（话题ID → 增量）
+// 写入成功后调用AckPendingTopicViews；写入失败或进程退出时，下次调用返回同一批增量，期间的新浏览继续累加到pending
+func TakePendingTopicViews() (map[int64]int64, error) {
+	ctx := context.Background()
+	result, err := rdb.Eval(ctx, takeTopicViewsScript, []string{topicViewsPendingKey, topicViewsFlushingKey}).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int64]int64, len(result)/2)
+	for i := 0; i+1 < len(result); i += 2 {
+		topicID, err := strconv.ParseInt(result[i], 10, 64)
+		if err != nil {
+			continue
+		}
+		count, err := strconv.ParseInt(result[i+1], 10, 64)
+		if err != nil || count <= 0 {
+			continue
+		}
+		counts[topicID] = count
+	}
+	return counts, nil
+}
+
+// AckPendingTopicViews 删除已写入MySQL的浏览数增量
+func AckPendingTopicViews() error {
+	return rdb.Del(context.Background(), topicViewsFlushingKey).Err()
+}
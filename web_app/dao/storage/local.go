@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage 本地文件系统存储
+type LocalStorage struct {
+	root    string // 存储根目录
+	baseURL string // 访问地址前缀（例如 /uploads）
+}
+
+// NewLocalStorage 创建本地文件系统存储
+func NewLocalStorage(root, baseURL string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("创建存储目录失败: %w", err)
+	}
+	if baseURL == "" {
+		baseURL = "/uploads"
+	}
+	return &LocalStorage{
+		root:    root,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}, nil
+}
+
+// path 将key转换为本地路径，拒绝跳出根目录的key
+func (s *LocalStorage) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if cleaned == "/" {
+		return "", errors.New("无效的存储key")
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
+
+// Put 写入对象（先写临时文件再重命名，避免读到半个文件）
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // 重命名成功后临时文件已不存在
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// Get 读取对象
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Exists 判断对象是否存在
+func (s *LocalStorage) Exists(ctx context.Context, key string) (bool, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Delete 删除对象
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// URL 获取对象的访问地址
+func (s *LocalStorage) URL(key string) string {
+	return s.baseURL + "/" + strings.TrimLeft(key, "/")
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLocalStorage_PutGetDelete 测试本地存储的读写删除
+func TestLocalStorage_PutGetDelete(t *testing.T) {
+	s, err := NewLocalStorage(t.TempDir(), "/uploads/")
+	require.NoError(t, err)
+	ctx := context.Background()
+	data := []byte("hello blossom")
+
+	require.NoError(t, s.Put(ctx, "ab/cd/file.txt", bytes.NewReader(data), int64(len(data)), "text/plain"))
+
+	ok, err := s.Exists(ctx, "ab/cd/file.txt")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	rc, err := s.Get(ctx, "ab/cd/file.txt")
+	require.NoError(t, err)
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, data, got)
+
+	assert.Equal(t, "/uploads/ab/cd/file.txt", s.URL("ab/cd/file.txt"))
+
+	require.NoError(t, s.Delete(ctx, "ab/cd/file.txt"))
+	ok, err = s.Exists(ctx, "ab/cd/file.txt")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// 重复删除不报错
+	assert.NoError(t, s.Delete(ctx, "ab/cd/file.txt"))
+
+	_, err = s.Get(ctx, "ab/cd/file.txt")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestLocalStorage_PathTraversal 测试key无法跳出存储根目录
+func TestLocalStorage_PathTraversal(t *testing.T) {
+	root := t.TempDir()
+	s, err := NewLocalStorage(root, "")
+	require.NoError(t, err)
+
+	p, err := s.path("../../etc/passwd")
+	require.NoError(t, err)
+	assert.Equal(t, root+"/etc/passwd", p)
+
+	_, err = s.path("../")
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config S3兼容对象存储配置（AWS S3 / MinIO / 阿里云OSS等）
+type S3Config struct {
+	Endpoint  string // 服务地址（host:port，不带协议）
+	AccessKey string // 访问密钥ID
+	SecretKey string // 访问密钥
+	Bucket    string // 存储桶
+	Region    string // 区域（设置后不再请求桶位置）
+	UseSSL    bool   // 是否使用HTTPS
+	PublicURL string // 对外访问地址前缀（为空时使用 endpoint/bucket）
+}
+
+// S3Storage S3兼容对象存储
+type S3Storage struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+}
+
+// NewS3Storage 创建S3兼容对象存储
+func NewS3Storage(cfg S3Config) (*S3Storage, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("S3存储的endpoint和bucket不能为空")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建S3客户端失败: %w", err)
+	}
+
+	publicURL := cfg.PublicURL
+	if publicURL == "" {
+		scheme := "http"
+		if cfg.UseSSL {
+			scheme = "https"
+		}
+		publicURL = fmt.Sprintf("%s://%s/%s", scheme, cfg.Endpoint, cfg.Bucket)
+	}
+
+	return &S3Storage{
+		client:    client,
+		bucket:    cfg.Bucket,
+		publicURL: strings.TrimRight(publicURL, "/"),
+	}, nil
+}
+
+// Put 写入对象
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType:  contentType,
+		CacheControl: "public, max-age=31536000, immutable", // key由内容哈希生成，内容不会变化
+	})
+	return err
+}
+
+// Get 读取对象
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	// GetObject是惰性的，先Stat以便把不存在转换为ErrNotFound
+	if ok, err := s.Exists(ctx, key); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrNotFound
+	}
+	return s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+}
+
+// Exists 判断对象是否存在
+func (s *S3Storage) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err == nil {
+		return true, nil
+	}
+	if minio.ToErrorResponse(err).StatusCode == 404 {
+		return false, nil
+	}
+	return false, err
+}
+
+// Delete 删除对象
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+// URL 获取对象的访问地址
+func (s *S3Storage) URL(key string) string {
+	return s.publicURL + "/" + strings.TrimLeft(key, "/")
+}
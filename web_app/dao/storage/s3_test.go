@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 最小化的S3兼容服务（仅支持路径风格的单对象 PUT/GET/HEAD/DELETE）
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: map[string][]byte{}, types: map[string]string{}}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	switch r.Method {
+	case http.MethodPut:
+		body, err := readS3Body(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = body
+		f.types[key] = r.Header.Get("Content-Type")
+		w.Header().Set("ETag", `"fake-etag"`)
+		w.WriteHeader(http.StatusOK)
+	case http.MethodHead, http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", f.types[key])
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("ETag", `"fake-etag"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// readS3Body 读取请求体，兼容aws-chunked流式签名格式
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var out bytes.Buffer
+	br := bufio.NewReader(r.Body)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex := strings.SplitN(strings.TrimSpace(line), ";", 2)[0]
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return out.Bytes(), nil
+		}
+		if _, err := io.CopyN(&out, br, size); err != nil {
+			return nil, err
+		}
+		br.ReadString('\n') // 块结尾的CRLF
+	}
+}
+
+// TestS3Storage_PutGetDelete 测试S3存储的读写删除
+func TestS3Storage_PutGetDelete(t *testing.T) {
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s, err := NewS3Storage(S3Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		AccessKey: "minioadmin",
+		SecretKey: "minioadmin",
+		Bucket:    "blossom",
+		PublicURL: "https://cdn.example.com/",
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	data := []byte("hello blossom")
+
+	require.NoError(t, s.Put(ctx, "ab/cd/file.txt", bytes.NewReader(data), int64(len(data)), "text/plain"))
+	assert.Equal(t, data, fake.objects["blossom/ab/cd/file.txt"])
+	assert.Equal(t, "text/plain", fake.types["blossom/ab/cd/file.txt"])
+
+	ok, err := s.Exists(ctx, "ab/cd/file.txt")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	rc, err := s.Get(ctx, "ab/cd/file.txt")
+	require.NoError(t, err)
+	got, err := io.ReadAll(rc)
+	rc.Close()
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	assert.Equal(t, "https://cdn.example.com/ab/cd/file.txt", s.URL("ab/cd/file.txt"))
+
+	require.NoError(t, s.Delete(ctx, "ab/cd/file.txt"))
+	ok, err = s.Exists(ctx, "ab/cd/file.txt")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = s.Get(ctx, "ab/cd/file.txt")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestS3Storage_DefaultPublicURL 测试未配置public_url时的访问地址
+func TestS3Storage_DefaultPublicURL(t *testing.T) {
+	s, err := NewS3Storage(S3Config{Endpoint: "127.0.0.1:9000", Bucket: "blossom"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:9000/blossom/a.png", s.URL("a.png"))
+
+	_, err = NewS3Storage(S3Config{Endpoint: "127.0.0.1:9000"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,75 @@
+// Package storage 提供可插拔的文件存储（本地文件系统 / S3兼容对象存储）
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// ErrNotFound 对象不存在
+var ErrNotFound = errors.New("对象不存在")
+
+// Storage 文件存储接口
+type Storage interface {
+	// Put 写入对象（同key覆盖）
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get 读取对象，调用方负责关闭返回的Reader
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Exists 判断对象是否存在
+	Exists(ctx context.Context, key string) (bool, error)
+	// Delete 删除对象（不存在时不报错）
+	Delete(ctx context.Context, key string) error
+	// URL 获取对象的访问地址
+	URL(key string) string
+}
+
+// store 全局存储实例
+var store Storage
+
+// Init 根据配置初始化存储后端
+func Init() error {
+	backend := viper.GetString("upload.backend")
+
+	var err error
+	switch backend {
+	case "s3":
+		store, err = NewS3Storage(S3Config{
+			Endpoint:  viper.GetString("upload.s3.endpoint"),
+			AccessKey: viper.GetString("upload.s3.access_key"),
+			SecretKey: viper.GetString("upload.s3.secret_key"),
+			Bucket:    viper.GetString("upload.s3.bucket"),
+			Region:    viper.GetString("upload.s3.region"),
+			UseSSL:    viper.GetBool("upload.s3.use_ssl"),
+			PublicURL: viper.GetString("upload.s3.public_url"),
+		})
+	case "local", "":
+		store, err = NewLocalStorage(LocalDir(), viper.GetString("upload.local.base_url"))
+	default:
+		return fmt.Errorf("未知的存储后端: %s", backend)
+	}
+	if err != nil {
+		return err
+	}
+
+	zap.L().Info("文件存储初始化成功", zap.String("backend", backend))
+	return nil
+}
+
+// GetStorage 获取全局存储实例
+func GetStorage() Storage {
+	return store
+}
+
+// LocalDir 获取本地存储目录（本地后端时由路由挂载为静态目录）
+func LocalDir() string {
+	dir := viper.GetString("upload.local.dir")
+	if dir == "" {
+		dir = "uploads"
+	}
+	return dir
+}
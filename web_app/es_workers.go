@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"time"
+	"web_app/consumers"
+	"web_app/dao/elasticsearch"
+	"web_app/dao/mysql"
+	"web_app/tasks"
+	"web_app/workers"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	// esConnectBackoff 首次重连Elasticsearch的等待时间，连续失败时按指数增长
+	esConnectBackoff = 5 * time.Second
+	// esConnectMaxBackoff 最长重连等待时间
+	esConnectMaxBackoff = time.Minute
+)
+
+// startESWorkers 启动依赖ES的后台任务：Kafka消费者（或直接写入ES的发件箱转发）和一致性检查
+func startESWorkers(consumerWorkers, taskWorkers *workers.Group) {
+	if mysql.OutboxEnabled() && consumers.OutboxDirect() {
+		consumerWorkers.Go("outbox-relay", consumers.RunOutboxRelay) // 事务发件箱转发（直接写入ES）
+	}
+	if !consumers.OutboxDirect() {
+		consumerWorkers.Go("es-consumer", consumers.RunESConsumer) // Kafka消费者（ES同步）
+	}
+	if viper.GetBool("elasticsearch.consistency.enabled") {
+		taskWorkers.Go("es-consistency", tasks.RunESConsistencyTask) // MySQL与ES一致性检查定时任务
+	}
+}
+
+// runESConnector 返回后台重连Elasticsearch的任务：连接成功后调用onConnected启动ES同步任务，然后等待关机
+// 未连接期间搜索降级为MySQL全文检索，变更保留在Kafka或发件箱中，连接后由同步任务补齐
+func runESConnector(onConnected func()) workers.RunFunc {
+	return func(ctx context.Context) error {
+		backoff := esConnectBackoff
+		for !elasticsearch.Available() {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return nil
+			}
+
+			if err := elasticsearch.Init(); err != nil {
+				zap.L().Warn("重连Elasticsearch失败", zap.Error(err), zap.Duration("backoff", backoff))
+				if backoff *= 2; backoff > esConnectMaxBackoff {
+					backoff = esConnectMaxBackoff
+				}
+				continue
+			}
+			zap.L().Info("Elasticsearch重连成功，启动ES同步任务")
+		}
+
+		if ctx.Err() == nil {
+			onConnected()
+		}
+		<-ctx.Done()
+		return nil
+	}
+}
@@ -0,0 +1,242 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 事件总线实现
+const (
+	backendLocal = "local" // 进程内分发
+	backendKafka = "kafka" // 通过Kafka分发（多实例时每个事件只由消费者组中的一个实例处理）
+)
+
+const (
+	// publishTimeout 发布事件的最长等待时间（进程内队列已满或Kafka写入缓慢时）
+	publishTimeout = time.Second
+	// maxRetryBackoff 订阅者重试的最长等待时间
+	maxRetryBackoff = 10 * time.Second
+)
+
+// errNotInitialized 事件总线尚未初始化
+var errNotInitialized = errors.New("事件总线未初始化")
+
+var (
+	// 已发布的事件数
+	eventsPublished = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "domain_events_published_total",
+			Help: "已发布的领域事件数",
+		},
+		[]string{"event"},
+	)
+
+	// 订阅者重试后仍然失败的事件数
+	eventHandlerFailures = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "domain_event_handler_failures_total",
+			Help: "订阅者重试后仍然处理失败的领域事件数",
+		},
+		[]string{"event", "subscriber"},
+	)
+)
+
+// Handler 订阅者处理函数，返回error时退避重试
+type Handler func(ctx context.Context, event Event) error
+
+// Bus 事件总线
+type Bus interface {
+	// Publish 发布事件，事件进入队列后即返回
+	Publish(ctx context.Context, event Event) error
+	// Subscribe 为指定名称的事件注册订阅者，须在Run之前调用
+	Subscribe(name, subscriber string, handler Handler)
+	// Run 分发事件直到ctx取消
+	Run(ctx context.Context) error
+	// Close 释放资源
+	Close() error
+}
+
+// subscription 一个订阅者
+type subscription struct {
+	subscriber string
+	handler    Handler
+}
+
+// dispatcher 把事件分发给订阅者，两种实现共用
+// 每个订阅者独立重试，某个订阅者失败不影响其他订阅者，也不会让已成功的订阅者重复执行
+type dispatcher struct {
+	subs         map[string][]subscription
+	maxRetries   int           // 处理失败后的重试次数
+	retryBackoff time.Duration // 首次重试间隔，之后按指数增长
+}
+
+func newDispatcher(maxRetries int, retryBackoff time.Duration) *dispatcher {
+	return &dispatcher{
+		subs:         make(map[string][]subscription),
+		maxRetries:   maxRetries,
+		retryBackoff: retryBackoff,
+	}
+}
+
+// Subscribe 注册订阅者
+func (d *dispatcher) Subscribe(name, subscriber string, handler Handler) {
+	d.subs[name] = append(d.subs[name], subscription{subscriber: subscriber, handler: handler})
+}
+
+// dispatch 依次调用事件的所有订阅者
+// ctx取消后不再等待重试，剩余的订阅者只执行一次（关机时尽量处理完已入队的事件）
+func (d *dispatcher) dispatch(ctx context.Context, event Event) {
+	// 订阅者使用不随关机取消的ctx，保证排空队列时仍能写入
+	handlerCtx := context.WithoutCancel(ctx)
+	for _, sub := range d.subs[event.Name()] {
+		var err error
+		for attempt := 0; attempt <= d.maxRetries; attempt++ {
+			if attempt > 0 {
+				if !sleepContext(ctx, retryBackoff(d.retryBackoff, attempt-1)) {
+					break
+				}
+			}
+			if err = safeHandle(handlerCtx, sub.handler, event); err == nil {
+				break
+			}
+			zap.L().Warn("订阅者处理事件失败",
+				zap.Error(err),
+				zap.String("event", event.Name()),
+				zap.String("subscriber", sub.subscriber),
+				zap.Int64("key", event.Key()),
+				zap.Int("attempt", attempt+1))
+		}
+		if err != nil {
+			eventHandlerFailures.WithLabelValues(event.Name(), sub.subscriber).Inc()
+			zap.L().Error("订阅者重试后仍然失败，放弃该事件",
+				zap.Error(err),
+				zap.String("event", event.Name()),
+				zap.String("subscriber", sub.subscriber),
+				zap.Any("payload", event))
+		}
+	}
+}
+
+// safeHandle 调用订阅者，panic转换为error（不影响同一队列中的后续事件）
+func safeHandle(ctx context.Context, handler Handler, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("订阅者panic: %v", r)
+			zap.L().Error("订阅者panic", zap.Any("panic", r), zap.ByteString("stack", debug.Stack()))
+		}
+	}()
+	return handler(ctx, event)
+}
+
+// retryBackoff 计算第attempt次重试前的等待时间（指数增长，不超过maxRetryBackoff）
+func retryBackoff(base time.Duration, attempt int) time.Duration {
+	backoff := base
+	for i := 0; i < attempt && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return backoff
+}
+
+// sleepContext 等待指定时间，ctx取消时提前返回false
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// bus 应用的事件总线，Init之前发布事件会返回错误
+var bus Bus
+
+// Init 按配置创建事件总线
+func Init() error {
+	maxRetries := viper.GetInt("events.max_retries")
+	retryBackoff := viper.GetDuration("events.retry_backoff")
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	if retryBackoff <= 0 {
+		retryBackoff = 200 * time.Millisecond
+	}
+	d := newDispatcher(maxRetries, retryBackoff)
+
+	backend := viper.GetString("events.backend")
+	switch backend {
+	case backendKafka:
+		topic := viper.GetString("events.kafka_topic")
+		if topic == "" {
+			return errors.New("events.kafka_topic 未配置")
+		}
+		bus = newKafkaBus(d, viper.GetStringSlice("kafka.brokers"), topic, viper.GetString("events.group_id"))
+	case backendLocal, "":
+		backend = backendLocal
+		bus = newLocalBus(d, viper.GetInt("events.workers"), viper.GetInt("events.buffer_size"))
+	default:
+		return fmt.Errorf("不支持的事件总线实现: %s", backend)
+	}
+
+	zap.L().Info("事件总线初始化成功", zap.String("backend", backend))
+	return nil
+}
+
+// Publish 发布事件（最多等待publishTimeout），失败时返回错误，由调用方决定如何处理
+func Publish(event Event) error {
+	if bus == nil {
+		return errNotInitialized
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	if err := bus.Publish(ctx, event); err != nil {
+		return err
+	}
+	eventsPublished.WithLabelValues(event.Name()).Inc()
+	return nil
+}
+
+// On 为类型T的事件注册订阅者，须在Init之后、Run之前调用
+func On[T Event](subscriber string, handle func(ctx context.Context, event T) error) {
+	if bus == nil {
+		panic(errNotInitialized)
+	}
+	var zero T
+	bus.Subscribe(zero.Name(), subscriber, func(ctx context.Context, event Event) error {
+		typed, ok := event.(T)
+		if !ok {
+			return fmt.Errorf("事件类型不匹配: %T", event)
+		}
+		return handle(ctx, typed)
+	})
+}
+
+// Run 分发事件直到ctx取消（由main.go注册到后台任务监督者）
+func Run(ctx context.Context) error {
+	if bus == nil {
+		return errNotInitialized
+	}
+	return bus.Run(ctx)
+}
+
+// Close 关闭事件总线
+func Close() {
+	if bus == nil {
+		return
+	}
+	if err := bus.Close(); err != nil {
+		zap.L().Warn("关闭事件总线失败", zap.Error(err))
+	}
+}
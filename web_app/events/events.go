@@ -0,0 +1,124 @@
+// Package events 领域事件总线：业务逻辑在写入成功后发布事件，缓存清理、计数器、@提及等副作用由订阅者处理
+// 同一话题的事件按发布顺序处理，订阅者失败时退避重试
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// 事件名称（Kafka实现中作为消息头，用于解码）
+const (
+	NameTopicCreated   = "topic.created"
+	NameTopicViewed    = "topic.viewed"
+	NameTopicPinned    = "topic.pinned"
+	NameTopicLocked    = "topic.locked"
+	NameCommentCreated = "comment.created"
+	NameCommentDeleted = "comment.deleted"
+	NameVoteCast       = "vote.cast"
+)
+
+// Event 领域事件
+type Event interface {
+	// Name 事件名称
+	Name() string
+	// Key 排序键（话题ID），相同排序键的事件按发布顺序处理
+	Key() int64
+}
+
+// TopicCreated 话题已创建
+type TopicCreated struct {
+	TopicID   int64     `json:"topic_id,string"`
+	UserID    int64     `json:"user_id,string"`
+	Content   string    `json:"content"` // Markdown原文（解析@提及）
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TopicViewed 话题详情被浏览
+type TopicViewed struct {
+	TopicID int64 `json:"topic_id,string"`
+	ViewID  int64 `json:"view_id,string"` // 本次浏览的唯一ID（订阅者据此去重，重复投递不会重复计数）
+}
+
+// TopicPinned 话题置顶状态已变更
+type TopicPinned struct {
+	TopicID int64  `json:"topic_id,string"`
+	Pinned  bool   `json:"pinned"`
+	Scope   string `json:"scope"`
+}
+
+// TopicLocked 话题锁定状态已变更
+type TopicLocked struct {
+	TopicID int64 `json:"topic_id,string"`
+	Locked  bool  `json:"locked"`
+}
+
+// CommentCreated 评论已创建
+type CommentCreated struct {
+	CommentID int64  `json:"comment_id,string"`
+	TopicID   int64  `json:"topic_id,string"`
+	UserID    int64  `json:"user_id,string"`
+	ParentID  *int64 `json:"parent_id,string,omitempty"`
+	Content   string `json:"content"`
+}
+
+// CommentDeleted 评论已删除
+type CommentDeleted struct {
+	CommentID int64 `json:"comment_id,string"`
+	TopicID   int64 `json:"topic_id,string"`
+	UserID    int64 `json:"user_id,string"`
+}
+
+// VoteCast 用户投票已变更（OldType/NewType: 1点赞 -1点踩 0未投票）
+type VoteCast struct {
+	TopicID int64 `json:"topic_id,string"`
+	UserID  int64 `json:"user_id,string"`
+	OldType int   `json:"old_type"`
+	NewType int   `json:"new_type"`
+}
+
+func (TopicCreated) Name() string   { return NameTopicCreated }
+func (TopicViewed) Name() string    { return NameTopicViewed }
+func (TopicPinned) Name() string    { return NameTopicPinned }
+func (TopicLocked) Name() string    { return NameTopicLocked }
+func (CommentCreated) Name() string { return NameCommentCreated }
+func (CommentDeleted) Name() string { return NameCommentDeleted }
+func (VoteCast) Name() string       { return NameVoteCast }
+
+func (e TopicCreated) Key() int64   { return e.TopicID }
+func (e TopicViewed) Key() int64    { return e.TopicID }
+func (e TopicPinned) Key() int64    { return e.TopicID }
+func (e TopicLocked) Key() int64    { return e.TopicID }
+func (e CommentCreated) Key() int64 { return e.TopicID }
+func (e CommentDeleted) Key() int64 { return e.TopicID }
+func (e VoteCast) Key() int64       { return e.TopicID }
+
+// decoders 按事件名称把JSON解码为具体的事件类型
+var decoders = map[string]func([]byte) (Event, error){
+	NameTopicCreated:   decodeAs[TopicCreated],
+	NameTopicViewed:    decodeAs[TopicViewed],
+	NameTopicPinned:    decodeAs[TopicPinned],
+	NameTopicLocked:    decodeAs[TopicLocked],
+	NameCommentCreated: decodeAs[CommentCreated],
+	NameCommentDeleted: decodeAs[CommentDeleted],
+	NameVoteCast:       decodeAs[VoteCast],
+}
+
+// decodeAs 把JSON解码为类型T的事件
+func decodeAs[T Event](data []byte) (Event, error) {
+	var event T
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// decode 按事件名称解码事件
+func decode(name string, data []byte) (Event, error) {
+	dec, ok := decoders[name]
+	if !ok {
+		return nil, fmt.Errorf("未知的事件类型: %s", name)
+	}
+	return dec(data)
+}
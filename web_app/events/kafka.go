@@ -0,0 +1,127 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+const (
+	// eventHeader 保存事件名称的消息头
+	eventHeader = "event"
+	// commitTimeout 提交offset的超时时间（关机后仍提交已处理的消息，避免重复处理）
+	commitTimeout = 3 * time.Second
+	// writeBatchTimeout 写入器凑批的最长等待时间（kafka-go默认1秒，Publish在请求路径上同步等待写入完成）
+	writeBatchTimeout = 5 * time.Millisecond
+)
+
+// messageReader Kafka消息读取接口（*kafka.Reader 实现，测试时替换）
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// messageWriter Kafka消息写入接口（*kafka.Writer 实现，测试时替换）
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// kafkaBus 基于Kafka的事件总线：事件按排序键分区，同一话题的事件进入同一分区按顺序处理
+// 处理完成后才提交offset，进程崩溃时未提交的事件会重新投递（订阅者可能重复执行）
+type kafkaBus struct {
+	*dispatcher
+	reader messageReader
+	writer messageWriter
+}
+
+func newKafkaBus(d *dispatcher, brokers []string, topic, groupID string) *kafkaBus {
+	return &kafkaBus{
+		dispatcher: d,
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     brokers,
+			Topic:       topic,
+			GroupID:     groupID,
+			MinBytes:    1,
+			MaxBytes:    10e6,              // 10MB
+			StartOffset: kafka.FirstOffset, // 消费组首次启动时从头消费，不丢失启动前已发布的事件
+		}),
+		writer: newEventWriter(brokers, topic),
+	}
+}
+
+// newEventWriter 创建事件写入器：按排序键分区，凑批时间很短，发布事件不拖慢请求
+func newEventWriter(brokers []string, topic string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: writeBatchTimeout,
+	}
+}
+
+// Publish 把事件写入Kafka（消息key为排序键，消息头为事件名称）
+func (b *kafkaBus) Publish(ctx context.Context, event Event) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return b.writer.WriteMessages(ctx, kafka.Message{
+		Key:     []byte(strconv.FormatInt(event.Key(), 10)),
+		Value:   value,
+		Headers: []kafka.Header{{Key: eventHeader, Value: []byte(event.Name())}},
+	})
+}
+
+// Run 消费事件并分发给订阅者，直到ctx取消
+func (b *kafkaBus) Run(ctx context.Context) error {
+	zap.L().Info("Kafka事件总线已启动")
+	for {
+		msg, err := b.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		event, err := decodeMessage(msg)
+		if err != nil {
+			zap.L().Error("无法解析的事件，跳过",
+				zap.Error(err),
+				zap.Int("partition", msg.Partition),
+				zap.Int64("offset", msg.Offset))
+		} else {
+			b.dispatch(ctx, event)
+		}
+
+		commitCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), commitTimeout)
+		err = b.reader.CommitMessages(commitCtx, msg)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// decodeMessage 按消息头中的事件名称解码
+func decodeMessage(msg kafka.Message) (Event, error) {
+	for _, header := range msg.Headers {
+		if header.Key == eventHeader {
+			return decode(string(header.Value), msg.Value)
+		}
+	}
+	return nil, errors.New("消息缺少事件名称")
+}
+
+// Close 关闭读取器和写入器
+func (b *kafkaBus) Close() error {
+	return errors.Join(b.reader.Close(), b.writer.Close())
+}
@@ -0,0 +1,155 @@
+package events
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	metadataAPI "github.com/segmentio/kafka-go/protocol/metadata"
+	produceAPI "github.com/segmentio/kafka-go/protocol/produce"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReader 按顺序返回预置消息，读完后取消ctx（模拟关机）
+type fakeReader struct {
+	mu        sync.Mutex
+	messages  []kafka.Message
+	committed []kafka.Message
+	onDrained context.CancelFunc
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	r.mu.Lock()
+	if len(r.messages) > 0 {
+		msg := r.messages[0]
+		r.messages = r.messages[1:]
+		r.mu.Unlock()
+		return msg, nil
+	}
+	r.mu.Unlock()
+
+	if r.onDrained != nil {
+		r.onDrained()
+	}
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (r *fakeReader) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.committed = append(r.committed, msgs...)
+	return nil
+}
+
+func (r *fakeReader) Close() error { return nil }
+
+// fakeWriter 记录写入的消息
+type fakeWriter struct {
+	written []kafka.Message
+}
+
+func (w *fakeWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.written = append(w.written, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) Close() error { return nil }
+
+// TestKafkaBus_RoundTrip 测试发布的消息被消费后解码为原类型并提交offset
+func TestKafkaBus_RoundTrip(t *testing.T) {
+	parentID := int64(1747052461824102401)
+	published := []Event{
+		TopicCreated{TopicID: 1747052461824102400, UserID: 3, Content: "@alice", CreatedAt: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)},
+		CommentCreated{CommentID: 5, TopicID: 1747052461824102400, UserID: 4, ParentID: &parentID, Content: "回复"},
+		VoteCast{TopicID: 1747052461824102400, UserID: 3, OldType: 1, NewType: -1},
+	}
+
+	writer := &fakeWriter{}
+	producer := &kafkaBus{dispatcher: newDispatcher(0, time.Millisecond), writer: writer}
+	for _, event := range published {
+		require.NoError(t, producer.Publish(context.Background(), event))
+	}
+	require.Len(t, writer.written, 3)
+	assert.Equal(t, "1747052461824102400", string(writer.written[0].Key))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	reader := &fakeReader{messages: writer.written, onDrained: cancel}
+	consumer := &kafkaBus{dispatcher: newDispatcher(0, time.Millisecond), reader: reader}
+	rec := &recorder{}
+	consumer.Subscribe(NameTopicCreated, "rec", rec.handle)
+	consumer.Subscribe(NameCommentCreated, "rec", rec.handle)
+	consumer.Subscribe(NameVoteCast, "rec", rec.handle)
+
+	require.NoError(t, consumer.Run(ctx))
+	assert.Equal(t, published, rec.snapshot())
+	assert.Len(t, reader.committed, 3)
+}
+
+// TestKafkaBus_SkipInvalid 测试无法解析的消息跳过并提交，不阻塞后续消息
+func TestKafkaBus_SkipInvalid(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	reader := &fakeReader{
+		messages: []kafka.Message{
+			{Value: []byte(`{}`)},
+			{Value: []byte(`{}`), Headers: []kafka.Header{{Key: eventHeader, Value: []byte("topic.unknown")}}},
+			{Value: []byte(`not json`), Headers: []kafka.Header{{Key: eventHeader, Value: []byte(NameTopicViewed)}}},
+			{Value: []byte(`{"topic_id":"42"}`), Headers: []kafka.Header{{Key: eventHeader, Value: []byte(NameTopicViewed)}}},
+		},
+		onDrained: cancel,
+	}
+	b := &kafkaBus{dispatcher: newDispatcher(0, time.Millisecond), reader: reader}
+	rec := &recorder{}
+	b.Subscribe(NameTopicViewed, "rec", rec.handle)
+
+	require.NoError(t, b.Run(ctx))
+	assert.Equal(t, []Event{TopicViewed{TopicID: 42}}, rec.snapshot())
+	assert.Len(t, reader.committed, 4)
+}
+
+// fakeTransport 模拟只有一个分区的Kafka broker，写入立即成功
+type fakeTransport struct{}
+
+func (fakeTransport) RoundTrip(_ context.Context, _ net.Addr, req kafka.Request) (kafka.Response, error) {
+	switch r := req.(type) {
+	case *metadataAPI.Request:
+		res := &metadataAPI.Response{Brokers: []metadataAPI.ResponseBroker{{NodeID: 0, Host: "127.0.0.1", Port: 9092}}}
+		for _, name := range r.TopicNames {
+			res.Topics = append(res.Topics, metadataAPI.ResponseTopic{
+				Name:       name,
+				Partitions: []metadataAPI.ResponsePartition{{PartitionIndex: 0, LeaderID: 0}},
+			})
+		}
+		return res, nil
+	case *produceAPI.Request:
+		res := &produceAPI.Response{}
+		for _, topic := range r.Topics {
+			res.Topics = append(res.Topics, produceAPI.ResponseTopic{
+				Topic:      topic.Topic,
+				Partitions: []produceAPI.ResponsePartition{{Partition: 0}},
+			})
+		}
+		return res, nil
+	}
+	return nil, kafka.UnsupportedVersion
+}
+
+// TestKafkaBus_PublishLatency 测试按事件写入器的配置发布单个事件不会等待默认1秒的凑批时间
+func TestKafkaBus_PublishLatency(t *testing.T) {
+	writer := newEventWriter([]string{"127.0.0.1:9092"}, "events")
+	writer.Transport = fakeTransport{}
+	b := &kafkaBus{writer: writer}
+	defer writer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	start := time.Now()
+	require.NoError(t, b.Publish(ctx, TopicViewed{TopicID: 42, ViewID: 1}))
+	assert.Less(t, time.Since(start), 200*time.Millisecond)
+}
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultLocalWorkers 进程内总线默认的worker数
+	defaultLocalWorkers = 8
+	// defaultLocalBufferSize 每个worker默认的队列长度
+	defaultLocalBufferSize = 1024
+)
+
+// localBus 进程内事件总线：按排序键把事件分配到固定的worker队列，同一话题的事件由同一个worker按顺序处理
+// 关机时处理完队列中已有的事件再退出；进程崩溃时队列中的事件会丢失
+type localBus struct {
+	*dispatcher
+	queues []chan Event
+}
+
+func newLocalBus(d *dispatcher, workers, bufferSize int) *localBus {
+	if workers <= 0 {
+		workers = defaultLocalWorkers
+	}
+	if bufferSize <= 0 {
+		bufferSize = defaultLocalBufferSize
+	}
+	b := &localBus{dispatcher: d, queues: make([]chan Event, workers)}
+	for i := range b.queues {
+		b.queues[i] = make(chan Event, bufferSize)
+	}
+	return b
+}
+
+// Publish 把事件放入对应worker的队列，队列已满时等待直到ctx取消
+func (b *localBus) Publish(ctx context.Context, event Event) error {
+	select {
+	case b.queue(event.Key()) <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// queue 返回排序键对应的队列
+func (b *localBus) queue(key int64) chan Event {
+	return b.queues[uint64(key)%uint64(len(b.queues))]
+}
+
+// Run 每个队列启动一个worker，ctx取消后处理完队列中的事件再返回
+func (b *localBus) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, queue := range b.queues {
+		wg.Add(1)
+		go func(queue chan Event) {
+			defer wg.Done()
+			b.work(ctx, queue)
+		}(queue)
+	}
+	zap.L().Info("进程内事件总线已启动", zap.Int("workers", len(b.queues)))
+	wg.Wait()
+	return nil
+}
+
+// work 按顺序处理一个队列中的事件
+func (b *localBus) work(ctx context.Context, queue chan Event) {
+	for {
+		select {
+		case event := <-queue:
+			b.dispatch(ctx, event)
+		case <-ctx.Done():
+			b.drain(ctx, queue)
+			return
+		}
+	}
+}
+
+// drain 处理队列中剩余的事件（不再等待重试）
+func (b *localBus) drain(ctx context.Context, queue chan Event) {
+	for {
+		select {
+		case event := <-queue:
+			b.dispatch(ctx, event)
+		default:
+			return
+		}
+	}
+}
+
+// Close 进程内总线没有需要释放的资源
+func (b *localBus) Close() error {
+	return nil
+}
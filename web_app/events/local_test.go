@@ -0,0 +1,160 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder 并发安全地记录订阅者收到的事件
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recorder) handle(_ context.Context, event Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recorder) snapshot() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+// runBus 在后台运行事件总线，返回停止函数（取消ctx并等待Run返回）
+func runBus(t *testing.T, b Bus) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, b.Run(ctx))
+	}()
+	stop = func() {
+		cancel()
+		<-done
+	}
+	t.Cleanup(stop)
+	return stop
+}
+
+// TestLocalBus_OrderPerKey 测试同一话题的事件按发布顺序处理
+func TestLocalBus_OrderPerKey(t *testing.T) {
+	b := newLocalBus(newDispatcher(0, time.Millisecond), 4, 100)
+	rec := &recorder{}
+	b.Subscribe(NameTopicViewed, "rec", rec.handle)
+	b.Subscribe(NameVoteCast, "rec", rec.handle)
+
+	// 运行前发布，关机时应排空队列
+	for i := 0; i < 20; i++ {
+		require.NoError(t, b.Publish(context.Background(), VoteCast{TopicID: 7, NewType: i}))
+		require.NoError(t, b.Publish(context.Background(), TopicViewed{TopicID: int64(100 + i)}))
+	}
+	stop := runBus(t, b)
+	stop()
+
+	got := rec.snapshot()
+	require.Len(t, got, 40)
+	var votes []int
+	for _, event := range got {
+		if vote, ok := event.(VoteCast); ok {
+			votes = append(votes, vote.NewType)
+		}
+	}
+	want := make([]int, 20)
+	for i := range want {
+		want[i] = i
+	}
+	assert.Equal(t, want, votes)
+}
+
+// TestLocalBus_RetryPerSubscriber 测试失败的订阅者单独重试，不影响其他订阅者
+func TestLocalBus_RetryPerSubscriber(t *testing.T) {
+	b := newLocalBus(newDispatcher(3, time.Millisecond), 1, 10)
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	count := func(name string, failTimes int) Handler {
+		return func(context.Context, Event) error {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts[name]++
+			if attempts[name] <= failTimes {
+				return errors.New("暂时不可用")
+			}
+			return nil
+		}
+	}
+	b.Subscribe(NameCommentCreated, "flaky", count("flaky", 2))
+	b.Subscribe(NameCommentCreated, "stable", count("stable", 0))
+	b.Subscribe(NameCommentCreated, "broken", count("broken", 100))
+	b.Subscribe(NameCommentCreated, "panics", func(context.Context, Event) error { panic("bug") })
+
+	done := make(chan struct{})
+	b.Subscribe(NameCommentCreated, "last", func(context.Context, Event) error {
+		close(done)
+		return nil
+	})
+	runBus(t, b)
+	require.NoError(t, b.Publish(context.Background(), CommentCreated{CommentID: 1, TopicID: 2}))
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("事件未分发到最后一个订阅者")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, map[string]int{"flaky": 3, "stable": 1, "broken": 4}, attempts)
+}
+
+// TestLocalBus_PublishFull 测试队列已满时发布等待直到ctx取消
+func TestLocalBus_PublishFull(t *testing.T) {
+	b := newLocalBus(newDispatcher(0, time.Millisecond), 1, 1)
+	require.NoError(t, b.Publish(context.Background(), TopicViewed{TopicID: 1}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := b.Publish(ctx, TopicViewed{TopicID: 1})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// TestOn 测试按类型注册的订阅者收到具体类型的事件
+func TestOn(t *testing.T) {
+	saved := bus
+	defer func() { bus = saved }()
+	local := newLocalBus(newDispatcher(0, time.Millisecond), 2, 10)
+	bus = local
+
+	got := make(chan TopicCreated, 1)
+	On("typed", func(_ context.Context, e TopicCreated) error {
+		got <- e
+		return nil
+	})
+	runBus(t, local)
+	require.NoError(t, Publish(TopicCreated{TopicID: 9, UserID: 3, Content: "@alice 你好"}))
+
+	select {
+	case e := <-got:
+		assert.Equal(t, int64(9), e.TopicID)
+		assert.Equal(t, "@alice 你好", e.Content)
+	case <-time.After(5 * time.Second):
+		t.Fatal("订阅者未收到事件")
+	}
+}
+
+// TestPublish_NotInitialized 测试未初始化时发布返回错误
+func TestPublish_NotInitialized(t *testing.T) {
+	saved := bus
+	defer func() { bus = saved }()
+	bus = nil
+
+	assert.ErrorIs(t, Publish(TopicViewed{TopicID: 1}), errNotInitialized)
+}
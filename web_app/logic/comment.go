@@ -7,6 +7,7 @@ import (
 	"time"
 	"web_app/dao/mysql"
 	"web_app/dao/redis"
+	"web_app/events"
 	"web_app/models"
 	"web_app/utils"
 
@@ -22,11 +23,16 @@ func CreateComment(userID, topicID int64, req *models.CreateCommentRequest) erro
 	// 使用 WithLock 自动管理锁的获取和释放（2秒超时，防止用户短时间内重复提交）
 	return utils.WithLock(ctx, redis.GetClient(), lockKey, 2*time.Second, func() error {
 		// 1. 验证话题是否存在
-		_, err := mysql.GetTopicByID(topicID)
+		topic, err := mysql.GetTopicByID(topicID)
 		if err != nil {
 			return errors.New("话题不存在")
 		}
 
+		// 锁定的话题禁止新评论
+		if topic.IsLocked {
+			return ErrTopicLocked
+		}
+
 		// 2. 如果有父评论ID，验证父评论是否存在且属于同一话题
 		if req.ParentID != nil {
 			parentComment, err := mysql.GetCommentByID(*req.ParentID)
@@ -38,31 +44,40 @@ func CreateComment(userID, topicID int64, req *models.CreateCommentRequest) erro
 			}
 		}
 
-		// 3. 生成雪花算法ID
+		// 3. 校验引用的附件（只能引用自己上传的附件）
+		attachmentIDs, err := parseAttachmentIDs(userID, req.AttachmentIDs)
+		if err != nil {
+			return err
+		}
+
+		// 4. 生成雪花算法ID
 		commentID := utils.GenerateID()
 
-		// 4. 插入评论（显式设置创建时间为当前时间）
+		// 5. 插入评论及附件关联（显式设置创建时间为当前时间）
 		now := time.Now()
 		err = mysql.InsertComment(&models.Comment{
-			ID:        commentID,
-			TopicID:   topicID,
-			UserID:    userID,
-			Content:   req.Content,
-			ParentID:  req.ParentID,
-			CreatedAt: now,
-			UpdatedAt: now,
-		})
+			ID:          commentID,
+			TopicID:     topicID,
+			UserID:      userID,
+			Content:     req.Content,
+			ContentHTML: utils.RenderMarkdown(req.Content),
+			ParentID:    req.ParentID,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}, attachmentIDs)
 		if err != nil {
 			zap.L().Error("插入评论失败", zap.Error(err))
 			return errors.New("插入评论失败")
 		}
 
-		// 5. 更新话题评论数（异步处理，不影响主流程）
-		go func() {
-			if err := mysql.UpdateTopicCommentCount(topicID, 1); err != nil {
-				zap.L().Warn("更新话题评论数失败", zap.Error(err))
-			}
-		}()
+		// 6. 更新话题评论数、记录@提及由事件订阅者处理，不影响主流程
+		publishEvent(events.CommentCreated{
+			CommentID: commentID,
+			TopicID:   topicID,
+			UserID:    userID,
+			ParentID:  req.ParentID,
+			Content:   req.Content,
+		})
 
 		return nil
 	})
@@ -83,7 +98,32 @@ func GetCommentsByTopicID(topicID int64, req *models.GetCommentsRequest) ([]*mod
 		return nil, 0, errors.New("查询评论失败")
 	}
 
-	return commentList, total, nil
+	// 3. 按格式返回副本并填充@提及和附件
+	result := formatComments(commentList, req.Format)
+	attachCommentMentions(commentList, result)
+	attachCommentAttachments(result)
+	return result, total, nil
+}
+
+// attachCommentAttachments 批量填充评论的附件（查询失败时只记录日志，不影响评论列表）
+func attachCommentAttachments(comments []*models.Comment) {
+	if len(comments) == 0 {
+		return
+	}
+	ids := make([]int64, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+	attachments, err := mysql.GetCommentAttachments(ids)
+	if err != nil {
+		zap.L().Warn("查询评论附件失败", zap.Error(err))
+		return
+	}
+	for _, comment := range comments {
+		for _, attachment := range attachments[comment.ID] {
+			comment.Attachments = append(comment.Attachments, withAttachmentURLs(attachment))
+		}
+	}
 }
 
 // DeleteComment 删除评论（仅允许作者删除）
@@ -105,12 +145,8 @@ func DeleteComment(userID, commentID int64) error {
 		return errors.New("删除评论失败")
 	}
 
-	// 4. 更新话题评论数（异步处理，不影响主流程）
-	go func() {
-		if err := mysql.UpdateTopicCommentCount(comment.TopicID, -1); err != nil {
-			zap.L().Warn("更新话题评论数失败", zap.Error(err))
-		}
-	}()
+	// 4. 更新话题评论数由事件订阅者处理，不影响主流程
+	publishEvent(events.CommentDeleted{CommentID: commentID, TopicID: comment.TopicID, UserID: userID})
 
 	return nil
 }
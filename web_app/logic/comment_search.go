@@ -0,0 +1,97 @@
+package logic
+
+import (
+	"fmt"
+	"strconv"
+	"web_app/dao/elasticsearch"
+	"web_app/dao/mysql"
+	"web_app/models"
+
+	"go.uber.org/zap"
+)
+
+// commentTopicsLimit 综合模式下最多取多少个评论命中的话题参与话题搜索
+const commentTopicsLimit = 100
+
+// SearchComments 搜索评论
+func SearchComments(params *models.SearchCommentsRequest) (*models.CommentSearchResponse, error) {
+	page, pageSize := params.Page, params.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	if params.TopicID != "" {
+		if _, err := strconv.ParseInt(params.TopicID, 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: 无效的话题ID", ErrInvalidSearchParams)
+		}
+	}
+
+	esResp, err := elasticsearch.SearchComments(&elasticsearch.CommentSearchRequest{
+		Keyword:  params.Keyword,
+		TopicID:  params.TopicID,
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// ES中只有内容，作者和话题标题从MySQL补齐（同时过滤掉已删除但索引尚未同步的评论）
+	ids := make([]int64, 0, len(esResp.Hits))
+	snippets := make(map[int64]string, len(esResp.Hits))
+	for _, hit := range esResp.Hits {
+		id, err := strconv.ParseInt(hit.Comment.CommentID, 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+		snippets[id] = hit.Snippet
+	}
+	rows, err := mysql.GetCommentSearchResults(ids)
+	if err != nil {
+		zap.L().Error("查询评论信息失败", zap.Error(err))
+		return nil, err
+	}
+	byID := make(map[int64]*models.CommentSearchResult, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = row
+	}
+
+	// 保持ES的相关度顺序
+	comments := make([]*models.CommentSearchResult, 0, len(ids))
+	for _, id := range ids {
+		if row, ok := byID[id]; ok {
+			row.Snippet = snippets[id]
+			comments = append(comments, row)
+		}
+	}
+
+	totalPages := int((esResp.Total + int64(pageSize) - 1) / int64(pageSize))
+	return &models.CommentSearchResponse{
+		Total:      esResp.Total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+		HasMore:    page < totalPages,
+		Comments:   comments,
+		Took:       esResp.Took,
+	}, nil
+}
+
+// matchCommentTopics 查询评论命中的话题及其最相关的评论（按话题ID索引）
+// 评论搜索失败时只记录日志，话题搜索照常进行
+func matchCommentTopics(keyword string) map[string]*elasticsearch.CommentHit {
+	hits, err := elasticsearch.MatchCommentTopics(keyword, commentTopicsLimit)
+	if err != nil {
+		zap.L().Warn("按评论搜索话题失败，只搜索话题", zap.Error(err), zap.String("keyword", keyword))
+		return nil
+	}
+
+	matches := make(map[string]*elasticsearch.CommentHit, len(hits))
+	for _, hit := range hits {
+		matches[hit.Comment.TopicID] = hit
+	}
+	return matches
+}
@@ -0,0 +1,56 @@
+package logic
+
+import (
+	"web_app/models"
+	"web_app/utils"
+)
+
+// formatTopic 按请求的内容格式返回话题副本
+// 返回副本而不是原地修改，避免影响正在异步写入缓存的对象
+func formatTopic(topic *models.Topic, format string) *models.Topic {
+	if topic == nil {
+		return nil
+	}
+	result := *topic
+
+	// 旧数据没有渲染缓存，按需渲染
+	if result.ContentHTML == "" && format != utils.ContentFormatRaw {
+		result.ContentHTML = utils.RenderMarkdown(result.Content)
+	}
+
+	switch format {
+	case utils.ContentFormatRaw:
+		result.ContentHTML = ""
+	case utils.ContentFormatHTML:
+		result.Content = ""
+	}
+	return &result
+}
+
+// formatTopics 按请求的内容格式返回话题列表副本
+func formatTopics(topics []*models.Topic, format string) []*models.Topic {
+	result := make([]*models.Topic, 0, len(topics))
+	for _, topic := range topics {
+		result = append(result, formatTopic(topic, format))
+	}
+	return result
+}
+
+// formatComments 按请求的内容格式返回评论列表副本
+func formatComments(comments []*models.Comment, format string) []*models.Comment {
+	result := make([]*models.Comment, 0, len(comments))
+	for _, comment := range comments {
+		c := *comment
+		if c.ContentHTML == "" && format != utils.ContentFormatRaw {
+			c.ContentHTML = utils.RenderMarkdown(c.Content)
+		}
+		switch format {
+		case utils.ContentFormatRaw:
+			c.ContentHTML = ""
+		case utils.ContentFormatHTML:
+			c.Content = ""
+		}
+		result = append(result, &c)
+	}
+	return result
+}
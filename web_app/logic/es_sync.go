@@ -0,0 +1,306 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"time"
+	"web_app/dao/elasticsearch"
+	"web_app/dao/mysql"
+	"web_app/dao/redis"
+	"web_app/models"
+	"web_app/utils"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	// syncLockKey 全量同步任务锁（同一时间只允许一个同步任务）
+	syncLockKey = "lock:es:sync"
+	// syncLockTTL 同步锁过期时间，每批处理后续期；进程退出后锁过期即可续传
+	syncLockTTL = 5 * time.Minute
+	// maxSyncStatusErrors 进度中保留的最近错误条数
+	maxSyncStatusErrors = 20
+)
+
+// ErrSyncRunning 已有同步任务在执行
+var ErrSyncRunning = errors.New("已有同步任务在执行")
+
+// syncBatchSize 每批从MySQL读取的话题/评论数（也是保存断点的粒度）
+func syncBatchSize() int {
+	if size := viper.GetInt("elasticsearch.sync.batch_size"); size > 0 {
+		return size
+	}
+	return 1000
+}
+
+// StartESSync 启动MySQL到ES的全量同步（先话题后评论），任务在后台执行
+// 上次同步未完成（失败或进程中断）时从断点续传，restart为true时从头开始
+func StartESSync(restart bool) (*models.ESSyncStatus, error) {
+	ctx := context.Background()
+
+	lock := utils.NewDistributedLock(redis.GetClient(), syncLockKey, syncLockTTL)
+	if err := lock.Lock(ctx); err != nil {
+		if errors.Is(err, utils.ErrLockFailed) {
+			return nil, ErrSyncRunning
+		}
+		zap.L().Error("获取同步锁失败", zap.Error(err))
+		return nil, errors.New("获取同步锁失败")
+	}
+
+	status, err := newSyncStatus(restart)
+	if err != nil {
+		_ = lock.Unlock(ctx)
+		zap.L().Error("初始化同步进度失败", zap.Error(err))
+		return nil, errors.New("初始化同步进度失败")
+	}
+	if err := redis.SaveSyncStatus(status); err != nil {
+		_ = lock.Unlock(ctx)
+		zap.L().Error("保存同步进度失败", zap.Error(err))
+		return nil, errors.New("保存同步进度失败")
+	}
+
+	snapshot := *status
+	go func() {
+		defer func() {
+			if err := lock.Unlock(context.Background()); err != nil {
+				zap.L().Warn("释放同步锁失败", zap.Error(err))
+			}
+		}()
+		defer func() {
+			if r := recover(); r != nil {
+				zap.L().Error("同步任务panic", zap.Any("error", r))
+			}
+		}()
+
+		runESSync(lock, status)
+	}()
+
+	return &snapshot, nil
+}
+
+// newSyncStatus 创建本次同步的初始进度（续传时沿用上次的断点和计数）
+func newSyncStatus(restart bool) (*models.ESSyncStatus, error) {
+	status := &models.ESSyncStatus{}
+	if !restart {
+		previous, err := redis.GetSyncStatus()
+		if err != nil {
+			return nil, err
+		}
+		if previous != nil && previous.State != models.ESSyncStateCompleted {
+			status = previous
+			zap.L().Info("从断点续传同步",
+				zap.String("phase", status.Phase),
+				zap.Int64("last_id", status.LastID),
+				zap.Int64("processed", status.Processed))
+		}
+	}
+
+	if status.Phase == "" {
+		status.Phase = models.ESSyncPhaseTopics
+	}
+	remaining, err := countSyncRemaining(status)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	status.State = models.ESSyncStateRunning
+	status.Total = status.Processed + remaining
+	status.StartedAt = now
+	status.UpdatedAt = now
+	status.FinishedAt = nil
+	status.ETASeconds = 0
+	return status, nil
+}
+
+// countSyncRemaining 统计断点之后还需要同步的文档数
+func countSyncRemaining(status *models.ESSyncStatus) (int64, error) {
+	commentAfter := status.LastID
+	var topics int64
+	if status.Phase == models.ESSyncPhaseTopics {
+		var err error
+		if topics, err = mysql.CountTopicsAfterID(status.LastID); err != nil {
+			return 0, err
+		}
+		commentAfter = 0 // 话题阶段尚未完成，评论从头同步
+	}
+
+	comments, err := mysql.CountCommentsAfterID(commentAfter)
+	if err != nil {
+		return 0, err
+	}
+	return topics + comments, nil
+}
+
+// runESSync 按ID游标分批读取话题和评论，通过批量处理器写入ES，每批提交后保存断点
+func runESSync(lock *utils.DistributedLock, status *models.ESSyncStatus) {
+	ctx := context.Background()
+	batchSize := syncBatchSize()
+	startProcessed := status.Processed
+	zap.L().Info("开始同步数据到Elasticsearch",
+		zap.Int64("total", status.Total),
+		zap.String("phase", status.Phase),
+		zap.Int64("last_id", status.LastID),
+		zap.Int("batch_size", batchSize))
+
+	syncer, err := elasticsearch.NewBulkSyncer(ctx, elasticsearch.LoadBulkSyncConfig())
+	if err != nil {
+		failSync(status, err)
+		return
+	}
+	defer func() {
+		if err := syncer.Close(); err != nil {
+			zap.L().Warn("关闭批量处理器失败", zap.Error(err))
+		}
+	}()
+
+	// 1. 同步话题
+	if status.Phase == models.ESSyncPhaseTopics {
+		err := syncBatches(ctx, lock, syncer, status, startProcessed, func(lastID int64) (int, int64, error) {
+			readAt := time.Now()
+			topics, err := mysql.GetTopicsAfterID(lastID, batchSize)
+			if err != nil || len(topics) == 0 {
+				return 0, lastID, err
+			}
+			syncer.AddTopics(topics, readAt.UnixMilli())
+			return len(topics), topics[len(topics)-1].ID, nil
+		})
+		if err != nil {
+			failSync(status, err)
+			return
+		}
+		status.Phase = models.ESSyncPhaseComments
+		status.LastID = 0
+	}
+
+	// 2. 同步评论
+	err = syncBatches(ctx, lock, syncer, status, startProcessed, func(lastID int64) (int, int64, error) {
+		readAt := time.Now()
+		comments, err := mysql.GetCommentsAfterID(lastID, batchSize)
+		if err != nil || len(comments) == 0 {
+			return 0, lastID, err
+		}
+		syncer.AddComments(comments, readAt.UnixMilli())
+		return len(comments), comments[len(comments)-1].ID, nil
+	})
+	if err != nil {
+		failSync(status, err)
+		return
+	}
+
+	now := time.Now()
+	status.State = models.ESSyncStateCompleted
+	status.Total = status.Processed
+	status.UpdatedAt = now
+	status.FinishedAt = &now
+	status.ETASeconds = 0
+	if err := redis.SaveSyncStatus(status); err != nil {
+		zap.L().Warn("保存同步进度失败", zap.Error(err))
+	}
+
+	zap.L().Info("同步完成",
+		zap.Int64("processed", status.Processed),
+		zap.Int64("indexed", status.Indexed),
+		zap.Int64("failed", status.Failed),
+		zap.Duration("took", now.Sub(status.StartedAt)))
+}
+
+// syncBatches 循环读取一批数据加入批量处理器并提交，直到没有数据
+// add 从断点之后读取一批数据并加入处理器，返回条数和该批最大ID
+func syncBatches(ctx context.Context, lock *utils.DistributedLock, syncer *elasticsearch.BulkSyncer,
+	status *models.ESSyncStatus, startProcessed int64, add func(lastID int64) (int, int64, error)) error {
+	for {
+		count, lastID, err := add(status.LastID)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+
+		result, err := syncer.Flush()
+		if err != nil {
+			return err
+		}
+
+		// 批次已全部提交（重试后仍失败的计入失败数），推进断点
+		status.Processed += int64(count)
+		status.Indexed += result.Indexed
+		status.Failed += result.Failed
+		status.Errors = appendSyncErrors(status.Errors, result.Errors...)
+		status.LastID = lastID
+		status.UpdatedAt = time.Now()
+		if status.Processed > status.Total {
+			status.Total = status.Processed // 同步期间有新增数据
+		}
+		status.ETASeconds = estimateSyncETA(status, startProcessed)
+		if err := redis.SaveSyncStatus(status); err != nil {
+			zap.L().Warn("保存同步断点失败", zap.Error(err), zap.String("phase", status.Phase), zap.Int64("last_id", lastID))
+		}
+
+		// 续期失败说明锁已过期并可能被其他实例获取，停止以免重复同步
+		if err := lock.Extend(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// failSync 将同步标记为失败并保存（断点保留，再次触发时续传）
+func failSync(status *models.ESSyncStatus, err error) {
+	zap.L().Error("同步失败", zap.Error(err), zap.String("phase", status.Phase), zap.Int64("last_id", status.LastID))
+
+	now := time.Now()
+	status.State = models.ESSyncStateFailed
+	status.Errors = appendSyncErrors(status.Errors, err.Error())
+	status.UpdatedAt = now
+	status.FinishedAt = &now
+	status.ETASeconds = 0
+	if err := redis.SaveSyncStatus(status); err != nil {
+		zap.L().Warn("保存同步进度失败", zap.Error(err))
+	}
+}
+
+// appendSyncErrors 追加错误信息，只保留最近的 maxSyncStatusErrors 条
+func appendSyncErrors(errs []string, more ...string) []string {
+	errs = append(errs, more...)
+	if len(errs) > maxSyncStatusErrors {
+		errs = errs[len(errs)-maxSyncStatusErrors:]
+	}
+	return errs
+}
+
+// estimateSyncETA 按本次运行的平均速度估算剩余秒数
+func estimateSyncETA(status *models.ESSyncStatus, startProcessed int64) int64 {
+	done := status.Processed - startProcessed
+	elapsed := time.Since(status.StartedAt)
+	if done <= 0 || elapsed <= 0 || status.Total <= status.Processed {
+		return 0
+	}
+	perItem := elapsed / time.Duration(done)
+	return int64((perItem * time.Duration(status.Total-status.Processed)).Seconds())
+}
+
+// GetESSyncStatus 获取全量同步进度，从未同步过时返回 nil
+// 进度为同步中但锁已过期，说明执行任务的进程已退出，标记为中断
+func GetESSyncStatus() (*models.ESSyncStatus, error) {
+	status, err := redis.GetSyncStatus()
+	if err != nil {
+		zap.L().Error("获取同步进度失败", zap.Error(err))
+		return nil, errors.New("获取同步进度失败")
+	}
+	if status == nil || status.State != models.ESSyncStateRunning {
+		return status, nil
+	}
+
+	exists, err := redis.GetClient().Exists(context.Background(), syncLockKey).Result()
+	if err != nil {
+		zap.L().Warn("检查同步锁失败", zap.Error(err))
+		return status, nil
+	}
+	if exists == 0 {
+		status.State = models.ESSyncStateInterrupted
+		status.ETASeconds = 0
+	}
+	return status, nil
+}
@@ -0,0 +1,59 @@
+package logic
+
+import (
+	"context"
+	"web_app/dao/mysql"
+	"web_app/dao/redis"
+	"web_app/events"
+
+	"go.uber.org/zap"
+)
+
+// RegisterEventHandlers 注册领域事件的订阅者（缓存清理、计数器、@提及），须在事件总线启动前调用
+// 订阅者失败时由事件总线退避重试，同一话题的事件按发布顺序处理
+func RegisterEventHandlers() {
+	// 话题创建：清除列表缓存、记录@提及
+	events.On("topic-list-cache", func(_ context.Context, _ events.TopicCreated) error {
+		return redis.DeleteAllTopicListCache()
+	})
+	events.On("mentions", func(_ context.Context, e events.TopicCreated) error {
+		return recordMentions(e.UserID, e.TopicID, nil, e.Content)
+	})
+
+	// 浏览话题：按浏览ID去重后计入Redis中的浏览数增量，由定时任务按批写入MySQL
+	events.On("view-count", func(_ context.Context, e events.TopicViewed) error {
+		return redis.RecordTopicView(e.ViewID, e.TopicID)
+	})
+
+	// 置顶/锁定/投票：清除详情和列表缓存（置顶影响列表排序，投票影响点赞数）
+	events.On("topic-cache", func(_ context.Context, e events.TopicPinned) error {
+		return invalidateTopicCache(e.TopicID)
+	})
+	events.On("topic-cache", func(_ context.Context, e events.TopicLocked) error {
+		return invalidateTopicCache(e.TopicID)
+	})
+	events.On("topic-cache", func(_ context.Context, e events.VoteCast) error {
+		return invalidateTopicCache(e.TopicID)
+	})
+
+	// 评论创建/删除：重新统计话题评论数（幂等，重复投递或乱序都不会计错）、记录@提及
+	events.On("comment-count", func(_ context.Context, e events.CommentCreated) error {
+		return mysql.RefreshTopicCommentCount(e.TopicID)
+	})
+	events.On("mentions", func(_ context.Context, e events.CommentCreated) error {
+		return recordMentions(e.UserID, e.TopicID, &e.CommentID, e.Content)
+	})
+	events.On("comment-count", func(_ context.Context, e events.CommentDeleted) error {
+		return mysql.RefreshTopicCommentCount(e.TopicID)
+	})
+}
+
+// publishEvent 发布领域事件，失败时只记录日志（主流程已成功写入，不返回错误）
+func publishEvent(event events.Event) {
+	if err := events.Publish(event); err != nil {
+		zap.L().Error("发布事件失败，相关副作用不会执行",
+			zap.Error(err),
+			zap.String("event", event.Name()),
+			zap.Int64("key", event.Key()))
+	}
+}
@@ -0,0 +1,154 @@
+package logic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"web_app/dao/mysql"
+	"web_app/models"
+	"web_app/utils"
+
+	"go.uber.org/zap"
+)
+
+// mentionExcerptLen 提及收件箱中内容摘要的最大字符数
+const mentionExcerptLen = 100
+
+// resolveMentionUsers 一次批量查询解析被@的用户名，返回 小写用户名 -> 用户
+// 用户名比较与MySQL默认排序规则一致，不区分大小写
+func resolveMentionUsers(spans []models.MentionSpan) (map[string]*models.User, error) {
+	names := utils.MentionedUsernames(spans)
+	if len(names) == 0 {
+		return nil, nil
+	}
+	users, err := mysql.GetUsersByUsernames(names)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*models.User, len(users))
+	for _, user := range users {
+		result[strings.ToLower(user.Username)] = user
+	}
+	return result, nil
+}
+
+// filterMentionSpans 只保留能解析到用户的@片段并填充用户ID
+func filterMentionSpans(spans []models.MentionSpan, users map[string]*models.User) []models.MentionSpan {
+	var result []models.MentionSpan
+	for _, span := range spans {
+		if user, ok := users[strings.ToLower(span.Username)]; ok {
+			span.UserID = user.ID
+			result = append(result, span)
+		}
+	}
+	return result
+}
+
+// parseContentMentions 解析内容中的@并解析为用户
+func parseContentMentions(content string) ([]models.MentionSpan, error) {
+	spans := utils.ParseMentions(content)
+	if len(spans) == 0 {
+		return nil, nil
+	}
+	users, err := resolveMentionUsers(spans)
+	if err != nil {
+		return nil, err
+	}
+	return filterMentionSpans(spans, users), nil
+}
+
+// mentionID 由被@的用户、话题和评论确定提及ID
+// 事件重复投递或重试时生成相同的ID，写入时主键冲突被忽略，收件箱不会出现重复记录
+func mentionID(userID, topicID int64, commentID *int64) int64 {
+	var buf [24]byte
+	binary.BigEndian.PutUint64(buf[0:], uint64(userID))
+	binary.BigEndian.PutUint64(buf[8:], uint64(topicID))
+	if commentID != nil {
+		binary.BigEndian.PutUint64(buf[16:], uint64(*commentID))
+	}
+	h := fnv.New64a()
+	h.Write(buf[:])
+	return int64(h.Sum64() >> 1) // 保证为正数
+}
+
+// recordMentions 为被@的用户写入提及记录（自己@自己不记录）
+// 由事件订阅者调用，写入失败时返回错误由事件总线重试，不影响话题/评论的发布；重复执行不会重复写入
+func recordMentions(fromUserID, topicID int64, commentID *int64, content string) error {
+	spans, err := parseContentMentions(content)
+	if err != nil {
+		// 内容无法解析，重试也不会成功
+		zap.L().Warn("解析@提及失败", zap.Error(err), zap.Int64("topic_id", topicID))
+		return nil
+	}
+
+	now := time.Now()
+	seen := make(map[int64]bool, len(spans))
+	mentions := make([]*models.Mention, 0, len(spans))
+	for _, span := range spans {
+		if span.UserID == fromUserID || seen[span.UserID] {
+			continue
+		}
+		seen[span.UserID] = true
+		mentions = append(mentions, &models.Mention{
+			ID:         mentionID(span.UserID, topicID, commentID),
+			UserID:     span.UserID,
+			FromUserID: fromUserID,
+			TopicID:    topicID,
+			CommentID:  commentID,
+			CreatedAt:  now,
+		})
+	}
+
+	if err := mysql.InsertMentions(mentions); err != nil {
+		return fmt.Errorf("写入提及记录失败: %w", err)
+	}
+	return nil
+}
+
+// attachCommentMentions 为评论列表填充@片段（所有评论的用户名合并为一次查询）
+// source 为原始评论（包含Markdown原文），target 为返回给前端的副本，两者顺序一致
+func attachCommentMentions(source, target []*models.Comment) {
+	spansList := make([][]models.MentionSpan, len(source))
+	var all []models.MentionSpan
+	for i, comment := range source {
+		spansList[i] = utils.ParseMentions(comment.Content)
+		all = append(all, spansList[i]...)
+	}
+	if len(all) == 0 {
+		return
+	}
+
+	users, err := resolveMentionUsers(all)
+	if err != nil {
+		zap.L().Warn("解析评论@提及失败", zap.Error(err))
+		return
+	}
+	for i, spans := range spansList {
+		target[i].Mentions = filterMentionSpans(spans, users)
+	}
+}
+
+// GetUserMentions 获取当前用户收到的@提及
+func GetUserMentions(userID int64, req *models.GetMentionsRequest) ([]*models.Mention, int64, error) {
+	mentions, total, err := mysql.GetMentionsByUserID(userID, req.Page, req.PageSize)
+	if err != nil {
+		zap.L().Error("查询提及列表失败", zap.Error(err), zap.Int64("user_id", userID))
+		return nil, 0, errors.New("查询提及列表失败")
+	}
+	for _, mention := range mentions {
+		mention.Excerpt = truncateRunes(mention.Excerpt, mentionExcerptLen)
+	}
+	return mentions, total, nil
+}
+
+// truncateRunes 按字符截断字符串，超出部分以省略号结尾
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "…"
+}
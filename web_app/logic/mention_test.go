@@ -0,0 +1,27 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMentionID 测试同一处@总是得到相同的ID，不同位置的@得到不同的ID
+func TestMentionID(t *testing.T) {
+	commentID, otherComment := int64(100), int64(101)
+
+	id := mentionID(1, 10, &commentID)
+	assert.Positive(t, id)
+	assert.Equal(t, id, mentionID(1, 10, &commentID), "重复投递时ID相同")
+
+	others := []int64{
+		mentionID(1, 10, nil),
+		mentionID(1, 10, &otherComment),
+		mentionID(2, 10, &commentID),
+		mentionID(1, 11, &commentID),
+	}
+	for _, other := range others {
+		assert.NotEqual(t, id, other)
+	}
+	assert.Equal(t, mentionID(1, 10, nil), mentionID(1, 10, nil), "话题正文中的@")
+}
@@ -0,0 +1,213 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"web_app/dao/mysql"
+	"web_app/dao/redis"
+	"web_app/models"
+	"web_app/utils"
+
+	"go.uber.org/zap"
+)
+
+// 投票相关的错误（控制器据此返回400/404/409）
+var (
+	ErrInvalidPollCloseAt = errors.New("投票截止时间必须晚于当前时间")
+	ErrPollNotFound       = errors.New("该话题没有投票")
+	ErrPollClosed         = errors.New("投票已截止")
+	ErrPollAlreadyVoted   = errors.New("您已参与过该投票")
+	ErrInvalidPollOption  = errors.New("无效的投票选项")
+	ErrPollSingleChoice   = errors.New("单选投票只能选择一个选项")
+)
+
+// buildPoll 根据创建请求构建投票模型（ID在此生成，随话题一起入库）
+func buildPoll(topicID int64, req *models.CreatePollRequest, now time.Time) (*models.Poll, error) {
+	if err := validatePollCloseAt(req.CloseAt, now); err != nil {
+		return nil, err
+	}
+
+	poll := &models.Poll{
+		ID:          utils.GenerateID(),
+		TopicID:     topicID,
+		MultiChoice: req.MultiChoice,
+		CloseAt:     req.CloseAt,
+		CreatedAt:   now,
+		Options:     make([]*models.PollOption, 0, len(req.Options)),
+	}
+	for i, content := range req.Options {
+		poll.Options = append(poll.Options, &models.PollOption{
+			ID:       utils.GenerateID(),
+			PollID:   poll.ID,
+			Content:  content,
+			Position: i,
+		})
+	}
+	return poll, nil
+}
+
+// validatePollCloseAt 校验截止时间（可以不截止，设置时必须晚于当前时间）
+func validatePollCloseAt(closeAt *time.Time, now time.Time) error {
+	if closeAt != nil && !closeAt.After(now) {
+		return ErrInvalidPollCloseAt
+	}
+	return nil
+}
+
+// VotePoll 参与话题投票
+func VotePoll(userID, topicID int64, req *models.PollVoteRequest) error {
+	// 使用分布式锁防止同一用户并发重复投票
+	ctx := context.Background()
+	lockKey := fmt.Sprintf("lock:poll:%d:%d", topicID, userID)
+
+	return utils.WithLock(ctx, redis.GetClient(), lockKey, 3*time.Second, func() error {
+		// 1. 查询话题的投票
+		poll, err := mysql.GetPollByTopicID(topicID)
+		if err != nil {
+			zap.L().Error("查询投票失败", zap.Error(err), zap.Int64("topic_id", topicID))
+			return errors.New("查询投票失败")
+		}
+		if poll == nil {
+			return ErrPollNotFound
+		}
+
+		// 2. 查询用户已有的投票记录
+		voted, err := mysql.GetUserPollOptionIDs(poll.ID, userID)
+		if err != nil {
+			zap.L().Error("查询投票记录失败", zap.Error(err))
+			return errors.New("查询投票记录失败")
+		}
+
+		// 3. 校验截止时间、是否已投票和选项
+		now := time.Now()
+		optionIDs, err := validatePollVote(poll, voted, req.OptionIDs, now)
+		if err != nil {
+			return err
+		}
+
+		// 4. 写入MySQL（投票记录 + 选项计数）
+		votes := make([]*models.PollVote, 0, len(optionIDs))
+		for _, optionID := range optionIDs {
+			votes = append(votes, &models.PollVote{
+				ID:        utils.GenerateID(),
+				PollID:    poll.ID,
+				OptionID:  optionID,
+				UserID:    userID,
+				CreatedAt: now,
+			})
+		}
+		if err := mysql.InsertPollVotes(votes); err != nil {
+			zap.L().Error("写入投票记录失败", zap.Error(err))
+			return errors.New("投票失败")
+		}
+
+		// 5. 累加Redis计数（缓存不存在时跳过，读取时会从MySQL重建）
+		if err := redis.IncrPollCounts(poll.ID, optionIDs); err != nil {
+			zap.L().Warn("更新投票计数缓存失败", zap.Error(err), zap.Int64("poll_id", poll.ID))
+		}
+
+		return nil
+	})
+}
+
+// validatePollVote 校验本次投票：未截止、用户未投过票（每人只能投一次）、选项有效，返回去重后的选项ID
+func validatePollVote(poll *models.Poll, voted []int64, rawIDs []string, now time.Time) ([]int64, error) {
+	if poll.IsClosed(now) {
+		return nil, ErrPollClosed
+	}
+	if len(voted) > 0 {
+		return nil, ErrPollAlreadyVoted
+	}
+	return parsePollOptionIDs(poll, rawIDs)
+}
+
+// parsePollOptionIDs 解析并校验用户选择的选项
+func parsePollOptionIDs(poll *models.Poll, rawIDs []string) ([]int64, error) {
+	valid := make(map[int64]bool, len(poll.Options))
+	for _, option := range poll.Options {
+		valid[option.ID] = true
+	}
+
+	seen := make(map[int64]bool, len(rawIDs))
+	optionIDs := make([]int64, 0, len(rawIDs))
+	for _, raw := range rawIDs {
+		optionID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || !valid[optionID] {
+			return nil, ErrInvalidPollOption
+		}
+		if seen[optionID] {
+			continue
+		}
+		seen[optionID] = true
+		optionIDs = append(optionIDs, optionID)
+	}
+
+	if !poll.MultiChoice && len(optionIDs) != 1 {
+		return nil, ErrPollSingleChoice
+	}
+	return optionIDs, nil
+}
+
+// GetTopicPoll 获取话题的投票结果，话题没有投票时返回nil
+// userID为0表示未登录用户；用户投票前或投票未截止时不返回计数
+func GetTopicPoll(topicID, userID int64) (*models.Poll, error) {
+	poll, err := mysql.GetPollByTopicID(topicID)
+	if err != nil || poll == nil {
+		return nil, err
+	}
+
+	// 1. 当前用户的投票状态，结果不可见时隐藏计数
+	var optionIDs []int64
+	if userID != 0 {
+		if optionIDs, err = mysql.GetUserPollOptionIDs(poll.ID, userID); err != nil {
+			return nil, err
+		}
+	}
+	if !applyPollVisibility(poll, optionIDs, time.Now()) {
+		return poll, nil
+	}
+
+	// 2. 优先使用Redis中的计数，未命中则以MySQL为准并回填
+	counts, found, err := redis.GetPollCounts(poll.ID)
+	if err != nil {
+		zap.L().Warn("读取投票计数缓存失败", zap.Error(err), zap.Int64("poll_id", poll.ID))
+	}
+	if found {
+		for _, option := range poll.Options {
+			option.VoteCount = counts[option.ID]
+		}
+	} else {
+		counts = make(map[int64]int64, len(poll.Options))
+		for _, option := range poll.Options {
+			counts[option.ID] = option.VoteCount
+		}
+		if err := redis.SetPollCounts(poll.ID, counts); err != nil {
+			zap.L().Warn("回填投票计数缓存失败", zap.Error(err), zap.Int64("poll_id", poll.ID))
+		}
+	}
+
+	for _, option := range poll.Options {
+		poll.TotalVotes += option.VoteCount
+	}
+	return poll, nil
+}
+
+// applyPollVisibility 设置当前用户的投票状态，用户投票前且投票未截止时清空计数，返回结果是否可见
+func applyPollVisibility(poll *models.Poll, myOptionIDs []int64, now time.Time) bool {
+	poll.Voted = len(myOptionIDs) > 0
+	for _, optionID := range myOptionIDs {
+		poll.MyOptionIDs = append(poll.MyOptionIDs, strconv.FormatInt(optionID, 10))
+	}
+	poll.Closed = poll.IsClosed(now)
+	poll.ResultsVisible = poll.Voted || poll.Closed
+
+	if !poll.ResultsVisible {
+		for _, option := range poll.Options {
+			option.VoteCount = 0
+		}
+	}
+	return poll.ResultsVisible
+}
@@ -0,0 +1,123 @@
+package logic
+
+import (
+	"testing"
+	"time"
+	"web_app/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPoll 创建有三个选项（ID为1、2、3）的投票
+func newTestPoll(multiChoice bool, closeAt *time.Time) *models.Poll {
+	poll := &models.Poll{ID: 100, MultiChoice: multiChoice, CloseAt: closeAt}
+	for i := int64(1); i <= 3; i++ {
+		poll.Options = append(poll.Options, &models.PollOption{ID: i, PollID: poll.ID, VoteCount: i * 10})
+	}
+	return poll
+}
+
+// TestValidatePollCloseAt 测试截止时间必须晚于当前时间
+func TestValidatePollCloseAt(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	tests := []struct {
+		name    string
+		closeAt *time.Time
+		wantErr error
+	}{
+		{"不截止", nil, nil},
+		{"将来截止", &future, nil},
+		{"等于当前时间", &now, ErrInvalidPollCloseAt},
+		{"已过去", &past, ErrInvalidPollCloseAt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, validatePollCloseAt(tt.closeAt, now), tt.wantErr)
+		})
+	}
+}
+
+// TestValidatePollVote 测试截止、重复投票、单选/多选和选项校验
+func TestValidatePollVote(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	closed := now
+	open := now.Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		multiChoice bool
+		closeAt     *time.Time
+		voted       []int64
+		rawIDs      []string
+		want        []int64
+		wantErr     error
+	}{
+		{"单选一个选项", false, nil, nil, []string{"2"}, []int64{2}, nil},
+		{"单选多个选项", false, nil, nil, []string{"1", "2"}, nil, ErrPollSingleChoice},
+		{"单选重复同一选项", false, nil, nil, []string{"2", "2"}, []int64{2}, nil},
+		{"多选多个选项", true, nil, nil, []string{"1", "3"}, []int64{1, 3}, nil},
+		{"多选去重", true, nil, nil, []string{"3", "1", "3"}, []int64{3, 1}, nil},
+		{"不存在的选项", true, nil, nil, []string{"1", "4"}, nil, ErrInvalidPollOption},
+		{"选项ID格式错误", false, nil, nil, []string{"abc"}, nil, ErrInvalidPollOption},
+		{"未到截止时间", false, &open, nil, []string{"1"}, []int64{1}, nil},
+		{"到达截止时间", false, &closed, nil, []string{"1"}, nil, ErrPollClosed},
+		{"已投过票", true, nil, []int64{2}, []string{"1"}, nil, ErrPollAlreadyVoted},
+		{"截止优先于重复投票", false, &closed, []int64{2}, []string{"1"}, nil, ErrPollClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poll := newTestPoll(tt.multiChoice, tt.closeAt)
+			got, err := validatePollVote(poll, tt.voted, tt.rawIDs, now)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestApplyPollVisibility 测试用户投票前且未截止时隐藏计数，投票后或截止后可见
+func TestApplyPollVisibility(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	closed := now.Add(-time.Hour)
+	open := now.Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		closeAt     *time.Time
+		myOptionIDs []int64
+		wantVisible bool
+		wantClosed  bool
+		wantMine    []string
+	}{
+		{"未投票未截止", &open, nil, false, false, nil},
+		{"未投票不截止", nil, nil, false, false, nil},
+		{"已投票", &open, []int64{1, 3}, true, false, []string{"1", "3"}},
+		{"未投票已截止", &closed, nil, true, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poll := newTestPoll(true, tt.closeAt)
+			visible := applyPollVisibility(poll, tt.myOptionIDs, now)
+
+			assert.Equal(t, tt.wantVisible, visible)
+			assert.Equal(t, tt.wantVisible, poll.ResultsVisible)
+			assert.Equal(t, tt.wantClosed, poll.Closed)
+			assert.Equal(t, len(tt.myOptionIDs) > 0, poll.Voted)
+			assert.Equal(t, tt.wantMine, poll.MyOptionIDs)
+			for i, option := range poll.Options {
+				if tt.wantVisible {
+					assert.Equal(t, int64(i+1)*10, option.VoteCount)
+				} else {
+					assert.Zero(t, option.VoteCount, "结果不可见时隐藏计数")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+	"web_app/dao/elasticsearch"
+	"web_app/dao/mysql"
+	"web_app/dao/redis"
+	"web_app/models"
+	"web_app/utils"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	// reindexLockKey 重建索引任务锁（同一时间只允许一个重建任务）
+	reindexLockKey = "lock:es:reindex"
+	// reindexTimeout 重建任务最长执行时间（锁和双写标记的过期时间）
+	reindexTimeout = 2 * time.Hour
+	// dualWritePropagation 开启双写后等待各实例消费者感知的时间（需大于消费者的刷新间隔）
+	dualWritePropagation = 5 * time.Second
+	// reindexCatchUpMargin 补齐阶段向前多取的时间，覆盖时钟误差和秒级精度
+	reindexCatchUpMargin = time.Minute
+)
+
+// ErrReindexRunning 已有重建任务在执行
+var ErrReindexRunning = errors.New("已有重建索引任务在执行")
+
+// reindexBatchSize 每批从MySQL读取并写入ES的话题数
+func reindexBatchSize() int {
+	if size := viper.GetInt("elasticsearch.reindex.batch_size"); size > 0 {
+		return size
+	}
+	return 500
+}
+
+// StartReindex 触发零停机重建索引，任务在后台执行，返回新建的物理索引名
+//
+// 流程：创建新版本索引 → 开启Canal双写 → 按ID游标从MySQL流式导入 → 补齐导入期间的更新 →
+// 清理导入期间删除的话题 → 原子切换别名 → 关闭双写。旧索引保留用于回滚。
+func StartReindex() (string, error) {
+	ctx := context.Background()
+
+	lock := utils.NewDistributedLock(redis.GetClient(), reindexLockKey, reindexTimeout)
+	if err := lock.Lock(ctx); err != nil {
+		if errors.Is(err, utils.ErrLockFailed) {
+			return "", ErrReindexRunning
+		}
+		zap.L().Error("获取重建索引锁失败", zap.Error(err))
+		return "", errors.New("获取重建索引锁失败")
+	}
+
+	target, err := elasticsearch.CreateVersionedIndex(ctx)
+	if err != nil {
+		_ = lock.Unlock(ctx)
+		zap.L().Error("创建新索引失败", zap.Error(err))
+		return "", errors.New("创建新索引失败")
+	}
+
+	go func() {
+		defer func() {
+			if err := lock.Unlock(context.Background()); err != nil {
+				zap.L().Warn("释放重建索引锁失败", zap.Error(err))
+			}
+		}()
+		defer func() {
+			if r := recover(); r != nil {
+				zap.L().Error("重建索引panic", zap.Any("error", r))
+			}
+		}()
+
+		if err := runReindex(target); err != nil {
+			zap.L().Error("重建索引失败，删除未完成的新索引", zap.Error(err), zap.String("index", target))
+			// 等待各实例停止双写后再删除，避免迟到的双写按动态mapping重新创建该索引
+			time.Sleep(dualWritePropagation)
+			if err := elasticsearch.DeletePhysicalIndex(context.Background(), target); err != nil {
+				zap.L().Warn("删除未完成的新索引失败", zap.Error(err), zap.String("index", target))
+			}
+		}
+	}()
+
+	return target, nil
+}
+
+// runReindex 执行重建索引
+func runReindex(target string) error {
+	ctx := context.Background()
+	startedAt := time.Now()
+	batchSize := reindexBatchSize()
+	zap.L().Info("开始重建索引", zap.String("index", target), zap.Int("batch_size", batchSize))
+
+	// 1. 导入期间关闭自动刷新，提高写入速度
+	if err := elasticsearch.SetRefreshInterval(ctx, target, "-1"); err != nil {
+		return err
+	}
+
+	// 2. 开启双写并等待所有实例的消费者感知，此后的Canal变更会同时写入新索引
+	if err := redis.SetReindexTarget(target, reindexTimeout); err != nil {
+		return err
+	}
+	elasticsearch.SetDualWriteTarget(target)
+	defer func() {
+		elasticsearch.SetDualWriteTarget("")
+		if err := redis.ClearReindexTarget(); err != nil {
+			zap.L().Warn("清除双写标记失败", zap.Error(err))
+		}
+	}()
+	time.Sleep(dualWritePropagation)
+
+	// 3. 按ID游标从MySQL流式导入
+	total, err := streamTopicsToIndex(ctx, target, func(lastID int64) ([]*models.Topic, error) {
+		return mysql.GetTopicsAfterID(lastID, batchSize)
+	})
+	if err != nil {
+		return err
+	}
+	zap.L().Info("全量导入完成", zap.String("index", target), zap.Int("count", total))
+
+	// 4. 补齐导入期间被修改的话题（导入读取的可能是旧数据，而双写可能早于导入到达）
+	since := startedAt.Add(-reindexCatchUpMargin)
+	updated, err := streamTopicsToIndex(ctx, target, func(lastID int64) ([]*models.Topic, error) {
+		return mysql.GetTopicsUpdatedSince(since, lastID, batchSize)
+	})
+	if err != nil {
+		return err
+	}
+
+	// 5. 恢复刷新间隔并刷新（scroll只能读到已刷新的文档）
+	if err := elasticsearch.SetRefreshInterval(ctx, target, "1s"); err != nil {
+		return err
+	}
+	if err := elasticsearch.RefreshIndex(ctx, target); err != nil {
+		return err
+	}
+
+	// 6. 清理导入期间被删除、却被导入写回的话题
+	removed, err := removeDeletedTopics(ctx, target, batchSize)
+	if err != nil {
+		return err
+	}
+	if err := elasticsearch.RefreshIndex(ctx, target); err != nil {
+		return err
+	}
+
+	// 7. 原子切换别名（切换完成后才关闭双写，避免切换间隙的变更丢失）
+	previous, err := elasticsearch.SwapAlias(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	zap.L().Info("重建索引完成",
+		zap.String("index", target),
+		zap.Strings("previous", previous),
+		zap.Int("imported", total),
+		zap.Int("caught_up", updated),
+		zap.Int("removed", removed),
+		zap.Duration("took", time.Since(startedAt)))
+	return nil
+}
+
+// streamTopicsToIndex 按ID游标分批读取话题并写入指定索引，返回写入数量
+// 每批以读取前的时间作为外部版本号，与双写的Canal变更按时间先后决定保留哪份数据
+func streamTopicsToIndex(ctx context.Context, target string, fetch func(lastID int64) ([]*models.Topic, error)) (int, error) {
+	var lastID int64
+	total := 0
+	for {
+		readAt := time.Now()
+		topics, err := fetch(lastID)
+		if err != nil {
+			return total, err
+		}
+		if len(topics) == 0 {
+			return total, nil
+		}
+		if err := elasticsearch.BulkIndexTopicsTo(ctx, target, topics, readAt.UnixMilli()); err != nil {
+			return total, err
+		}
+		total += len(topics)
+		lastID = topics[len(topics)-1].ID
+	}
+}
+
+// removeDeletedTopics 删除新索引中在MySQL已不存在的话题，返回删除数量
+func removeDeletedTopics(ctx context.Context, target string, batchSize int) (int, error) {
+	removed := 0
+	err := elasticsearch.ScanTopicIDs(ctx, target, batchSize, func(docIDs []string) error {
+		ids := make([]int64, 0, len(docIDs))
+		for _, docID := range docIDs {
+			if id, err := strconv.ParseInt(docID, 10, 64); err == nil {
+				ids = append(ids, id)
+			}
+		}
+		existing, err := mysql.GetExistingTopicIDs(ids)
+		if err != nil {
+			return err
+		}
+
+		var stale []string
+		for _, docID := range docIDs {
+			id, err := strconv.ParseInt(docID, 10, 64)
+			if err != nil || !existing[id] {
+				stale = append(stale, docID)
+			}
+		}
+		removed += len(stale)
+		return elasticsearch.DeleteTopicsFrom(ctx, target, stale)
+	})
+	return removed, err
+}
@@ -0,0 +1,72 @@
+package logic
+
+import (
+	"strconv"
+	"web_app/dao/elasticsearch"
+	"web_app/dao/mysql"
+	"web_app/dao/redis"
+	"web_app/models"
+
+	"go.uber.org/zap"
+)
+
+// relatedTopicsSize 相关话题条数
+const relatedTopicsSize = 5
+
+// GetRelatedTopics 获取相关话题（按相似度排序，只返回标题和统计信息）
+// 相关话题ID按话题缓存，话题数据每次从数据库读取，已删除的话题自然被过滤
+func GetRelatedTopics(topicID int64) ([]*models.Topic, error) {
+	ids, err := redis.GetRelatedTopicIDsCache(topicID)
+	if err != nil {
+		if ids, err = loadRelatedTopicIDs(topicID); err != nil {
+			return nil, err
+		}
+	}
+
+	topics, err := mysql.GetTopicsByIDs(ids)
+	if err != nil {
+		zap.L().Error("查询相关话题失败", zap.Error(err), zap.Int64("topic_id", topicID))
+		return nil, err
+	}
+
+	// 按相似度顺序返回，不返回正文
+	byID := make(map[int64]*models.Topic, len(topics))
+	for _, topic := range topics {
+		byID[topic.ID] = topic
+	}
+	result := make([]*models.Topic, 0, len(ids))
+	for _, id := range ids {
+		if topic, ok := byID[id]; ok {
+			topic.Content = ""
+			topic.ContentHTML = ""
+			result = append(result, topic)
+		}
+	}
+	return result, nil
+}
+
+// loadRelatedTopicIDs 缓存未命中时从ES查询相关话题ID并写入缓存
+func loadRelatedTopicIDs(topicID int64) ([]int64, error) {
+	// 确认话题存在（话题不存在时返回“话题不存在”）
+	if _, err := mysql.GetTopicByID(topicID); err != nil {
+		return nil, err
+	}
+
+	docIDs, err := elasticsearch.RelatedTopics(strconv.FormatInt(topicID, 10), relatedTopicsSize)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(docIDs))
+	for _, docID := range docIDs {
+		if id, err := strconv.ParseInt(docID, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	go func() {
+		if err := redis.CacheRelatedTopicIDs(topicID, ids); err != nil {
+			zap.L().Warn("缓存相关话题失败", zap.Error(err), zap.Int64("topic_id", topicID))
+		}
+	}()
+	return ids, nil
+}
@@ -2,16 +2,51 @@
 package logic
 
 import (
+	"errors"
 	"fmt"
+	"html"
 	"strconv"
+	"strings"
 	"time"
 	"web_app/dao/elasticsearch"
+	"web_app/dao/mysql"
+	"web_app/dao/redis"
 	"web_app/models"
+	"web_app/utils"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
 )
 
+// ErrInvalidSearchParams 搜索参数错误
+var ErrInvalidSearchParams = errors.New("搜索参数错误")
+
+const (
+	// suggestTopicsSize 标题补全条数
+	suggestTopicsSize = 8
+	// suggestQueriesSize 热门搜索词条数
+	suggestQueriesSize = 5
+	// popularQueryMinCount 近7天至少被搜索过的次数（过滤只出现一次的搜索词）
+	popularQueryMinCount = 2
+)
+
+// relaxOnEmpty 零结果时是否放宽匹配重试（默认开启）
+func relaxOnEmpty() bool {
+	if viper.IsSet("elasticsearch.search.relax_on_empty") {
+		return viper.GetBool("elasticsearch.search.relax_on_empty")
+	}
+	return true
+}
+
+// searchDateLayouts 支持的时间筛选格式
+var searchDateLayouts = []string{esTimeFormat, "2006-01-02"}
+
 // SearchTopics 搜索话题
-func SearchTopics(keyword, category string, page, pageSize int, sortBy string) (*models.SearchResponse, error) {
+func SearchTopics(params *models.SearchTopicsRequest) (*models.SearchResponse, error) {
+	start := time.Now()
+
 	// 参数验证
+	page, pageSize := params.Page, params.PageSize
 	if page < 1 {
 		page = 1
 	}
@@ -19,44 +54,87 @@ func SearchTopics(keyword, category string, page, pageSize int, sortBy string) (
 		pageSize = 20
 	}
 
-	// 调用ES搜索
+	// 未指定排序时：有关键词按相关度，否则按时间
+	sortBy := params.SortBy
+	if sortBy == "" {
+		if params.Keyword != "" {
+			sortBy = elasticsearch.SortByRelevance
+		} else {
+			sortBy = elasticsearch.SortByCreatedAt
+		}
+	}
+
 	req := &elasticsearch.SearchRequest{
-		Keyword:  keyword,
-		Category: category,
-		Page:     page,
-		PageSize: pageSize,
-		SortBy:   sortBy,
+		Keyword:     params.Keyword,
+		Category:    params.Category,
+		MinLikes:    params.MinLikes,
+		MinComments: params.MinComments,
+		Page:        page,
+		PageSize:    pageSize,
+		SortBy:      sortBy,
+	}
+	if err := applySearchFilters(req, params); err != nil {
+		return nil, err
+	}
+
+	// 作者不存在时直接返回空结果
+	if params.Author != "" {
+		user, err := mysql.GetUserByUsername(params.Author)
+		if err != nil {
+			if err.Error() == "用户不存在" {
+				response := emptySearchResponse(page, pageSize)
+				recordSearch(params, response, time.Since(start))
+				return response, nil
+			}
+			return nil, err
+		}
+		req.UserID = strconv.FormatInt(user.ID, 10)
 	}
 
-	esResp, err := elasticsearch.SearchTopics(req)
+	// 综合模式：先找出评论命中的话题，一并参与话题搜索（降级为MySQL全文检索时不支持）
+	var commentMatches map[string]*elasticsearch.CommentHit
+	if params.WithComments && params.Keyword != "" && searchBackends.usesElasticsearch() {
+		commentMatches = matchCommentTopics(params.Keyword)
+		for topicID := range commentMatches {
+			req.CommentTopicIDs = append(req.CommentTopicIDs, topicID)
+		}
+	}
+
+	// 调用搜索后端（ES不可用时降级为MySQL全文检索）
+	esResp, backend, err := searchBackends.SearchTopics(req)
 	if err != nil {
 		return nil, err
 	}
 
-	// 转换为models.Topic格式
-	topics := make([]*models.Topic, 0, len(esResp.Topics))
-	timeFormat := "2006-01-02 15:04:05"
+	// 零结果时：给出拼写纠正建议，并按配置放宽匹配重试一次
+	var suggestion string
+	if esResp.Total == 0 && params.Keyword != "" {
+		suggestion = esResp.Suggestion
+		if relaxOnEmpty() {
+			req.Relaxed = true
+			if esResp, backend, err = searchBackends.SearchTopics(req); err != nil {
+				return nil, err
+			}
+		}
+	}
 
-	for _, doc := range esResp.Topics {
-		topicID, _ := strconv.ParseInt(doc.TopicID, 10, 64)
-		userID, _ := strconv.ParseInt(doc.UserID, 10, 64)
-
-		// 解析时间字符串
-		createdAt, _ := time.Parse(timeFormat, doc.CreatedAt)
-		updatedAt, _ := time.Parse(timeFormat, doc.UpdatedAt)
-
-		topic := &models.Topic{
-			ID:           topicID,
-			UserID:       userID,
-			Title:        doc.Title,
-			Content:      doc.Content,
-			Category:     doc.Category,
-			CreatedAt:    createdAt,
-			UpdatedAt:    updatedAt,
-			ViewCount:    doc.ViewCount,
-			CommentCount: doc.CommentCount,
+	// 转换为搜索结果（标题/摘要使用ES高亮片段，未命中高亮时转义原文兜底）
+	results := make([]*models.SearchResult, 0, len(esResp.Hits))
+	for _, hit := range esResp.Hits {
+		highlightTitle := hit.HighlightTitle
+		if highlightTitle == "" {
+			highlightTitle = html.EscapeString(hit.Topic.Title)
+		}
+		result := &models.SearchResult{
+			Topic:          topicFromDocument(hit.Topic),
+			HighlightTitle: highlightTitle,
+			Snippet:        hit.Snippet,
 		}
-		topics = append(topics, topic)
+		if match, ok := commentMatches[hit.Topic.TopicID]; ok {
+			commentID, _ := strconv.ParseInt(match.Comment.CommentID, 10, 64)
+			result.MatchedComment = &models.CommentMatch{CommentID: commentID, Snippet: match.Snippet}
+		}
+		results = append(results, result)
 	}
 
 	// 构建响应
@@ -69,25 +147,132 @@ func SearchTopics(keyword, category string, page, pageSize int, sortBy string) (
 		PageSize:   pageSize,
 		TotalPages: totalPages,
 		HasMore:    hasMore,
-		Topics:     topics,
+		Topics:     results,
+		Facets:     esResp.Facets,
+		Suggestion: suggestion,
+		Relaxed:    req.Relaxed && esResp.Total > 0,
 		Took:       esResp.Took,
+		Backend:    backend,
 	}
 
+	// 统计耗时和零结果，并异步记录搜索日志
+	recordSearch(params, response, time.Since(start))
+
 	return response, nil
 }
 
-// SuggestTopics 搜索建议
-func SuggestTopics(prefix string) ([]string, error) {
-	if prefix == "" {
-		return []string{}, nil
+// applySearchFilters 校验并转换标签和时间筛选条件
+func applySearchFilters(req *elasticsearch.SearchRequest, params *models.SearchTopicsRequest) error {
+	tags, err := utils.NormalizeTags(params.Tags)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSearchParams, err.Error())
 	}
+	req.Tags = tags
 
-	suggestions, err := elasticsearch.SuggestTopics(prefix, 10)
+	var from, to time.Time
+	if params.CreatedFrom != "" {
+		if from, err = parseSearchDate(params.CreatedFrom); err != nil {
+			return fmt.Errorf("%w: created_from 格式应为 yyyy-MM-dd 或 yyyy-MM-dd HH:mm:ss", ErrInvalidSearchParams)
+		}
+		req.CreatedFrom = params.CreatedFrom
+	}
+	if params.CreatedTo != "" {
+		if to, err = parseSearchDate(params.CreatedTo); err != nil {
+			return fmt.Errorf("%w: created_to 格式应为 yyyy-MM-dd 或 yyyy-MM-dd HH:mm:ss", ErrInvalidSearchParams)
+		}
+		req.CreatedTo = params.CreatedTo
+	}
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		return fmt.Errorf("%w: created_from 不能晚于 created_to", ErrInvalidSearchParams)
+	}
+	return nil
+}
+
+// parseSearchDate 解析时间筛选参数
+func parseSearchDate(value string) (time.Time, error) {
+	var err error
+	for _, layout := range searchDateLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// emptySearchResponse 构建空的搜索结果
+func emptySearchResponse(page, pageSize int) *models.SearchResponse {
+	return &models.SearchResponse{
+		Page:     page,
+		PageSize: pageSize,
+		Topics:   []*models.SearchResult{},
+		Facets: &models.SearchFacets{
+			Categories: []models.FacetBucket{},
+			Tags:       []models.FacetBucket{},
+			Daily:      []models.FacetBucket{},
+		},
+	}
+}
+
+// SuggestTopics 搜索建议：以输入开头的热门搜索词 + 标题补全（可按分类限定）
+// 热门搜索词获取失败时只返回标题补全
+func SuggestTopics(prefix, category string) (*models.SuggestResponse, error) {
+	response := &models.SuggestResponse{
+		Queries: []string{},
+		Topics:  []*models.TopicSuggestion{},
+	}
+	if strings.TrimSpace(prefix) == "" {
+		return response, nil
+	}
+
+	docs, err := elasticsearch.SuggestTopics(prefix, category, suggestTopicsSize)
 	if err != nil {
 		return nil, fmt.Errorf("获取搜索建议失败: %w", err)
 	}
+	for _, doc := range docs {
+		topicID, _ := strconv.ParseInt(doc.TopicID, 10, 64)
+		response.Topics = append(response.Topics, &models.TopicSuggestion{
+			ID:       topicID,
+			Title:    doc.Title,
+			Category: doc.Category,
+		})
+	}
+
+	queries, err := redis.GetPopularQueries(popularQueryMinCount)
+	if err != nil {
+		zap.L().Warn("获取热门搜索词失败", zap.Error(err))
+		return response, nil
+	}
+	response.Queries = matchPopularQueries(queries, prefix, suggestQueriesSize)
+	return response, nil
+}
+
+// matchPopularQueries 从按热度排序的搜索词中选出以前缀开头的（不含与前缀完全相同的和被屏蔽的）
+func matchPopularQueries(queries []string, prefix string, size int) []string {
+	prefix = utils.NormalizeSearchQuery(prefix)
+	matched := make([]string, 0, size)
+	for _, query := range queries {
+		if len(matched) >= size {
+			break
+		}
+		if query != prefix && strings.HasPrefix(query, prefix) && popularQueryAllowed(query) {
+			matched = append(matched, query)
+		}
+	}
+	return matched
+}
+
+// popularQueryAllowed 判断搜索词能否作为热门搜索词公开展示（接入审核服务时替换）
+var popularQueryAllowed = notBlockedQuery
 
-	return suggestions, nil
+// notBlockedQuery 搜索词不包含 search_analytics.blocked_words 中的屏蔽词
+func notBlockedQuery(query string) bool {
+	for _, word := range viper.GetStringSlice("search_analytics.blocked_words") {
+		if word = utils.NormalizeSearchQuery(word); word != "" && strings.Contains(query, word) {
+			return false
+		}
+	}
+	return true
 }
 
 // GetHotTopicsByCategory 获取分类热门话题
@@ -102,28 +287,8 @@ func GetHotTopicsByCategory(category string, size int) ([]*models.Topic, error)
 	}
 
 	topics := make([]*models.Topic, 0, len(docs))
-	timeFormat := "2006-01-02 15:04:05"
-
 	for _, doc := range docs {
-		topicID, _ := strconv.ParseInt(doc.TopicID, 10, 64)
-		userID, _ := strconv.ParseInt(doc.UserID, 10, 64)
-
-		// 解析时间字符串
-		createdAt, _ := time.Parse(timeFormat, doc.CreatedAt)
-		updatedAt, _ := time.Parse(timeFormat, doc.UpdatedAt)
-
-		topic := &models.Topic{
-			ID:           topicID,
-			UserID:       userID,
-			Title:        doc.Title,
-			Content:      doc.Content,
-			Category:     doc.Category,
-			CreatedAt:    createdAt,
-			UpdatedAt:    updatedAt,
-			ViewCount:    doc.ViewCount,
-			CommentCount: doc.CommentCount,
-		}
-		topics = append(topics, topic)
+		topics = append(topics, topicFromDocument(doc))
 	}
 
 	return topics, nil
@@ -138,3 +303,35 @@ func GetCategoryStats() (map[string]int64, error) {
 
 	return stats, nil
 }
+
+// esTimeFormat ES文档中的时间格式
+const esTimeFormat = "2006-01-02 15:04:05"
+
+// topicFromDocument 将ES文档转换为话题模型
+func topicFromDocument(doc *elasticsearch.TopicDocument) *models.Topic {
+	topicID, _ := strconv.ParseInt(doc.TopicID, 10, 64)
+	userID, _ := strconv.ParseInt(doc.UserID, 10, 64)
+
+	// 解析时间字符串
+	createdAt, _ := time.Parse(esTimeFormat, doc.CreatedAt)
+	updatedAt, _ := time.Parse(esTimeFormat, doc.UpdatedAt)
+
+	return &models.Topic{
+		ID:           topicID,
+		UserID:       userID,
+		Username:     doc.Username,
+		Title:        doc.Title,
+		Content:      doc.Content,
+		Category:     doc.Category,
+		Tags:         doc.Tags,
+		CreatedAt:    createdAt,
+		UpdatedAt:    updatedAt,
+		LikeCount:    doc.LikeCount,
+		DislikeCount: doc.DislikeCount,
+		ViewCount:    doc.ViewCount,
+		CommentCount: doc.CommentCount,
+		IsPinned:     doc.IsPinned,
+		PinScope:     doc.PinScope,
+		IsLocked:     doc.IsLocked,
+	}
+}
@@ -0,0 +1,249 @@
+// Package logic 搜索分析：异步记录搜索日志、按小时汇总搜索词，并提供搜索耗时和零结果率指标
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+	"web_app/dao/mysql"
+	"web_app/dao/redis"
+	"web_app/models"
+	"web_app/utils"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxSearchLogFiltersLen 筛选条件JSON的最大长度，与 search_logs.filters 列一致
+	maxSearchLogFiltersLen = 512
+	// defaultSearchStatsHours 搜索统计默认查询最近多少小时
+	defaultSearchStatsHours = 24
+	// defaultSearchStatsLimit 搜索词统计默认返回条数
+	defaultSearchStatsLimit = 20
+)
+
+var (
+	// 搜索耗时直方图（包含零结果时放宽匹配的重试）
+	searchDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "search_duration_seconds",
+			Help:    "话题搜索耗时（秒）",
+			Buckets: []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+		},
+		[]string{"result"},
+	)
+
+	// 搜索请求总数，result=hit/zero，零结果率 = zero / (hit + zero)
+	searchRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "search_requests_total",
+			Help: "话题搜索请求总数",
+		},
+		[]string{"result"},
+	)
+
+	// 缓冲区已满而丢弃的搜索日志数
+	searchLogsDropped = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "search_logs_dropped_total",
+			Help: "缓冲区已满而丢弃的搜索日志数",
+		},
+	)
+)
+
+// searchAnalyticsWorker 搜索日志异步写入器：日志先进入缓冲通道，攒够一批或定时写入MySQL并更新Redis小时统计
+type searchAnalyticsWorker struct {
+	logs          chan *models.SearchLog
+	batchSize     int
+	flushInterval time.Duration
+
+	mu     sync.RWMutex // 保护 closed，避免向已关闭的通道写入
+	closed bool
+}
+
+// searchAnalytics 全局搜索日志写入器，未初始化时不记录搜索日志（指标照常统计）
+var searchAnalytics *searchAnalyticsWorker
+
+// InitSearchAnalytics 初始化搜索日志写入器（缓冲区在重启写入任务时保留）
+func InitSearchAnalytics() {
+	bufferSize := viper.GetInt("search_analytics.buffer_size")
+	if bufferSize <= 0 {
+		bufferSize = 1024
+	}
+	batchSize := viper.GetInt("search_analytics.batch_size")
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	flushInterval := viper.GetDuration("search_analytics.flush_interval")
+	if flushInterval <= 0 {
+		flushInterval = 5 * time.Second
+	}
+
+	searchAnalytics = &searchAnalyticsWorker{
+		logs:          make(chan *models.SearchLog, bufferSize),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+	}
+	zap.L().Info("搜索日志写入器已初始化",
+		zap.Int("buffer_size", bufferSize),
+		zap.Int("batch_size", batchSize),
+		zap.Duration("flush_interval", flushInterval))
+}
+
+// RunSearchAnalytics 消费缓冲区按批写入搜索日志，ctx取消后停止接收新日志，写完缓冲区中剩余的日志后返回
+func RunSearchAnalytics(ctx context.Context) error {
+	w := searchAnalytics
+	if w == nil {
+		return errors.New("搜索日志写入器未初始化")
+	}
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*models.SearchLog, 0, w.batchSize)
+	for {
+		select {
+		case log := <-w.logs:
+			batch = append(batch, log)
+			if len(batch) >= w.batchSize {
+				flushSearchLogs(batch)
+				batch = make([]*models.SearchLog, 0, w.batchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				flushSearchLogs(batch)
+				batch = make([]*models.SearchLog, 0, w.batchSize)
+			}
+		case <-ctx.Done():
+			w.mu.Lock()
+			if !w.closed {
+				w.closed = true
+				close(w.logs)
+			}
+			w.mu.Unlock()
+
+			for log := range w.logs {
+				batch = append(batch, log)
+			}
+			flushSearchLogs(batch)
+			return nil
+		}
+	}
+}
+
+// flushSearchLogs 写入一批搜索日志，并计入小时统计和热门搜索词
+// 统计数据只用于分析，写入失败只记录日志
+func flushSearchLogs(batch []*models.SearchLog) {
+	if len(batch) == 0 {
+		return
+	}
+	if err := mysql.InsertSearchLogs(batch); err != nil {
+		zap.L().Warn("写入搜索日志失败", zap.Error(err), zap.Int("count", len(batch)))
+	}
+	for _, log := range batch {
+		if err := redis.IncrSearchStats(log); err != nil {
+			zap.L().Warn("更新搜索统计失败", zap.Error(err), zap.String("keyword", log.Keyword))
+		}
+		// 有结果的搜索词用于搜索建议（放宽匹配的多为拼写错误，屏蔽的搜索词不公开展示，都不记录）
+		if log.ResultCount > 0 && !log.Relaxed && popularQueryAllowed(log.Keyword) {
+			if err := redis.IncrSearchQuery(log.Keyword); err != nil {
+				zap.L().Warn("记录搜索词失败", zap.Error(err), zap.String("query", log.Keyword))
+			}
+		}
+	}
+}
+
+// recordSearch 统计一次搜索的耗时和结果，并记录搜索日志
+// 只记录带关键词的第一页搜索（翻页不重复记录），缓冲区已满时丢弃，不阻塞搜索请求
+func recordSearch(params *models.SearchTopicsRequest, response *models.SearchResponse, latency time.Duration) {
+	result := "hit"
+	if response.Total == 0 {
+		result = "zero"
+	}
+	searchDuration.WithLabelValues(result).Observe(latency.Seconds())
+	searchRequestsTotal.WithLabelValues(result).Inc()
+
+	w := searchAnalytics
+	if w == nil || response.Page != 1 {
+		return
+	}
+	keyword := utils.NormalizeSearchQuery(params.Keyword)
+	if keyword == "" {
+		return
+	}
+
+	log := &models.SearchLog{
+		ID:          utils.GenerateID(),
+		Keyword:     keyword,
+		Filters:     searchLogFilters(params),
+		ResultCount: response.Total,
+		LatencyMs:   latency.Milliseconds(),
+		Relaxed:     response.Relaxed,
+		CreatedAt:   time.Now(),
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.logs <- log:
+	default:
+		searchLogsDropped.Inc()
+	}
+}
+
+// searchLogFilters 将搜索参数中的筛选条件序列化为JSON，没有筛选条件时为空字符串
+func searchLogFilters(params *models.SearchTopicsRequest) string {
+	filters := models.SearchLogFilters{
+		Category:     params.Category,
+		Author:       params.Author,
+		Tags:         params.Tags,
+		CreatedFrom:  params.CreatedFrom,
+		CreatedTo:    params.CreatedTo,
+		MinLikes:     params.MinLikes,
+		MinComments:  params.MinComments,
+		SortBy:       params.SortBy,
+		WithComments: params.WithComments,
+	}
+	data, err := json.Marshal(filters)
+	if err != nil || string(data) == "{}" || len(data) > maxSearchLogFiltersLen {
+		return ""
+	}
+	return string(data)
+}
+
+// normalizeSearchStatsRequest 校验统计查询参数，超出范围时使用默认值
+func normalizeSearchStatsRequest(req *models.SearchQueryStatsRequest) (hours, limit int) {
+	hours, limit = req.Hours, req.Limit
+	if hours < 1 || hours > redis.SearchStatsMaxHours {
+		hours = defaultSearchStatsHours
+	}
+	if limit < 1 || limit > 100 {
+		limit = defaultSearchStatsLimit
+	}
+	return hours, limit
+}
+
+// GetTopSearchQueries 获取最近一段时间搜索次数最多的搜索词
+func GetTopSearchQueries(req *models.SearchQueryStatsRequest) ([]models.SearchQueryStat, error) {
+	hours, limit := normalizeSearchStatsRequest(req)
+	return redis.GetTopSearchQueries(hours, limit, false)
+}
+
+// GetZeroResultQueries 获取最近一段时间零结果次数最多的搜索词
+func GetZeroResultQueries(req *models.SearchQueryStatsRequest) ([]models.SearchQueryStat, error) {
+	hours, limit := normalizeSearchStatsRequest(req)
+	return redis.GetTopSearchQueries(hours, limit, true)
+}
+
+// GetHourlySearchStats 获取最近一段时间每小时的搜索次数、零结果率和平均耗时
+func GetHourlySearchStats(req *models.SearchQueryStatsRequest) ([]*models.SearchHourlyStat, error) {
+	hours, _ := normalizeSearchStatsRequest(req)
+	return redis.GetHourlySearchStats(hours)
+}
@@ -0,0 +1,283 @@
+// Package logic 搜索后端：Elasticsearch 与 MySQL 全文检索，ES故障时由熔断器自动降级
+package logic
+
+import (
+	"context"
+	"html"
+	"strconv"
+	"time"
+	"unicode/utf8"
+	"web_app/dao/elasticsearch"
+	"web_app/dao/mysql"
+	"web_app/models"
+	"web_app/utils"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 搜索后端（search.backend）
+const (
+	SearchBackendAuto          = "auto"          // 优先ES，ES不可用或熔断时降级为MySQL全文检索
+	SearchBackendElasticsearch = "elasticsearch" // 只使用ES（ES不可用时启动失败）
+	SearchBackendMySQL         = "mysql"         // 只使用MySQL全文检索
+)
+
+const (
+	// defaultBreakerFailures 熔断器默认连续失败阈值
+	defaultBreakerFailures = 5
+	// defaultBreakerOpenTimeout 熔断器打开后默认多久放行探测请求
+	defaultBreakerOpenTimeout = 30 * time.Second
+	// defaultSearchTimeout ES搜索默认超时时间（ES无响应时超时计为不可用，触发降级和熔断）
+	defaultSearchTimeout = 3 * time.Second
+	// fallbackSnippetSize 降级搜索的内容摘要长度（字符，与ES高亮摘要一致）
+	fallbackSnippetSize = 120
+)
+
+// 降级原因
+const (
+	fallbackUnavailable = "unavailable"  // ES未连接
+	fallbackBreakerOpen = "breaker_open" // 熔断器打开
+	fallbackError       = "error"        // 本次ES请求失败
+)
+
+var (
+	// 话题搜索降级为MySQL全文检索的次数（reason=unavailable/breaker_open/error）
+	searchFallbacks = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "search_fallback_total",
+			Help: "话题搜索降级为MySQL全文检索的次数",
+		},
+		[]string{"reason"},
+	)
+
+	// ES搜索熔断器状态
+	searchBreakerState = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "search_breaker_state",
+			Help: "ES搜索熔断器状态（0=关闭，1=打开，2=半开）",
+		},
+	)
+)
+
+// SearchBackend 话题搜索后端
+type SearchBackend interface {
+	// Name 后端名称（返回给前端，用于提示当前是否为降级搜索）
+	Name() string
+	// SearchTopics 搜索话题
+	SearchTopics(ctx context.Context, req *elasticsearch.SearchRequest) (*elasticsearch.SearchResponse, error)
+}
+
+// esSearchBackend Elasticsearch搜索（相关度打分、高亮、聚合、拼写纠正）
+type esSearchBackend struct{}
+
+// Name 后端名称
+func (esSearchBackend) Name() string {
+	return SearchBackendElasticsearch
+}
+
+// SearchTopics 搜索话题
+func (esSearchBackend) SearchTopics(ctx context.Context, req *elasticsearch.SearchRequest) (*elasticsearch.SearchResponse, error) {
+	return elasticsearch.SearchTopics(ctx, req)
+}
+
+// mysqlSearchBackend MySQL全文检索（ngram分词），不支持高亮、聚合、拼写纠正和评论命中
+type mysqlSearchBackend struct{}
+
+// Name 后端名称
+func (mysqlSearchBackend) Name() string {
+	return SearchBackendMySQL
+}
+
+// SearchTopics 搜索话题，放宽匹配时命中任意一个词即可
+func (mysqlSearchBackend) SearchTopics(_ context.Context, req *elasticsearch.SearchRequest) (*elasticsearch.SearchResponse, error) {
+	start := time.Now()
+	userID, _ := strconv.ParseInt(req.UserID, 10, 64)
+	topics, total, err := mysql.SearchTopicsFullText(&mysql.FullTextSearchRequest{
+		Keyword:     req.Keyword,
+		Category:    req.Category,
+		UserID:      userID,
+		Tags:        req.Tags,
+		CreatedFrom: req.CreatedFrom,
+		CreatedTo:   req.CreatedTo,
+		MinLikes:    req.MinLikes,
+		MinComments: req.MinComments,
+		Page:        req.Page,
+		PageSize:    req.PageSize,
+		SortBy:      req.SortBy,
+		MatchAny:    req.Relaxed,
+		SnippetSize: fallbackSnippetSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 与相关话题一样不返回正文，只返回摘要
+	hits := make([]*elasticsearch.SearchHit, 0, len(topics))
+	for _, topic := range topics {
+		snippet := fallbackSnippet(topic.Content)
+		topic.Content = ""
+		topic.ContentHTML = ""
+		hits = append(hits, &elasticsearch.SearchHit{
+			Topic:   documentFromTopic(topic),
+			Snippet: snippet,
+		})
+	}
+	return &elasticsearch.SearchResponse{
+		Total:    total,
+		Hits:     hits,
+		Facets:   emptySearchResponse(req.Page, req.PageSize).Facets,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Took:     time.Since(start).Milliseconds(),
+	}, nil
+}
+
+// fallbackSnippet 降级搜索的内容摘要：原文开头部分（已HTML转义，没有高亮）
+func fallbackSnippet(content string) string {
+	if utf8.RuneCountInString(content) > fallbackSnippetSize {
+		content = string([]rune(content)[:fallbackSnippetSize])
+	}
+	return html.EscapeString(content)
+}
+
+// searchRouter 按配置和熔断器状态选择搜索后端
+type searchRouter struct {
+	mode      string
+	primary   SearchBackend // Elasticsearch
+	fallback  SearchBackend // MySQL全文检索
+	available func() bool   // ES是否已连接
+	breaker   *utils.CircuitBreaker
+	timeout   time.Duration // ES搜索超时时间
+}
+
+// newSearchRouter 创建搜索后端选择器
+func newSearchRouter(mode string, failures int, openTimeout, timeout time.Duration) *searchRouter {
+	breaker := utils.NewCircuitBreaker(failures, openTimeout)
+	breaker.OnStateChange = func(from, to utils.BreakerState) {
+		searchBreakerState.Set(float64(to))
+		zap.L().Warn("ES搜索熔断器状态变化", zap.Stringer("from", from), zap.Stringer("to", to))
+	}
+	return &searchRouter{
+		mode:      mode,
+		primary:   esSearchBackend{},
+		fallback:  mysqlSearchBackend{},
+		available: elasticsearch.Available,
+		breaker:   breaker,
+		timeout:   timeout,
+	}
+}
+
+// searchBackends 全局搜索后端选择器（InitSearchBackend按配置重新创建）
+var searchBackends = newSearchRouter(SearchBackendAuto, defaultBreakerFailures, defaultBreakerOpenTimeout, defaultSearchTimeout)
+
+// InitSearchBackend 按配置初始化搜索后端（未知的配置值按auto处理）
+func InitSearchBackend() {
+	mode := viper.GetString("search.backend")
+	switch mode {
+	case SearchBackendElasticsearch, SearchBackendMySQL:
+	default:
+		mode = SearchBackendAuto
+	}
+	failures := viper.GetInt("search.breaker.failure_threshold")
+	if failures <= 0 {
+		failures = defaultBreakerFailures
+	}
+	openTimeout := viper.GetDuration("search.breaker.open_timeout")
+	if openTimeout <= 0 {
+		openTimeout = defaultBreakerOpenTimeout
+	}
+	timeout := viper.GetDuration("search.timeout")
+	if timeout <= 0 {
+		timeout = defaultSearchTimeout
+	}
+	searchBackends = newSearchRouter(mode, failures, openTimeout, timeout)
+}
+
+// SearchBackendMode 配置的搜索后端
+func SearchBackendMode() string {
+	return searchBackends.mode
+}
+
+// usesElasticsearch 当前是否使用ES搜索（降级期间跳过依赖ES的评论命中查询）
+func (r *searchRouter) usesElasticsearch() bool {
+	switch r.mode {
+	case SearchBackendMySQL:
+		return false
+	case SearchBackendElasticsearch:
+		return true
+	}
+	return r.available() && r.breaker.State() == utils.BreakerClosed
+}
+
+// SearchTopics 搜索话题，返回结果和实际使用的后端名称
+// auto模式下ES未连接、熔断器打开或本次请求因ES不可用（含超时）而失败时，改用MySQL全文检索
+func (r *searchRouter) SearchTopics(req *elasticsearch.SearchRequest) (*elasticsearch.SearchResponse, string, error) {
+	switch r.mode {
+	case SearchBackendMySQL:
+		return r.search(r.fallback, req)
+	case SearchBackendElasticsearch:
+		return r.searchPrimary(req)
+	}
+
+	if !r.available() {
+		return r.searchFallback(req, fallbackUnavailable)
+	}
+	if !r.breaker.Allow() {
+		return r.searchFallback(req, fallbackBreakerOpen)
+	}
+
+	resp, _, err := r.searchPrimary(req)
+	if err != nil && elasticsearch.IsUnavailable(err) {
+		r.breaker.Failure()
+		zap.L().Warn("ES搜索失败，降级为MySQL全文检索", zap.Error(err), zap.String("keyword", req.Keyword))
+		return r.searchFallback(req, fallbackError)
+	}
+	// 查询本身有误（4xx）时ES是可用的，不计入熔断
+	r.breaker.Success()
+	return resp, r.primary.Name(), err
+}
+
+// searchPrimary 使用ES搜索，超过超时时间未返回时取消请求
+func (r *searchRouter) searchPrimary(req *elasticsearch.SearchRequest) (*elasticsearch.SearchResponse, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	resp, err := r.primary.SearchTopics(ctx, req)
+	return resp, r.primary.Name(), err
+}
+
+// search 使用指定后端搜索
+func (r *searchRouter) search(backend SearchBackend, req *elasticsearch.SearchRequest) (*elasticsearch.SearchResponse, string, error) {
+	resp, err := backend.SearchTopics(context.Background(), req)
+	return resp, backend.Name(), err
+}
+
+// searchFallback 降级为MySQL全文检索
+func (r *searchRouter) searchFallback(req *elasticsearch.SearchRequest, reason string) (*elasticsearch.SearchResponse, string, error) {
+	searchFallbacks.WithLabelValues(reason).Inc()
+	return r.search(r.fallback, req)
+}
+
+// documentFromTopic 将话题转换为搜索结果中的文档（与ES中的文档字段一致）
+func documentFromTopic(topic *models.Topic) *elasticsearch.TopicDocument {
+	return &elasticsearch.TopicDocument{
+		TopicID:      strconv.FormatInt(topic.ID, 10),
+		UserID:       strconv.FormatInt(topic.UserID, 10),
+		Username:     topic.Username,
+		Title:        topic.Title,
+		Content:      topic.Content,
+		Category:     topic.Category,
+		Tags:         topic.Tags,
+		CreatedAt:    topic.CreatedAt.Format(esTimeFormat),
+		UpdatedAt:    topic.UpdatedAt.Format(esTimeFormat),
+		LikeCount:    topic.LikeCount,
+		DislikeCount: topic.DislikeCount,
+		ViewCount:    topic.ViewCount,
+		CommentCount: topic.CommentCount,
+		IsPinned:     topic.IsPinned,
+		PinScope:     topic.PinScope,
+		IsLocked:     topic.IsLocked,
+	}
+}
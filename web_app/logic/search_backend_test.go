@@ -0,0 +1,49 @@
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+	"web_app/dao/elasticsearch"
+	"web_app/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSearchBackend 模拟搜索后端，hang为true时一直等到ctx取消（模拟ES无响应）
+type fakeSearchBackend struct {
+	name string
+	hang bool
+}
+
+func (b fakeSearchBackend) Name() string { return b.name }
+
+func (b fakeSearchBackend) SearchTopics(ctx context.Context, req *elasticsearch.SearchRequest) (*elasticsearch.SearchResponse, error) {
+	if b.hang {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &elasticsearch.SearchResponse{Page: req.Page}, nil
+}
+
+// TestSearchRouter_TimeoutFallsBack 测试ES无响应时按超时时间降级为MySQL，并计入熔断
+func TestSearchRouter_TimeoutFallsBack(t *testing.T) {
+	r := newSearchRouter(SearchBackendAuto, 1, time.Minute, 20*time.Millisecond)
+	r.primary = fakeSearchBackend{name: SearchBackendElasticsearch, hang: true}
+	r.fallback = fakeSearchBackend{name: SearchBackendMySQL}
+	r.available = func() bool { return true }
+
+	start := time.Now()
+	resp, backend, err := r.SearchTopics(&elasticsearch.SearchRequest{Page: 1})
+	require.NoError(t, err)
+	assert.Equal(t, SearchBackendMySQL, backend)
+	assert.Equal(t, 1, resp.Page)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, utils.BreakerOpen, r.breaker.State(), "超时计入熔断")
+
+	// 熔断后直接使用MySQL，不再等待ES
+	_, backend, err = r.SearchTopics(&elasticsearch.SearchRequest{Page: 1})
+	require.NoError(t, err)
+	assert.Equal(t, SearchBackendMySQL, backend)
+}
@@ -0,0 +1,32 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMatchPopularQueries 测试按前缀匹配热门搜索词，跳过与前缀相同的和包含屏蔽词的
+func TestMatchPopularQueries(t *testing.T) {
+	viper.Set("search_analytics.blocked_words", []string{" 赌博 ", "SPAM"})
+	defer viper.Set("search_analytics.blocked_words", nil)
+
+	queries := []string{"golang 并发", "go", "golang spam", "golang 赌博网站", "python", "golang 入门", "golang gc"}
+
+	tests := []struct {
+		name   string
+		prefix string
+		size   int
+		want   []string
+	}{
+		{"前缀匹配并跳过屏蔽词", "Golang", 5, []string{"golang 并发", "golang 入门", "golang gc"}},
+		{"限制条数", "go", 2, []string{"golang 并发", "golang 入门"}},
+		{"无匹配", "java", 5, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchPopularQueries(queries, tt.prefix, tt.size))
+		})
+	}
+}
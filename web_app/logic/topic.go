@@ -7,6 +7,7 @@ import (
 	"time"
 	"web_app/dao/mysql"
 	"web_app/dao/redis"
+	"web_app/events"
 	"web_app/models"
 	"web_app/tasks"
 	"web_app/utils"
@@ -14,37 +15,55 @@ import (
 	"go.uber.org/zap"
 )
 
+// ErrTopicLocked 话题已锁定
+var ErrTopicLocked = errors.New("话题已锁定，禁止评论")
+
 // CreateTopic 创建话题
 func CreateTopic(userID int64, req *models.CreateTopicRequest) error {
+	tags, err := utils.NormalizeTags(req.Tags)
+	if err != nil {
+		return err
+	}
+
 	// 生成雪花算法ID
 	topicID := utils.GenerateID()
 
 	// 调用dao层插入话题（显式设置创建时间为当前时间）
 	now := time.Now()
 	topic := &models.Topic{
-		ID:        topicID,
-		UserID:    userID,
-		Title:     req.Title,
-		Content:   req.Content,
-		Category:  req.Category,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:          topicID,
+		UserID:      userID,
+		Title:       req.Title,
+		Content:     req.Content,
+		ContentHTML: utils.RenderMarkdown(req.Content),
+		Category:    req.Category,
+		Tags:        tags,
+		CreatedAt:   now,
+		UpdatedAt:   now,
 	}
 
-	err := mysql.InsertTopic(topic)
+	// 校验附带的投票和附件
+	var poll *models.Poll
+	if req.Poll != nil {
+		if poll, err = buildPoll(topicID, req.Poll, now); err != nil {
+			return err
+		}
+	}
+	attachmentIDs, err := parseAttachmentIDs(userID, req.AttachmentIDs)
 	if err != nil {
+		return err
+	}
+
+	// 话题、投票、附件关联在同一事务中写入
+	if err := mysql.InsertTopicWithRelations(topic, poll, attachmentIDs); err != nil {
 		zap.L().Error("插入话题失败", zap.Error(err))
 		return errors.New("插入话题失败")
 	}
 
-	// 异步清除话题列表缓存
-	go func() {
-		if err := redis.DeleteAllTopicListCache(); err != nil {
-			zap.L().Warn("清除话题列表缓存失败", zap.Error(err))
-		}
-	}()
+	// 清除列表缓存、记录@提及由事件订阅者处理
+	publishEvent(events.TopicCreated{TopicID: topicID, UserID: userID, Content: req.Content, CreatedAt: now})
 
-	// ES同步由Canal+Kafka自动处理，无需手动同步
+	// ES同步由Canal（或事务发件箱）自动处理，无需手动同步
 
 	return nil
 }
@@ -57,7 +76,7 @@ func GetTopics(req *models.GetTopicsRequest) ([]*models.Topic, int64, error) {
 	if err == nil {
 		// 缓存命中
 		zap.L().Debug("话题列表缓存命中", zap.String("cache_key", cacheKey))
-		return topicList, total, nil
+		return formatTopics(topicList, req.Format), total, nil
 	}
 
 	// 2. 缓存未命中，从数据库查询
@@ -74,22 +93,36 @@ func GetTopics(req *models.GetTopicsRequest) ([]*models.Topic, int64, error) {
 		}
 	}()
 
-	return topicList, total, nil
+	return formatTopics(topicList, req.Format), total, nil
+}
+
+// GetTopicByID 获取话题详情（userID为0表示未登录用户，format为内容格式raw/html，为空时两者都返回）
+func GetTopicByID(topicID, userID int64, format string) (*models.Topic, error) {
+	topic, err := getTopicDetail(topicID)
+	if err != nil {
+		return nil, err
+	}
+
+	// formatTopic返回副本，后续附加的投票结果（与当前用户相关）不会进入话题详情缓存
+	result := formatTopic(topic, format)
+	poll, err := GetTopicPoll(topicID, userID)
+	if err != nil {
+		zap.L().Warn("查询话题投票失败", zap.Error(err), zap.Int64("topic_id", topicID))
+		return result, nil
+	}
+	result.Poll = poll
+	return result, nil
 }
 
-// GetTopicByID 获取话题详情
-func GetTopicByID(topicID int64) (*models.Topic, error) {
+// getTopicDetail 获取话题详情（优先读缓存）
+func getTopicDetail(topicID int64) (*models.Topic, error) {
 	// 1. 尝试从Redis缓存获取
 	topic, err := redis.GetTopicDetailCache(topicID)
 	if err == nil {
 		// 缓存命中
 		zap.L().Debug("话题详情缓存命中", zap.Int64("topic_id", topicID))
 		// 即使缓存命中，也要增加浏览数
-		go func() {
-			if err := mysql.UpdateTopicViewCount(topicID); err != nil {
-				zap.L().Warn("更新浏览数失败", zap.Error(err))
-			}
-		}()
+		publishEvent(events.TopicViewed{TopicID: topicID, ViewID: utils.GenerateID()})
 		return topic, nil
 	}
 
@@ -105,19 +138,27 @@ func GetTopicByID(topicID int64) (*models.Topic, error) {
 		return nil, err
 	}
 
-	// 3. 异步写入缓存
+	// 3. 加载附件和@提及（发布后不会变化，随详情一起缓存）
+	attachments, err := mysql.GetTopicAttachments(topicID)
+	if err != nil {
+		zap.L().Warn("查询话题附件失败", zap.Error(err), zap.Int64("topic_id", topicID))
+	}
+	for _, attachment := range attachments {
+		topic.Attachments = append(topic.Attachments, withAttachmentURLs(attachment))
+	}
+	if topic.Mentions, err = parseContentMentions(topic.Content); err != nil {
+		zap.L().Warn("解析话题@提及失败", zap.Error(err), zap.Int64("topic_id", topicID))
+	}
+
+	// 4. 异步写入缓存
 	go func() {
 		if err := redis.CacheTopicDetail(topic); err != nil {
 			zap.L().Warn("缓存话题详情失败", zap.Error(err))
 		}
 	}()
 
-	// 4. 增加浏览数（异步处理，不影响主流程）
-	go func() {
-		if err := mysql.UpdateTopicViewCount(topicID); err != nil {
-			zap.L().Warn("更新浏览数失败", zap.Error(err))
-		}
-	}()
+	// 5. 增加浏览数（由事件订阅者处理，不影响主流程）
+	publishEvent(events.TopicViewed{TopicID: topicID, ViewID: utils.GenerateID()})
 
 	return topic, nil
 }
@@ -159,81 +200,95 @@ func VoteTopic(userID, topicID int64, voteType string) error {
 }
 
 // processVoteLogic 处理具体的投票逻辑（内部函数）
+// 投票记录和话题点赞/点踩数在同一事务中更新
 func processVoteLogic(userID, topicID int64, voteValue int, existingVote *models.Vote) error {
+	event := events.VoteCast{TopicID: topicID, UserID: userID, NewType: voteValue}
 	if existingVote == nil {
 		// 未投票，新增投票
-		voteID := utils.GenerateID()
-		if err := mysql.InsertVote(&models.Vote{
-			ID:       voteID,
-			UserID:   userID,
-			TopicID:  topicID,
-			VoteType: voteValue,
-		}); err != nil {
+		if err := mysql.ApplyVote(utils.GenerateID(), userID, topicID, 0, voteValue); err != nil {
+			zap.L().Error("投票失败", zap.Error(err), zap.Int64("topic_id", topicID))
 			return errors.New("投票失败")
 		}
-
-		// 更新话题计数
-		if voteValue == 1 {
-			if err := mysql.UpdateTopicLikeCount(topicID, 1); err != nil {
-				zap.L().Error("更新点赞数失败", zap.Error(err))
-			}
-		} else {
-			if err := mysql.UpdateTopicDislikeCount(topicID, 1); err != nil {
-				zap.L().Error("更新点踩数失败", zap.Error(err))
-			}
-		}
 	} else if existingVote.VoteType == voteValue {
 		// 已投相同类型的票，取消投票
-		if err := mysql.DeleteVote(userID, topicID); err != nil {
+		event.OldType, event.NewType = existingVote.VoteType, 0
+		if err := mysql.ApplyVote(existingVote.ID, userID, topicID, existingVote.VoteType, 0); err != nil {
+			zap.L().Error("取消投票失败", zap.Error(err), zap.Int64("topic_id", topicID))
 			return errors.New("取消投票失败")
 		}
-
-		// 更新话题计数
-		if voteValue == 1 {
-			if err := mysql.UpdateTopicLikeCount(topicID, -1); err != nil {
-				zap.L().Error("更新点赞数失败", zap.Error(err))
-			}
-		} else {
-			if err := mysql.UpdateTopicDislikeCount(topicID, -1); err != nil {
-				zap.L().Error("更新点踩数失败", zap.Error(err))
-			}
-		}
 	} else {
-		// 已投不同类型的票，更新投票
-		if err := mysql.UpdateVote(userID, topicID, voteValue); err != nil {
+		// 已投不同类型的票，更新投票（减少原来的计数，增加新的）
+		event.OldType = existingVote.VoteType
+		if err := mysql.ApplyVote(existingVote.ID, userID, topicID, existingVote.VoteType, voteValue); err != nil {
+			zap.L().Error("更新投票失败", zap.Error(err), zap.Int64("topic_id", topicID))
 			return errors.New("更新投票失败")
 		}
+	}
 
-		// 更新话题计数（减少原来的，增加新的）
-		if existingVote.VoteType == 1 {
-			if err := mysql.UpdateTopicLikeCount(topicID, -1); err != nil {
-				zap.L().Error("更新点赞数失败", zap.Error(err))
-			}
-			if err := mysql.UpdateTopicDislikeCount(topicID, 1); err != nil {
-				zap.L().Error("更新点踩数失败", zap.Error(err))
-			}
-		} else {
-			if err := mysql.UpdateTopicDislikeCount(topicID, -1); err != nil {
-				zap.L().Error("更新点踩数失败", zap.Error(err))
-			}
-			if err := mysql.UpdateTopicLikeCount(topicID, 1); err != nil {
-				zap.L().Error("更新点赞数失败", zap.Error(err))
-			}
-		}
+	// 清除该话题的详情缓存和列表缓存由事件订阅者处理
+	publishEvent(event)
+
+	return nil
+}
+
+// PinTopic 置顶/取消置顶话题（版主操作）
+func PinTopic(topicID int64, req *models.PinTopicRequest) error {
+	// 1. 验证话题是否存在
+	if _, err := mysql.GetTopicByID(topicID); err != nil {
+		return errors.New("话题不存在")
 	}
 
-	// 异步清除该话题的缓存和列表缓存
-	go func() {
-		// 清除话题详情缓存
-		if err := redis.DeleteTopicCache(topicID); err != nil {
-			zap.L().Warn("清除话题详情缓存失败", zap.Error(err))
-		}
-		// 清除话题列表缓存
-		if err := redis.DeleteAllTopicListCache(); err != nil {
-			zap.L().Warn("清除话题列表缓存失败", zap.Error(err))
-		}
-	}()
+	// 2. 置顶范围默认为分类置顶
+	scope := req.Scope
+	if scope == "" {
+		scope = models.PinScopeCategory
+	}
+
+	// 3. 更新置顶状态
+	if err := mysql.UpdateTopicPinned(topicID, req.Pinned, scope); err != nil {
+		zap.L().Error("更新话题置顶状态失败", zap.Error(err), zap.Int64("topic_id", topicID))
+		return errors.New("更新置顶状态失败")
+	}
 
+	// 4. 置顶影响列表排序，立即清除详情和列表缓存（失败时由事件订阅者重试清除）
+	if err := invalidateTopicCache(topicID); err != nil {
+		zap.L().Warn("清除话题缓存失败", zap.Error(err), zap.Int64("topic_id", topicID))
+	}
+	publishEvent(events.TopicPinned{TopicID: topicID, Pinned: req.Pinned, Scope: scope})
+
+	return nil
+}
+
+// LockTopic 锁定/解锁话题（版主操作）
+func LockTopic(topicID int64, req *models.LockTopicRequest) error {
+	// 1. 验证话题是否存在
+	if _, err := mysql.GetTopicByID(topicID); err != nil {
+		return errors.New("话题不存在")
+	}
+
+	// 2. 更新锁定状态
+	if err := mysql.UpdateTopicLocked(topicID, req.Locked); err != nil {
+		zap.L().Error("更新话题锁定状态失败", zap.Error(err), zap.Int64("topic_id", topicID))
+		return errors.New("更新锁定状态失败")
+	}
+
+	// 3. 立即清除缓存，避免锁定后仍从缓存读到未锁定状态（失败时由事件订阅者重试清除）
+	if err := invalidateTopicCache(topicID); err != nil {
+		zap.L().Warn("清除话题缓存失败", zap.Error(err), zap.Int64("topic_id", topicID))
+	}
+	publishEvent(events.TopicLocked{TopicID: topicID, Locked: req.Locked})
+
+	return nil
+}
+
+// invalidateTopicCache 清除话题详情缓存和列表缓存（任一失败都返回错误，重试时两者都会再清除一次）
+func invalidateTopicCache(topicID int64) error {
+	if err := redis.DeleteTopicCache(topicID); err != nil {
+		return fmt.Errorf("清除话题详情缓存失败: %w", err)
+	}
+	if err := redis.DeleteAllTopicListCache(); err != nil {
+		return fmt.Errorf("清除话题列表缓存失败: %w", err)
+	}
 	return nil
 }
 
@@ -0,0 +1,235 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+	"web_app/dao/mysql"
+	"web_app/dao/storage"
+	"web_app/models"
+	"web_app/utils"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// allowedUploadTypes 默认允许上传的MIME类型及其扩展名（按文件内容嗅探判断，而不是信任扩展名）
+var allowedUploadTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"application/zip": ".zip",
+	"text/plain":      ".txt",
+}
+
+// MaxUploadSize 单个文件的最大字节数（upload.max_size_mb，默认10MB）
+func MaxUploadSize() int64 {
+	sizeMB := viper.GetInt64("upload.max_size_mb")
+	if sizeMB <= 0 {
+		sizeMB = 10
+	}
+	return sizeMB << 20
+}
+
+// UploadAttachment 上传附件：校验大小和类型、按内容去重、生成缩略图
+func UploadAttachment(userID int64, fileHeader *multipart.FileHeader) (*models.Attachment, error) {
+	// 1. 校验大小并读取内容（多读1字节用于判断是否超限）
+	maxSize := MaxUploadSize()
+	if fileHeader.Size > maxSize {
+		return nil, fmt.Errorf("文件大小不能超过%dMB", maxSize>>20)
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, errors.New("读取上传文件失败")
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, errors.New("读取上传文件失败")
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("文件大小不能超过%dMB", maxSize>>20)
+	}
+	if len(data) == 0 {
+		return nil, errors.New("文件不能为空")
+	}
+
+	// 2. 嗅探MIME类型
+	mimeType, ext, err := sniffUploadType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. 内容去重：相同内容直接返回已有附件
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	existing, err := mysql.GetAttachmentBySHA256(hash)
+	if err != nil {
+		zap.L().Error("查询附件失败", zap.Error(err))
+		return nil, errors.New("上传失败")
+	}
+	if existing != nil {
+		return recordUpload(userID, existing)
+	}
+
+	attachment := &models.Attachment{
+		ID:           utils.GenerateID(),
+		UserID:       userID,
+		SHA256:       hash,
+		StorageKey:   fmt.Sprintf("attachments/%s/%s/%s%s", hash[:2], hash[2:4], hash, ext),
+		MimeType:     mimeType,
+		Size:         int64(len(data)),
+		OriginalName: filepath.Base(fileHeader.Filename),
+		CreatedAt:    time.Now(),
+	}
+
+	// 4. 图片：校验可解码并生成缩略图
+	ctx := context.Background()
+	store := storage.GetStorage()
+	if isImageType(mimeType) {
+		info, err := utils.DecodeImageInfo(data)
+		if err != nil {
+			return nil, errors.New("图片文件无效或尺寸过大")
+		}
+		attachment.Width, attachment.Height = info.Width, info.Height
+
+		thumb, thumbType, err := utils.GenerateThumbnail(data, thumbnailSize())
+		if err != nil {
+			zap.L().Warn("生成缩略图失败", zap.Error(err), zap.String("sha256", hash))
+		} else {
+			thumbExt := ".jpg"
+			if thumbType == "image/png" {
+				thumbExt = ".png"
+			}
+			thumbKey := fmt.Sprintf("thumbnails/%s/%s/%s%s", hash[:2], hash[2:4], hash, thumbExt)
+			if err := store.Put(ctx, thumbKey, bytes.NewReader(thumb), int64(len(thumb)), thumbType); err != nil {
+				zap.L().Warn("保存缩略图失败", zap.Error(err), zap.String("key", thumbKey))
+			} else {
+				attachment.ThumbnailKey = thumbKey
+			}
+		}
+	}
+
+	// 5. 保存原文件（key由内容哈希决定，并发上传同一文件时覆盖写入结果一致）
+	if err := store.Put(ctx, attachment.StorageKey, bytes.NewReader(data), attachment.Size, mimeType); err != nil {
+		zap.L().Error("保存附件失败", zap.Error(err), zap.String("key", attachment.StorageKey))
+		return nil, errors.New("上传失败")
+	}
+
+	// 6. 写入附件记录；并发上传同一文件导致唯一键冲突时，以先写入的记录为准
+	if err := mysql.InsertAttachment(attachment); err != nil {
+		if existing, getErr := mysql.GetAttachmentBySHA256(hash); getErr == nil && existing != nil {
+			return recordUpload(userID, existing)
+		}
+		zap.L().Error("写入附件记录失败", zap.Error(err))
+		return nil, errors.New("上传失败")
+	}
+
+	return recordUpload(userID, attachment)
+}
+
+// recordUpload 记录用户上传过该附件（之后才能在话题或评论中引用），返回带访问地址的附件
+func recordUpload(userID int64, attachment *models.Attachment) (*models.Attachment, error) {
+	if err := mysql.RecordAttachmentUpload(userID, attachment.ID); err != nil {
+		zap.L().Error("写入附件上传记录失败", zap.Error(err), zap.Int64("attachment_id", attachment.ID))
+		return nil, errors.New("上传失败")
+	}
+	return withAttachmentURLs(attachment), nil
+}
+
+// sniffUploadType 根据文件内容嗅探MIME类型，返回类型和存储扩展名
+func sniffUploadType(data []byte) (string, string, error) {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return "", "", errors.New("无法识别的文件类型")
+	}
+
+	allowed := allowedUploadTypes
+	if types := viper.GetStringSlice("upload.allowed_types"); len(types) > 0 {
+		allowed = make(map[string]string, len(types))
+		for _, t := range types {
+			if ext, ok := allowedUploadTypes[t]; ok {
+				allowed[t] = ext
+			}
+		}
+	}
+
+	ext, ok := allowed[mediaType]
+	if !ok {
+		return "", "", fmt.Errorf("不支持的文件类型: %s", mediaType)
+	}
+	return mediaType, ext, nil
+}
+
+// isImageType 判断是否为图片类型
+func isImageType(mimeType string) bool {
+	switch mimeType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+// thumbnailSize 缩略图长边像素（upload.thumbnail_size，默认320）
+func thumbnailSize() int {
+	if size := viper.GetInt("upload.thumbnail_size"); size > 0 {
+		return size
+	}
+	return 320
+}
+
+// withAttachmentURLs 填充附件的访问地址
+func withAttachmentURLs(attachment *models.Attachment) *models.Attachment {
+	store := storage.GetStorage()
+	attachment.URL = store.URL(attachment.StorageKey)
+	if attachment.ThumbnailKey != "" {
+		attachment.ThumbnailURL = store.URL(attachment.ThumbnailKey)
+	}
+	return attachment
+}
+
+// ErrAttachmentNotOwned 引用了不存在或不是自己上传的附件
+var ErrAttachmentNotOwned = errors.New("附件不存在或不是您上传的")
+
+// parseAttachmentIDs 解析并校验话题或评论引用的附件ID，只能引用自己上传过的附件
+func parseAttachmentIDs(userID int64, rawIDs []string) ([]int64, error) {
+	if len(rawIDs) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[int64]bool, len(rawIDs))
+	ids := make([]int64, 0, len(rawIDs))
+	for _, raw := range rawIDs {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, ErrAttachmentNotOwned
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	owned, err := mysql.GetUserAttachmentIDs(userID, ids)
+	if err != nil {
+		zap.L().Error("查询附件失败", zap.Error(err))
+		return nil, errors.New("查询附件失败")
+	}
+	if len(owned) != len(ids) {
+		return nil, ErrAttachmentNotOwned
+	}
+	return ids, nil
+}
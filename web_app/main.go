@@ -14,11 +14,15 @@ import (
 	"web_app/dao/elasticsearch"
 	"web_app/dao/mysql"
 	"web_app/dao/redis"
+	"web_app/dao/storage"
+	"web_app/events"
 	"web_app/logger"
+	"web_app/logic"
 	"web_app/routes"
 	"web_app/settings"
 	"web_app/tasks"
 	"web_app/utils"
+	"web_app/workers"
 
 	_ "net/http/pprof" // 性能监控
 
@@ -59,6 +63,11 @@ func main() {
 	}()
 	zap.L().Debug("日志系统初始化成功...")
 
+	// 运维子命令执行完直接退出
+	if runCommand(os.Args[1:]) {
+		return
+	}
+
 	// 初始化雪花算法ID生成器
 	if err := utils.InitSnowflake(); err != nil {
 		fmt.Printf("初始化雪花算法失败, 错误:%v\n", err)
@@ -80,26 +89,54 @@ func main() {
 	}
 	defer redis.Close()
 
-	// 初始化Elasticsearch
+	// 初始化Elasticsearch（只有搜索后端配置为elasticsearch时必须可用，否则搜索降级为MySQL全文检索）
+	logic.InitSearchBackend()
 	if err := elasticsearch.Init(); err != nil {
-		fmt.Printf("初始化Elasticsearch失败, 错误:%v\n", err)
-		return
+		if logic.SearchBackendMode() == logic.SearchBackendElasticsearch {
+			fmt.Printf("初始化Elasticsearch失败, 错误:%v\n", err)
+			return
+		}
+		zap.L().Error("初始化Elasticsearch失败，搜索降级为MySQL全文检索，后台重连成功后启动ES同步任务", zap.Error(err))
+	} else {
+		zap.L().Debug("Elasticsearch初始化成功...")
 	}
 	defer elasticsearch.Close()
-	zap.L().Debug("Elasticsearch初始化成功...")
 
-	// 启动热度排名定时任务
-	tasks.StartHotRankingTask()
+	// 初始化文件存储
+	if err := storage.Init(); err != nil {
+		fmt.Printf("初始化文件存储失败, 错误:%v\n", err)
+		return
+	}
 
-	// 启动Kafka消费者（ES同步）
-	go func() {
-		defer func() {
-			if err := recover(); err != nil {
-				zap.L().Error("Kafka消费者panic", zap.Any("error", err))
-			}
-		}()
-		consumers.StartESConsumer()
-	}()
+	// 初始化事件总线并注册订阅者
+	if err := events.Init(); err != nil {
+		fmt.Printf("初始化事件总线失败, 错误:%v\n", err)
+		return
+	}
+	defer events.Close()
+	logic.RegisterEventHandlers()
+
+	// 启动后台任务（异常退出或panic时自动重启，关机时按分组创建顺序关闭）
+	// 事件总线最先关闭：HTTP服务器停止后不再有新事件，处理完队列中的事件再关闭其他任务
+	logic.InitSearchAnalytics()
+	workers.Default.Group("events").GoCritical("event-bus", events.Run) // 领域事件分发（缓存清理、计数器、@提及），关键任务：停止时队列积满会阻塞发布
+	consumerWorkers := workers.Default.Group("consumers")
+	if mysql.OutboxEnabled() && !consumers.OutboxDirect() {
+		consumerWorkers.Go("outbox-relay", consumers.RunOutboxRelay) // 事务发件箱转发（发布到Kafka，代替Canal）
+	}
+	taskWorkers := workers.Default.Group("tasks")
+	taskWorkers.Go("hot-ranking", tasks.RunHotRankingTask)       // 热度排名定时任务
+	taskWorkers.Go("search-analytics", logic.RunSearchAnalytics) // 搜索日志写入
+	taskWorkers.Go("search-stats", tasks.RunSearchStatsTask)     // 搜索统计持久化定时任务
+	taskWorkers.Go("view-count", tasks.RunViewCountTask)         // 浏览数按批写入MySQL
+	// 写入ES的任务在ES连接后启动；启动时ES不可用则由后台任务重连，变更保留在Kafka或发件箱中，连接后补齐
+	if elasticsearch.Available() {
+		startESWorkers(consumerWorkers, taskWorkers)
+	} else {
+		consumerWorkers.Go("es-connector", runESConnector(func() {
+			startESWorkers(consumerWorkers, taskWorkers)
+		}))
+	}
 
 	// 启动pprof性能监控服务（仅开发环境）
 	if viper.GetString("app.mode") == "dev" {
@@ -139,9 +176,12 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// 关闭服务器
+	// 依次关闭：HTTP服务器 → 事件总线 → 消费者 → 定时任务，之后关闭Redis/MySQL/ES连接（defer）
 	if err := srv.Shutdown(ctx); err != nil {
-		zap.L().Fatal("服务器强制关闭", zap.Error(err))
+		zap.L().Error("服务器强制关闭", zap.Error(err))
+	}
+	if err := workers.Default.Shutdown(ctx); err != nil {
+		zap.L().Error("后台任务未能在超时前退出", zap.Error(err))
 	}
 
 	zap.L().Info("服务器已安全退出")
@@ -0,0 +1,46 @@
+// Package middleware 提供中间件功能
+package middleware
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"web_app/dao/mysql"
+	"web_app/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// getUserRole 查询用户角色（测试时替换）
+var getUserRole = mysql.GetUserRole
+
+// RequireRole 角色校验中间件，必须放在JWTAuth之后
+// 每次请求都从数据库读取角色，撤销权限后立即生效，不需要等待token过期
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get("user_id")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, models.NewErrorResponse(models.CodeUnauthorized, "请先登录"))
+			c.Abort()
+			return
+		}
+
+		role, err := getUserRole(userID.(int64))
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			zap.L().Error("查询用户角色失败", zap.Error(err), zap.Any("user_id", userID))
+			c.JSON(http.StatusInternalServerError, models.NewErrorResponse(models.CodeServerError, "服务器错误"))
+			c.Abort()
+			return
+		}
+
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, models.NewErrorResponse(models.CodeForbidden, "无权操作"))
+		c.Abort()
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"web_app/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRequireRole 测试只有指定角色可以访问，未登录、普通用户和不存在的用户被拒绝
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	roles := map[int64]string{1: models.RoleUser, 2: models.RoleModerator, 3: models.RoleAdmin}
+	orig := getUserRole
+	defer func() { getUserRole = orig }()
+	getUserRole = func(userID int64) (string, error) {
+		if userID == 4 {
+			return "", errors.New("连接失败")
+		}
+		role, ok := roles[userID]
+		if !ok {
+			return "", sql.ErrNoRows
+		}
+		return role, nil
+	}
+
+	tests := []struct {
+		name   string
+		userID int64 // 0表示未登录
+		want   int
+	}{
+		{"未登录", 0, http.StatusUnauthorized},
+		{"普通用户", 1, http.StatusForbidden},
+		{"版主", 2, http.StatusOK},
+		{"管理员", 3, http.StatusOK},
+		{"查询失败", 4, http.StatusInternalServerError},
+		{"用户不存在", 5, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tt.userID != 0 {
+					c.Set("user_id", tt.userID)
+				}
+			})
+			r.POST("/pin", RequireRole(models.RoleModerator, models.RoleAdmin), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/pin", nil))
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+// Package models 定义数据模型
+package models
+
+import (
+	"time"
+)
+
+// Attachment 附件模型（按内容SHA-256去重，同一文件只存一份）
+type Attachment struct {
+	ID           int64     `json:"id,string" db:"id"`                // 附件ID
+	UserID       int64     `json:"user_id,string" db:"user_id"`      // 首次上传的用户ID
+	SHA256       string    `json:"sha256" db:"sha256"`               // 内容哈希
+	StorageKey   string    `json:"-" db:"storage_key"`               // 存储key（不返回给前端）
+	ThumbnailKey string    `json:"-" db:"thumbnail_key"`             // 缩略图存储key
+	MimeType     string    `json:"mime_type" db:"mime_type"`         // MIME类型
+	Size         int64     `json:"size" db:"size"`                   // 文件大小（字节）
+	Width        int       `json:"width,omitempty" db:"width"`       // 图片宽度
+	Height       int       `json:"height,omitempty" db:"height"`     // 图片高度
+	OriginalName string    `json:"original_name" db:"original_name"` // 原始文件名
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`       // 创建时间
+	URL          string    `json:"url" db:"-"`                       // 访问地址
+	ThumbnailURL string    `json:"thumbnail_url,omitempty" db:"-"`   // 缩略图访问地址
+}
+
+// TableName 指定表名
+func (Attachment) TableName() string {
+	return "attachments"
+}
@@ -7,20 +7,24 @@ import (
 
 // Comment 评论模型
 type Comment struct {
-	ID        int64     `json:"id,string" db:"id"`               // 评论ID（JSON序列化为字符串以避免JavaScript精度丢失）
-	TopicID   int64     `json:"topic_id,string" db:"topic_id"`   // 话题ID
-	UserID    int64     `json:"user_id,string" db:"user_id"`     // 用户ID
-	Username  string    `json:"username" db:"username"`          // 用户名（从users表JOIN）
-	Content   string    `json:"content" db:"content"`            // 评论内容
-	ParentID  *int64    `json:"parent_id,string" db:"parent_id"` // 父评论ID（用于回复，可为空）
-	CreatedAt time.Time `json:"created_at" db:"created_at"`      // 创建时间
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`      // 更新时间
+	ID          int64         `json:"id,string" db:"id"`                        // 评论ID（JSON序列化为字符串以避免JavaScript精度丢失）
+	TopicID     int64         `json:"topic_id,string" db:"topic_id"`            // 话题ID
+	UserID      int64         `json:"user_id,string" db:"user_id"`              // 用户ID
+	Username    string        `json:"username" db:"username"`                   // 用户名（从users表JOIN）
+	Content     string        `json:"content,omitempty" db:"content"`           // 评论内容（Markdown原文）
+	ContentHTML string        `json:"content_html,omitempty" db:"content_html"` // 渲染并过滤后的HTML
+	ParentID    *int64        `json:"parent_id,string" db:"parent_id"`          // 父评论ID（用于回复，可为空）
+	CreatedAt   time.Time     `json:"created_at" db:"created_at"`               // 创建时间
+	UpdatedAt   time.Time     `json:"updated_at" db:"updated_at"`               // 更新时间
+	Mentions    []MentionSpan `json:"mentions,omitempty" db:"-"`                // 内容中的@提及
+	Attachments []*Attachment `json:"attachments,omitempty" db:"-"`             // 附件列表
 }
 
 // CreateCommentRequest 创建评论请求参数
 type CreateCommentRequest struct {
-	Content  string `json:"content" binding:"required,min=1,max=1000"` // 评论内容：1-1000个字符
-	ParentID *int64 `json:"parent_id,string,omitempty"`                // 父评论ID（可选，用于回复）
+	Content       string   `json:"content" binding:"required,min=1,max=1000"` // 评论内容：1-1000个字符
+	ParentID      *int64   `json:"parent_id,string,omitempty"`                // 父评论ID（可选，用于回复）
+	AttachmentIDs []string `json:"attachment_ids" binding:"omitempty,max=9"`  // 附件ID（先通过上传接口获取，最多9个）
 }
 
 // CommentListResponse 评论列表响应
@@ -35,11 +39,49 @@ type CommentListResponse struct {
 
 // GetCommentsRequest 获取评论列表请求参数
 type GetCommentsRequest struct {
-	Page     int `form:"page,default=1"`       // 页码，默认第1页
-	PageSize int `form:"page_size,default=20"` // 每页数量，默认20条
+	Page     int    `form:"page,default=1"`                            // 页码，默认第1页
+	PageSize int    `form:"page_size,default=20"`                      // 每页数量，默认20条
+	Format   string `form:"format" binding:"omitempty,oneof=raw html"` // 内容格式：raw=仅原文，html=仅HTML，默认两者都返回
 }
 
 // TableName 指定表名
 func (Comment) TableName() string {
 	return "comments"
 }
+
+// SearchCommentsRequest 评论搜索请求参数
+type SearchCommentsRequest struct {
+	Keyword  string `form:"keyword" binding:"required"` // 搜索关键词
+	TopicID  string `form:"topic_id"`                   // 只搜索指定话题下的评论（可选）
+	Page     int    `form:"page"`                       // 页码
+	PageSize int    `form:"page_size"`                  // 每页数量
+}
+
+// CommentSearchResult 评论搜索结果条目
+type CommentSearchResult struct {
+	ID         int64     `json:"id,string" db:"id"`               // 评论ID
+	TopicID    int64     `json:"topic_id,string" db:"topic_id"`   // 话题ID
+	TopicTitle string    `json:"topic_title" db:"topic_title"`    // 话题标题
+	UserID     int64     `json:"user_id,string" db:"user_id"`     // 评论者ID
+	Username   string    `json:"username" db:"username"`          // 评论者用户名
+	ParentID   *int64    `json:"parent_id,string" db:"parent_id"` // 父评论ID
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`      // 创建时间
+	Snippet    string    `json:"snippet" db:"-"`                  // 内容摘要（已HTML转义，关键词以<em>标记）
+}
+
+// CommentSearchResponse 评论搜索响应
+type CommentSearchResponse struct {
+	Total      int64                  `json:"total"`       // 总数
+	Page       int                    `json:"page"`        // 当前页
+	PageSize   int                    `json:"page_size"`   // 每页数量
+	TotalPages int                    `json:"total_pages"` // 总页数
+	HasMore    bool                   `json:"has_more"`    // 是否有下一页
+	Comments   []*CommentSearchResult `json:"comments"`    // 搜索结果列表
+	Took       int64                  `json:"took"`        // 搜索耗时(毫秒)
+}
+
+// CommentMatch 话题通过评论命中时，最相关的那条评论
+type CommentMatch struct {
+	CommentID int64  `json:"comment_id,string"` // 评论ID
+	Snippet   string `json:"snippet"`           // 评论摘要（已HTML转义，关键词以<em>标记）
+}
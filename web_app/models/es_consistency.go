@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ESConsistencyReport MySQL与ES话题索引的一致性检查结果
+// 各类差异的ID只保留前若干个作为样例，数量字段为完整计数
+type ESConsistencyReport struct {
+	MySQLScanned int64     `json:"mysql_scanned"` // 扫描的MySQL话题数
+	ESScanned    int64     `json:"es_scanned"`    // 扫描的ES文档数
+	Missing      int       `json:"missing"`       // MySQL中存在、ES中缺失的话题数
+	Extra        int       `json:"extra"`         // ES中存在、MySQL中已不存在的文档数
+	Stale        int       `json:"stale"`         // 两边都存在但索引字段不一致的话题数
+	MissingIDs   []string  `json:"missing_ids"`   // 缺失的话题ID（样例）
+	ExtraIDs     []string  `json:"extra_ids"`     // 多余的文档ID（样例）
+	StaleIDs     []string  `json:"stale_ids"`     // 过期的话题ID（样例）
+	Repaired     int       `json:"repaired"`      // 修复成功的文档数（未开启修复时为0）
+	Superseded   int       `json:"superseded"`    // 修复时已有更新的变更写入而跳过的文档数
+	StartedAt    time.Time `json:"started_at"`    // 开始时间
+	FinishedAt   time.Time `json:"finished_at"`   // 结束时间
+}
+
+// Drift 差异总数
+func (r *ESConsistencyReport) Drift() int {
+	return r.Missing + r.Extra + r.Stale
+}
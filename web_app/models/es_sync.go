@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ES同步任务状态
+const (
+	ESSyncStateRunning     = "running"     // 同步中
+	ESSyncStateCompleted   = "completed"   // 已完成
+	ESSyncStateFailed      = "failed"      // 失败（可从断点续传）
+	ESSyncStateInterrupted = "interrupted" // 进程退出导致中断（可从断点续传）
+)
+
+// ES同步阶段（先同步话题，再同步评论）
+const (
+	ESSyncPhaseTopics   = "topics"
+	ESSyncPhaseComments = "comments"
+)
+
+// ESSyncStatus 全量同步任务的进度（保存在Redis中，用于断点续传和进度查询）
+type ESSyncStatus struct {
+	State      string     `json:"state"`                 // 任务状态
+	Phase      string     `json:"phase"`                 // 当前阶段：topics/comments
+	Total      int64      `json:"total"`                 // 开始时需要同步的话题和评论总数
+	Processed  int64      `json:"processed"`             // 已处理的文档数
+	Indexed    int64      `json:"indexed"`               // 写入成功的文档数
+	Failed     int64      `json:"failed"`                // 重试后仍写入失败的文档数
+	LastID     int64      `json:"last_id"`               // 断点：当前阶段已提交到ES的最大ID
+	Errors     []string   `json:"errors"`                // 最近的错误信息
+	StartedAt  time.Time  `json:"started_at"`            // 开始时间（续传时为续传时间）
+	UpdatedAt  time.Time  `json:"updated_at"`            // 最近一次保存断点的时间
+	FinishedAt *time.Time `json:"finished_at,omitempty"` // 结束时间
+	ETASeconds int64      `json:"eta_seconds"`           // 预计剩余秒数（仅同步中有效）
+}
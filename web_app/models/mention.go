@@ -0,0 +1,46 @@
+// Package models 定义数据模型
+package models
+
+import (
+	"time"
+)
+
+// Mention 提及记录（被@用户的收件箱条目）
+type Mention struct {
+	ID           int64     `json:"id,string" db:"id"`                           // 提及ID
+	UserID       int64     `json:"user_id,string" db:"user_id"`                 // 被@的用户ID
+	FromUserID   int64     `json:"from_user_id,string" db:"from_user_id"`       // 发起@的用户ID
+	FromUsername string    `json:"from_username" db:"from_username"`            // 发起@的用户名（从users表JOIN）
+	TopicID      int64     `json:"topic_id,string" db:"topic_id"`               // 所在话题ID
+	TopicTitle   string    `json:"topic_title" db:"topic_title"`                // 所在话题标题（从topics表JOIN）
+	CommentID    *int64    `json:"comment_id,string,omitempty" db:"comment_id"` // 所在评论ID（在话题正文中@时为空）
+	Excerpt      string    `json:"excerpt" db:"excerpt"`                        // 内容摘要（JOIN原文后截断）
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`                  // 创建时间
+}
+
+// MentionSpan 内容中@到的用户（前端在渲染后的HTML文本节点中按用户名高亮）
+type MentionSpan struct {
+	Username string `json:"username"`       // 被@的用户名
+	UserID   int64  `json:"user_id,string"` // 被@的用户ID
+}
+
+// GetMentionsRequest 获取提及列表请求参数
+type GetMentionsRequest struct {
+	Page     int `form:"page,default=1"`       // 页码，默认第1页
+	PageSize int `form:"page_size,default=20"` // 每页数量，默认20条
+}
+
+// MentionListResponse 提及列表响应
+type MentionListResponse struct {
+	Total      int64      `json:"total"`       // 总数
+	Page       int        `json:"page"`        // 当前页
+	PageSize   int        `json:"page_size"`   // 每页数量
+	TotalPages int        `json:"total_pages"` // 总页数
+	HasMore    bool       `json:"has_more"`    // 是否有下一页
+	Mentions   []*Mention `json:"mentions"`    // 提及列表
+}
+
+// TableName 指定表名
+func (Mention) TableName() string {
+	return "mentions"
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// OutboxEvent 事务发件箱记录（与业务写入在同一事务中写入，由转发器发布）
+type OutboxEvent struct {
+	ID          int64      `db:"id"`           // 自增ID（转发顺序）
+	TableName   string     `db:"table_name"`   // 变更的表
+	RowID       int64      `db:"row_id"`       // 变更行的主键
+	Operation   string     `db:"operation"`    // INSERT/UPDATE/DELETE
+	Payload     string     `db:"payload"`      // Canal flatMessage格式的消息体
+	CreatedAt   time.Time  `db:"created_at"`   // 写入时间
+	PublishedAt *time.Time `db:"published_at"` // 发布时间，为空表示未发布
+}
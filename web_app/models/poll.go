@@ -0,0 +1,61 @@
+// Package models 定义数据模型
+package models
+
+import (
+	"time"
+)
+
+// Poll 话题投票（问卷）模型
+type Poll struct {
+	ID             int64         `json:"id,string" db:"id"`              // 投票ID
+	TopicID        int64         `json:"topic_id,string" db:"topic_id"`  // 所属话题ID
+	MultiChoice    bool          `json:"multi_choice" db:"multi_choice"` // 是否多选
+	CloseAt        *time.Time    `json:"close_at" db:"close_at"`         // 截止时间（为空表示不截止）
+	CreatedAt      time.Time     `json:"created_at" db:"created_at"`     // 创建时间
+	Options        []*PollOption `json:"options" db:"-"`                 // 选项列表
+	TotalVotes     int64         `json:"total_votes" db:"-"`             // 总票数（结果不可见时为0）
+	Closed         bool          `json:"closed" db:"-"`                  // 是否已截止
+	Voted          bool          `json:"voted" db:"-"`                   // 当前用户是否已投票
+	ResultsVisible bool          `json:"results_visible" db:"-"`         // 结果是否可见（已投票或已截止）
+	MyOptionIDs    []string      `json:"my_option_ids,omitempty" db:"-"` // 当前用户选择的选项ID
+}
+
+// PollOption 投票选项模型
+type PollOption struct {
+	ID        int64  `json:"id,string" db:"id"`           // 选项ID
+	PollID    int64  `json:"poll_id,string" db:"poll_id"` // 投票ID
+	Content   string `json:"content" db:"content"`        // 选项内容
+	Position  int    `json:"position" db:"position"`      // 选项顺序
+	VoteCount int64  `json:"vote_count" db:"vote_count"`  // 得票数（结果不可见时为0）
+}
+
+// PollVote 投票记录模型（多选时每个选项一条记录）
+type PollVote struct {
+	ID        int64     `json:"id,string" db:"id"`               // 记录ID
+	PollID    int64     `json:"poll_id,string" db:"poll_id"`     // 投票ID
+	OptionID  int64     `json:"option_id,string" db:"option_id"` // 选项ID
+	UserID    int64     `json:"user_id,string" db:"user_id"`     // 用户ID
+	CreatedAt time.Time `json:"created_at" db:"created_at"`      // 创建时间
+}
+
+// CreatePollRequest 创建投票请求参数（随话题一起创建）
+type CreatePollRequest struct {
+	Options     []string   `json:"options" binding:"required,min=2,max=10,dive,min=1,max=100"` // 选项：2-10个，每个1-100个字符
+	MultiChoice bool       `json:"multi_choice"`                                               // 是否多选
+	CloseAt     *time.Time `json:"close_at"`                                                   // 截止时间（可选）
+}
+
+// PollVoteRequest 参与投票请求参数
+type PollVoteRequest struct {
+	OptionIDs []string `json:"option_ids" binding:"required,min=1,max=10"` // 选项ID（字符串形式，避免JavaScript精度丢失）
+}
+
+// IsClosed 判断投票是否已截止
+func (p *Poll) IsClosed(now time.Time) bool {
+	return p.CloseAt != nil && !now.Before(*p.CloseAt)
+}
+
+// TableName 指定表名
+func (Poll) TableName() string {
+	return "polls"
+}
@@ -17,6 +17,7 @@ const (
 	CodeNotFound        = 1004 // 资源不存在
 	CodeAlreadyExists   = 1005 // 资源已存在
 	CodeTooManyRequests = 1006 // 请求过于频繁
+	CodeForbidden       = 1007 // 无权操作
 )
 
 // NewSuccessResponse 创建成功响应
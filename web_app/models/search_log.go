@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// SearchLog 一次搜索的记录（只记录带关键词的第一页搜索，翻页不重复记录）
+type SearchLog struct {
+	ID          int64     `json:"id,string" db:"id"`              // 日志ID
+	Keyword     string    `json:"keyword" db:"keyword"`           // 规范化后的搜索词
+	Filters     string    `json:"filters" db:"filters"`           // 筛选条件（JSON）
+	ResultCount int64     `json:"result_count" db:"result_count"` // 结果总数
+	LatencyMs   int64     `json:"latency_ms" db:"latency_ms"`     // 搜索耗时（毫秒）
+	Relaxed     bool      `json:"relaxed" db:"relaxed"`           // 是否为放宽匹配后的结果
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`     // 搜索时间
+}
+
+// SearchLogFilters 搜索日志中记录的筛选条件（未使用的条件不记录）
+type SearchLogFilters struct {
+	Category     string   `json:"category,omitempty"`
+	Author       string   `json:"author,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	CreatedFrom  string   `json:"created_from,omitempty"`
+	CreatedTo    string   `json:"created_to,omitempty"`
+	MinLikes     int      `json:"min_likes,omitempty"`
+	MinComments  int      `json:"min_comments,omitempty"`
+	SortBy       string   `json:"sort_by,omitempty"`
+	WithComments bool     `json:"with_comments,omitempty"`
+}
+
+// SearchQueryStat 搜索词统计
+type SearchQueryStat struct {
+	Keyword string `json:"keyword"` // 搜索词
+	Count   int64  `json:"count"`   // 次数
+}
+
+// SearchHourlyStat 每小时的搜索统计
+type SearchHourlyStat struct {
+	Hour         time.Time `json:"hour"`           // 统计小时（整点）
+	Total        int64     `json:"total"`          // 搜索次数
+	ZeroResults  int64     `json:"zero_results"`   // 零结果次数
+	ZeroRate     float64   `json:"zero_rate"`      // 零结果率
+	AvgLatencyMs float64   `json:"avg_latency_ms"` // 平均耗时（毫秒）
+}
+
+// SearchQueryStatsRequest 搜索词统计查询参数
+type SearchQueryStatsRequest struct {
+	Hours int `form:"hours"` // 统计最近多少小时（1-168，默认24）
+	Limit int `form:"limit"` // 返回条数（1-100，默认20）
+}
@@ -2,38 +2,104 @@
 package models
 
 import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
 	"time"
 )
 
 // Topic 话题模型
 type Topic struct {
-	ID           int64     `json:"id,string" db:"id"`                // 话题ID（JSON序列化为字符串以避免JavaScript精度丢失）
-	UserID       int64     `json:"user_id,string" db:"user_id"`      // 发布者ID
-	Username     string    `json:"username" db:"username"`           // 发布者用户名（从users表JOIN）
-	Title        string    `json:"title" db:"title"`                 // 话题标题
-	Content      string    `json:"content" db:"content"`             // 话题内容
-	Category     string    `json:"category" db:"category"`           // 分类（tech/design/discuss/share/product）
-	LikeCount    int       `json:"like_count" db:"like_count"`       // 点赞数
-	DislikeCount int       `json:"dislike_count" db:"dislike_count"` // 点踩数
-	CommentCount int       `json:"comment_count" db:"comment_count"` // 评论数
-	ViewCount    int       `json:"view_count" db:"view_count"`       // 浏览数
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`       // 创建时间
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`       // 更新时间
+	ID           int64         `json:"id,string" db:"id"`                        // 话题ID（JSON序列化为字符串以避免JavaScript精度丢失）
+	UserID       int64         `json:"user_id,string" db:"user_id"`              // 发布者ID
+	Username     string        `json:"username" db:"username"`                   // 发布者用户名（从users表JOIN）
+	Title        string        `json:"title" db:"title"`                         // 话题标题
+	Content      string        `json:"content,omitempty" db:"content"`           // 话题内容（Markdown原文）
+	ContentHTML  string        `json:"content_html,omitempty" db:"content_html"` // 渲染并过滤后的HTML
+	Category     string        `json:"category" db:"category"`                   // 分类（tech/design/discuss/share/product）
+	Tags         TagList       `json:"tags" db:"tags"`                           // 标签
+	LikeCount    int           `json:"like_count" db:"like_count"`               // 点赞数
+	DislikeCount int           `json:"dislike_count" db:"dislike_count"`         // 点踩数
+	CommentCount int           `json:"comment_count" db:"comment_count"`         // 评论数
+	ViewCount    int           `json:"view_count" db:"view_count"`               // 浏览数
+	IsPinned     bool          `json:"is_pinned" db:"is_pinned"`                 // 是否置顶
+	PinScope     string        `json:"pin_scope" db:"pin_scope"`                 // 置顶范围：global/category
+	IsLocked     bool          `json:"is_locked" db:"is_locked"`                 // 是否锁定（锁定后禁止评论）
+	CreatedAt    time.Time     `json:"created_at" db:"created_at"`               // 创建时间
+	UpdatedAt    time.Time     `json:"updated_at" db:"updated_at"`               // 更新时间
+	Poll         *Poll         `json:"poll,omitempty" db:"-"`                    // 附带的投票（仅详情接口返回）
+	Attachments  []*Attachment `json:"attachments,omitempty" db:"-"`             // 附件列表（仅详情接口返回）
+	Mentions     []MentionSpan `json:"mentions,omitempty" db:"-"`                // 内容中的@提及（仅详情接口返回）
+}
+
+// TagList 话题标签（数据库中以逗号分隔存储）
+type TagList []string
+
+// ParseTagList 解析逗号分隔的标签字符串
+func ParseTagList(s string) TagList {
+	tags := TagList{}
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Value 实现 driver.Valuer，写入数据库时拼接为逗号分隔的字符串
+func (t TagList) Value() (driver.Value, error) {
+	return strings.Join(t, ","), nil
+}
+
+// Scan 实现 sql.Scanner，从逗号分隔的字符串解析
+func (t *TagList) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*t = TagList{}
+	case []byte:
+		*t = ParseTagList(string(v))
+	case string:
+		*t = ParseTagList(v)
+	default:
+		return fmt.Errorf("无法将 %T 解析为标签", src)
+	}
+	return nil
 }
 
 // CreateTopicRequest 创建话题请求参数
 type CreateTopicRequest struct {
-	Title    string `json:"title" binding:"required,min=5,max=100"`                              // 标题：5-100个字符
-	Content  string `json:"content" binding:"required,min=10"`                                   // 内容：至少10个字符
-	Category string `json:"category" binding:"required,oneof=tech design discuss share product"` // 分类
+	Title         string             `json:"title" binding:"required,min=5,max=100"`                              // 标题：5-100个字符
+	Content       string             `json:"content" binding:"required,min=10"`                                   // 内容：至少10个字符
+	Category      string             `json:"category" binding:"required,oneof=tech design discuss share product"` // 分类
+	Poll          *CreatePollRequest `json:"poll,omitempty"`                                                      // 附带投票（可选）
+	AttachmentIDs []string           `json:"attachment_ids" binding:"omitempty,max=9"`                            // 附件ID（先通过上传接口获取，最多9个）
+	Tags          []string           `json:"tags" binding:"omitempty,max=5"`                                      // 标签（可选，最多5个）
 }
 
 // GetTopicsRequest 获取话题列表请求参数
 type GetTopicsRequest struct {
-	Page     int    `form:"page,default=1"`       // 页码，默认第1页
-	PageSize int    `form:"page_size,default=10"` // 每页数量，默认10条
-	Sort     string `form:"sort,default=hot"`     // 排序方式：hot/new/like
-	Category string `form:"category"`             // 分类筛选（可选）
+	Page     int    `form:"page,default=1"`                            // 页码，默认第1页
+	PageSize int    `form:"page_size,default=10"`                      // 每页数量，默认10条
+	Sort     string `form:"sort,default=hot"`                          // 排序方式：hot/new/like
+	Category string `form:"category"`                                  // 分类筛选（可选）
+	Format   string `form:"format" binding:"omitempty,oneof=raw html"` // 内容格式：raw=仅原文，html=仅HTML，默认两者都返回
+}
+
+// 置顶范围常量
+const (
+	PinScopeGlobal   = "global"   // 全站置顶：出现在不带分类筛选的列表顶部
+	PinScopeCategory = "category" // 分类置顶：仅在所属分类的列表顶部
+)
+
+// PinTopicRequest 置顶/取消置顶请求参数
+type PinTopicRequest struct {
+	Pinned bool   `json:"pinned"`                                          // true=置顶，false=取消置顶
+	Scope  string `json:"scope" binding:"omitempty,oneof=global category"` // 置顶范围，默认category
+}
+
+// LockTopicRequest 锁定/解锁请求参数
+type LockTopicRequest struct {
+	Locked bool `json:"locked"` // true=锁定，false=解锁
 }
 
 // TopicListResponse 话题列表响应
@@ -48,13 +114,67 @@ type TopicListResponse struct {
 
 // SearchResponse 搜索响应
 type SearchResponse struct {
-	Total      int64    `json:"total"`       // 总数
-	Page       int      `json:"page"`        // 当前页
-	PageSize   int      `json:"page_size"`   // 每页数量
-	TotalPages int      `json:"total_pages"` // 总页数
-	HasMore    bool     `json:"has_more"`    // 是否有下一页
-	Topics     []*Topic `json:"topics"`      // 话题列表
-	Took       int64    `json:"took"`        // 搜索耗时(毫秒)
+	Total      int64           `json:"total"`       // 总数
+	Page       int             `json:"page"`        // 当前页
+	PageSize   int             `json:"page_size"`   // 每页数量
+	TotalPages int             `json:"total_pages"` // 总页数
+	HasMore    bool            `json:"has_more"`    // 是否有下一页
+	Topics     []*SearchResult `json:"topics"`      // 搜索结果列表
+	Facets     *SearchFacets   `json:"facets"`      // 当前筛选条件下的聚合统计
+	Suggestion string          `json:"suggestion"`  // 零结果时的拼写纠正建议（"你是不是要找"，没有时为空）
+	Relaxed    bool            `json:"relaxed"`     // 按原条件没有结果，当前结果是放宽匹配后得到的
+	Took       int64           `json:"took"`        // 搜索耗时(毫秒)
+	Backend    string          `json:"backend"`     // 实际使用的搜索后端：elasticsearch，或降级时的mysql（不支持高亮、聚合和拼写纠正）
+}
+
+// SearchTopicsRequest 搜索请求参数
+type SearchTopicsRequest struct {
+	Keyword      string   `form:"keyword"`                                // 搜索关键词
+	Category     string   `form:"category"`                               // 分类筛选
+	Author       string   `form:"author"`                                 // 作者用户名
+	Tags         []string `form:"tags"`                                   // 标签（可重复传参或逗号分隔，需同时包含）
+	CreatedFrom  string   `form:"created_from"`                           // 发布时间下限：yyyy-MM-dd 或 yyyy-MM-dd HH:mm:ss
+	CreatedTo    string   `form:"created_to"`                             // 发布时间上限（含），只有日期时包含当天
+	MinLikes     int      `form:"min_likes" binding:"omitempty,min=0"`    // 最少点赞数
+	MinComments  int      `form:"min_comments" binding:"omitempty,min=0"` // 最少评论数
+	Page         int      `form:"page"`                                   // 页码
+	PageSize     int      `form:"page_size"`                              // 每页数量
+	SortBy       string   `form:"sort_by"`                                // 排序方式
+	WithComments bool     `form:"with_comments"`                          // 综合模式：同时返回通过评论命中的话题
+}
+
+// SuggestResponse 搜索建议响应
+type SuggestResponse struct {
+	Queries []string           `json:"queries"` // 热门搜索词（以输入开头，按近7天搜索次数排序）
+	Topics  []*TopicSuggestion `json:"topics"`  // 标题补全（容忍拼写误差，按互动量排序）
+}
+
+// TopicSuggestion 标题补全条目
+type TopicSuggestion struct {
+	ID       int64  `json:"id,string"` // 话题ID
+	Title    string `json:"title"`     // 标题
+	Category string `json:"category"`  // 分类
+}
+
+// SearchFacets 搜索聚合统计
+type SearchFacets struct {
+	Categories []FacetBucket `json:"categories"` // 分类分布
+	Tags       []FacetBucket `json:"tags"`       // 标签分布（前20个）
+	Daily      []FacetBucket `json:"daily"`      // 按天发布数（key为yyyy-MM-dd，只返回有话题的日期）
+}
+
+// FacetBucket 聚合桶
+type FacetBucket struct {
+	Key   string `json:"key"`   // 分类/标签/日期
+	Count int64  `json:"count"` // 话题数
+}
+
+// SearchResult 搜索结果条目（不含完整内容，只返回高亮标题和内容摘要）
+type SearchResult struct {
+	*Topic
+	HighlightTitle string        `json:"highlight_title"`           // 高亮标题（已HTML转义，关键词以<em>标记）
+	Snippet        string        `json:"snippet"`                   // 内容摘要（已HTML转义，关键词以<em>标记）
+	MatchedComment *CommentMatch `json:"matched_comment,omitempty"` // 综合模式下命中的评论
 }
 
 // TableName 指定表名
@@ -5,12 +5,20 @@ import (
 	"time"
 )
 
+// 用户角色
+const (
+	RoleUser      = "user"      // 普通用户
+	RoleModerator = "moderator" // 版主：可置顶、锁定话题
+	RoleAdmin     = "admin"     // 管理员：拥有版主权限，并可执行索引运维、查看搜索分析
+)
+
 // User 用户模型
 type User struct {
 	ID        int64     `json:"id,string" db:"id"`          // 用户ID（JSON序列化为字符串以避免JavaScript精度丢失）
 	Username  string    `json:"username" db:"username"`     // 用户名
 	Email     string    `json:"email" db:"email"`           // 邮箱
 	Password  string    `json:"-" db:"password"`            // 密码（不返回给前端）
+	Role      string    `json:"role" db:"role"`             // 角色：user/moderator/admin
 	CreatedAt time.Time `json:"created_at" db:"created_at"` // 创建时间
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"` // 更新时间
 }
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// 后台任务状态
+const (
+	WorkerStateRunning    = "running"    // 运行中
+	WorkerStateRestarting = "restarting" // 异常退出，等待重启
+	WorkerStateStopped    = "stopped"    // 已停止（正常结束或关机）
+)
+
+// WorkerStatus 后台任务的运行状态
+type WorkerStatus struct {
+	Name      string     `json:"name"`                 // 任务名称
+	Group     string     `json:"group"`                // 所属分组（按分组顺序关闭）
+	Critical  bool       `json:"critical"`             // 是否关键任务（关键任务不在运行时服务未就绪）
+	State     string     `json:"state"`                // 状态：running/restarting/stopped
+	Restarts  int        `json:"restarts"`             // 异常重启次数
+	LastError string     `json:"last_error,omitempty"` // 最近一次异常
+	StartedAt *time.Time `json:"started_at,omitempty"` // 最近一次启动时间
+}
+
+// ReadinessResponse 就绪检查响应
+type ReadinessResponse struct {
+	Ready   bool            `json:"ready"`   // 所有关键后台任务都在运行且未在关机
+	Workers []*WorkerStatus `json:"workers"` // 各后台任务状态
+}
@@ -4,12 +4,15 @@ package routes
 import (
 	"net/http"
 	"web_app/controllers"
+	"web_app/dao/storage"
 	_ "web_app/docs" // Swagger文档
 	"web_app/logger"
 	"web_app/middleware"
+	"web_app/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/viper"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -37,18 +40,35 @@ func SetupRouter() *gin.Engine {
 		})
 	})
 
+	// 就绪检查：关键后台任务运行中才返回200，关机时返回503；响应中包含所有后台任务的状态
+	healthCtrl := controllers.NewHealthController()
+	r.GET("/ready", healthCtrl.Ready)
+
 	// ========== Prometheus指标端点 ==========
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	// ========== Swagger API文档 ==========
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// ========== 本地上传文件 ==========
+	// 使用S3后端时由对象存储直接提供访问；nosniff防止浏览器把上传内容当作HTML执行
+	if viper.GetString("upload.backend") != "s3" {
+		uploads := r.Group("/uploads")
+		uploads.Use(func(c *gin.Context) {
+			c.Header("X-Content-Type-Options", "nosniff")
+			c.Next()
+		})
+		uploads.Static("/", storage.LocalDir())
+	}
+
 	// ========== 初始化控制器 ==========
 	userCtrl := controllers.NewUserController()
 	topicCtrl := controllers.NewTopicController()
 	commentCtrl := controllers.NewCommentController()
 	searchCtrl := controllers.NewSearchController()
 	adminCtrl := controllers.NewAdminController()
+	pollCtrl := controllers.NewPollController()
+	uploadCtrl := controllers.NewUploadController()
 
 	// ========== API 路由组 ==========
 	api := r.Group("/api")
@@ -61,19 +81,18 @@ func SetupRouter() *gin.Engine {
 			v1.POST("/login", userCtrl.Login)       // 用户登录
 
 			// 话题列表（无需登录也可以查看）
-			v1.GET("/topics", topicCtrl.GetTopics)                  // 获取话题列表
-			v1.GET("/topics/hot", topicCtrl.GetHotTopics)           // 获取热门话题
-			v1.GET("/topics/:id", topicCtrl.GetTopicByID)           // 获取话题详情
-			v1.GET("/topics/:id/comments", commentCtrl.GetComments) // 获取话题评论列表
+			v1.GET("/topics", topicCtrl.GetTopics)                                      // 获取话题列表
+			v1.GET("/topics/hot", topicCtrl.GetHotTopics)                               // 获取热门话题
+			v1.GET("/topics/:id", middleware.OptionalJWTAuth(), topicCtrl.GetTopicByID) // 获取话题详情（登录后返回个人投票状态）
+			v1.GET("/topics/:id/comments", commentCtrl.GetComments)                     // 获取话题评论列表
+			v1.GET("/topics/:id/related", topicCtrl.GetRelatedTopics)                   // 获取相关话题
 
 			// 搜索相关（无需登录）
-			v1.GET("/search", searchCtrl.SearchTopics)           // 搜索话题
-			v1.GET("/search/suggest", searchCtrl.SuggestTopics)  // 搜索建议
-			v1.GET("/search/hot", searchCtrl.GetHotTopics)       // 热门话题
-			v1.GET("/search/stats", searchCtrl.GetCategoryStats) // 分类统计
-
-			// 管理接口（临时公开，生产环境应加权限验证）
-			v1.POST("/admin/sync-es", adminCtrl.SyncToES) // 同步数据到ES
+			v1.GET("/search", searchCtrl.SearchTopics)            // 搜索话题
+			v1.GET("/search/comments", searchCtrl.SearchComments) // 搜索评论
+			v1.GET("/search/suggest", searchCtrl.SuggestTopics)   // 搜索建议
+			v1.GET("/search/hot", searchCtrl.GetHotTopics)        // 热门话题
+			v1.GET("/search/stats", searchCtrl.GetCategoryStats)  // 分类统计
 
 			// ===== 需要登录的接口 =====
 			// 使用JWT中间件保护
@@ -81,15 +100,43 @@ func SetupRouter() *gin.Engine {
 			auth.Use(middleware.JWTAuth())
 			{
 				// 用户相关
-				auth.GET("/user/info", userCtrl.GetUserInfo) // 获取当前用户信息
+				auth.GET("/user/info", userCtrl.GetUserInfo)     // 获取当前用户信息
+				auth.GET("/user/mentions", userCtrl.GetMentions) // @我的消息
 
 				// 话题相关
-				auth.POST("/topics", topicCtrl.CreateTopic)        // 创建话题
-				auth.POST("/topics/:id/vote", topicCtrl.VoteTopic) // 给话题投票
+				auth.POST("/topics", topicCtrl.CreateTopic)           // 创建话题
+				auth.POST("/topics/:id/vote", topicCtrl.VoteTopic)    // 给话题投票
+				auth.POST("/topics/:id/poll/vote", pollCtrl.VotePoll) // 参与话题附带的投票
+
+				// 附件上传
+				auth.POST("/uploads", uploadCtrl.Upload) // 上传图片/附件
 
 				// 评论相关
 				auth.POST("/topics/:id/comments", commentCtrl.CreateComment) // 发表评论
 				auth.DELETE("/comments/:id", commentCtrl.DeleteComment)      // 删除评论
+
+				// 版主操作（版主和管理员）
+				moderator := auth.Group("/admin")
+				moderator.Use(middleware.RequireRole(models.RoleModerator, models.RoleAdmin))
+				{
+					moderator.POST("/topics/:id/pin", adminCtrl.PinTopic)   // 置顶/取消置顶话题
+					moderator.POST("/topics/:id/lock", adminCtrl.LockTopic) // 锁定/解锁话题
+				}
+
+				// 管理员操作
+				admin := auth.Group("/admin")
+				admin.Use(middleware.RequireRole(models.RoleAdmin))
+				{
+					// 搜索索引运维
+					admin.POST("/es/reindex", adminCtrl.ReindexES)        // 零停机重建索引
+					admin.POST("/sync-es", adminCtrl.SyncToES)            // 同步数据到ES（后台执行，支持断点续传）
+					admin.GET("/sync-es/status", adminCtrl.GetSyncStatus) // 查询同步进度
+
+					// 搜索分析
+					admin.GET("/search/top-queries", adminCtrl.GetTopSearchQueries)   // 热门搜索词
+					admin.GET("/search/zero-queries", adminCtrl.GetZeroResultQueries) // 零结果搜索词
+					admin.GET("/search/hourly", adminCtrl.GetHourlySearchStats)       // 每小时搜索次数、零结果率和耗时
+				}
 			}
 		}
 	}
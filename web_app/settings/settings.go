@@ -3,6 +3,7 @@ package settings
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
@@ -59,9 +60,15 @@ type LogConfig struct {
 
 // KafkaConfig Kafka配置
 type KafkaConfig struct {
-	Brokers []string `mapstructure:"brokers"`
-	Topic   string   `mapstructure:"topic"`
-	GroupID string   `mapstructure:"group_id"`
+	Brokers         []string      `mapstructure:"brokers"`
+	Topic           string        `mapstructure:"topic"`
+	GroupID         string        `mapstructure:"group_id"`
+	DLQTopic        string        `mapstructure:"dlq_topic"`
+	MaxRetries      int           `mapstructure:"max_retries"`
+	RetryBackoff    time.Duration `mapstructure:"retry_backoff"`
+	RetryMaxBackoff time.Duration `mapstructure:"retry_max_backoff"`
+	BatchSize       int           `mapstructure:"batch_size"`
+	BatchWindow     time.Duration `mapstructure:"batch_window"`
 }
 
 // Init 初始化配置系统
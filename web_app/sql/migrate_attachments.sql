@@ -0,0 +1,57 @@
+-- 数据库迁移脚本：附件
+-- 为已有数据库增加附件、话题/评论附件关联和附件上传记录表，新部署直接使用 schema.sql 即可
+
+-- ========== 附件表 ==========
+CREATE TABLE IF NOT EXISTS `attachments` (
+    `id` BIGINT NOT NULL COMMENT '附件ID (使用雪花算法生成)',
+    `user_id` BIGINT NOT NULL COMMENT '首次上传的用户ID',
+    `sha256` CHAR(64) NOT NULL COMMENT '内容SHA-256（用于去重）',
+    `storage_key` VARCHAR(255) NOT NULL COMMENT '存储key',
+    `thumbnail_key` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '缩略图存储key（非图片为空）',
+    `mime_type` VARCHAR(100) NOT NULL COMMENT '嗅探得到的MIME类型',
+    `size` BIGINT NOT NULL COMMENT '文件大小（字节）',
+    `width` INT NOT NULL DEFAULT 0 COMMENT '图片宽度',
+    `height` INT NOT NULL DEFAULT 0 COMMENT '图片高度',
+    `original_name` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '原始文件名',
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_sha256` (`sha256`),
+    KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='附件表';
+
+-- ========== 话题附件关联表 ==========
+CREATE TABLE IF NOT EXISTS `topic_attachments` (
+    `topic_id` BIGINT NOT NULL COMMENT '话题ID',
+    `attachment_id` BIGINT NOT NULL COMMENT '附件ID',
+    `position` INT NOT NULL DEFAULT 0 COMMENT '顺序',
+    PRIMARY KEY (`topic_id`, `attachment_id`),
+    KEY `idx_attachment_id` (`attachment_id`),
+    CONSTRAINT `fk_topic_attachments_topic_id` FOREIGN KEY (`topic_id`) REFERENCES `topics` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_topic_attachments_attachment_id` FOREIGN KEY (`attachment_id`) REFERENCES `attachments` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='话题附件关联表';
+
+-- ========== 评论附件关联表 ==========
+CREATE TABLE IF NOT EXISTS `comment_attachments` (
+    `comment_id` BIGINT NOT NULL COMMENT '评论ID',
+    `attachment_id` BIGINT NOT NULL COMMENT '附件ID',
+    `position` INT NOT NULL DEFAULT 0 COMMENT '顺序',
+    PRIMARY KEY (`comment_id`, `attachment_id`),
+    KEY `idx_attachment_id` (`attachment_id`),
+    CONSTRAINT `fk_comment_attachments_comment_id` FOREIGN KEY (`comment_id`) REFERENCES `comments` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_comment_attachments_attachment_id` FOREIGN KEY (`attachment_id`) REFERENCES `attachments` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='评论附件关联表';
+
+-- ========== 附件上传记录表 ==========
+CREATE TABLE IF NOT EXISTS `attachment_uploads` (
+    `user_id` BIGINT NOT NULL COMMENT '上传的用户ID',
+    `attachment_id` BIGINT NOT NULL COMMENT '附件ID',
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '首次上传时间',
+    PRIMARY KEY (`user_id`, `attachment_id`),
+    KEY `idx_attachment_id` (`attachment_id`),
+    CONSTRAINT `fk_attachment_uploads_user_id` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_attachment_uploads_attachment_id` FOREIGN KEY (`attachment_id`) REFERENCES `attachments` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='附件上传记录表（附件按内容去重，记录每个上传过该文件的用户，只能引用自己上传的附件）';
+
+-- ========== 回填上传记录（已有附件的首次上传用户） ==========
+INSERT IGNORE INTO `attachment_uploads` (`user_id`, `attachment_id`, `created_at`)
+SELECT `user_id`, `id`, `created_at` FROM `attachments`;
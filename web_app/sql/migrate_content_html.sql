@@ -0,0 +1,9 @@
+-- 数据库迁移脚本：Markdown渲染结果缓存
+-- 为已有的 topics/comments 表增加 content_html 字段，新部署直接使用 schema.sql 即可
+-- 旧数据的 content_html 为空字符串，读取时会按需渲染
+
+ALTER TABLE `topics`
+    ADD COLUMN `content_html` MEDIUMTEXT NOT NULL COMMENT '渲染后的HTML缓存' AFTER `content`;
+
+ALTER TABLE `comments`
+    ADD COLUMN `content_html` MEDIUMTEXT NOT NULL COMMENT '渲染后的HTML缓存' AFTER `content`;
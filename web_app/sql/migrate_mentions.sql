@@ -0,0 +1,20 @@
+-- 数据库迁移脚本：@提及
+-- 为已有数据库增加提及（@我的消息）表，新部署直接使用 schema.sql 即可
+
+
+-- ========== 提及（@）表 ==========
+CREATE TABLE IF NOT EXISTS `mentions` (
+    `id` BIGINT NOT NULL COMMENT '提及ID（由被@用户、话题和评论确定，重复写入时忽略）',
+    `user_id` BIGINT NOT NULL COMMENT '被@的用户ID',
+    `from_user_id` BIGINT NOT NULL COMMENT '发起@的用户ID',
+    `topic_id` BIGINT NOT NULL COMMENT '所在话题ID',
+    `comment_id` BIGINT DEFAULT NULL COMMENT '所在评论ID（在话题正文中@时为空）',
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_user_created` (`user_id`, `created_at`),
+    KEY `idx_topic_id` (`topic_id`),
+    KEY `idx_comment_id` (`comment_id`),
+    CONSTRAINT `fk_mentions_user_id` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_mentions_topic_id` FOREIGN KEY (`topic_id`) REFERENCES `topics` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_mentions_comment_id` FOREIGN KEY (`comment_id`) REFERENCES `comments` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='提及表';
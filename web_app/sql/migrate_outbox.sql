@@ -0,0 +1,15 @@
+-- 数据库迁移脚本：事务发件箱
+-- 为已有数据库增加发件箱表（outbox.enabled=true 时使用），新部署直接使用 schema.sql 即可
+
+-- ========== 事务发件箱表 ==========
+CREATE TABLE IF NOT EXISTS `outbox_events` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '自增ID（转发顺序）',
+    `table_name` VARCHAR(64) NOT NULL COMMENT '变更的表',
+    `row_id` BIGINT NOT NULL COMMENT '变更行的主键',
+    `operation` VARCHAR(10) NOT NULL COMMENT '变更类型：INSERT/UPDATE/DELETE',
+    `payload` MEDIUMTEXT NOT NULL COMMENT 'Canal flatMessage格式的消息体',
+    `created_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '写入时间',
+    `published_at` DATETIME(3) DEFAULT NULL COMMENT '发布时间，NULL表示未发布',
+    PRIMARY KEY (`id`),
+    KEY `idx_published_at` (`published_at`, `id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='事务发件箱表（outbox模式下代替Canal同步ES）';
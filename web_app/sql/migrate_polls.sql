@@ -0,0 +1,40 @@
+-- 数据库迁移脚本：话题投票（问卷）
+-- 为已有数据库增加投票、投票选项和投票记录表，新部署直接使用 schema.sql 即可
+
+-- ========== 投票（问卷）表 ==========
+CREATE TABLE IF NOT EXISTS `polls` (
+    `id` BIGINT NOT NULL COMMENT '投票ID (使用雪花算法生成)',
+    `topic_id` BIGINT NOT NULL COMMENT '所属话题ID',
+    `multi_choice` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否多选',
+    `close_at` DATETIME DEFAULT NULL COMMENT '截止时间（为空表示不截止）',
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_topic_id` (`topic_id`),
+    CONSTRAINT `fk_polls_topic_id` FOREIGN KEY (`topic_id`) REFERENCES `topics` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='话题投票表';
+
+-- ========== 投票选项表 ==========
+CREATE TABLE IF NOT EXISTS `poll_options` (
+    `id` BIGINT NOT NULL COMMENT '选项ID (使用雪花算法生成)',
+    `poll_id` BIGINT NOT NULL COMMENT '投票ID',
+    `content` VARCHAR(100) NOT NULL COMMENT '选项内容',
+    `position` INT NOT NULL DEFAULT 0 COMMENT '选项顺序',
+    `vote_count` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '得票数',
+    PRIMARY KEY (`id`),
+    KEY `idx_poll_id` (`poll_id`),
+    CONSTRAINT `fk_poll_options_poll_id` FOREIGN KEY (`poll_id`) REFERENCES `polls` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='投票选项表';
+
+-- ========== 投票记录表 ==========
+CREATE TABLE IF NOT EXISTS `poll_votes` (
+    `id` BIGINT NOT NULL COMMENT '记录ID (使用雪花算法生成)',
+    `poll_id` BIGINT NOT NULL COMMENT '投票ID',
+    `option_id` BIGINT NOT NULL COMMENT '选项ID',
+    `user_id` BIGINT NOT NULL COMMENT '用户ID',
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_poll_user_option` (`poll_id`, `user_id`, `option_id`),
+    KEY `idx_option_id` (`option_id`),
+    CONSTRAINT `fk_poll_votes_poll_id` FOREIGN KEY (`poll_id`) REFERENCES `polls` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_poll_votes_user_id` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='投票记录表';
@@ -0,0 +1,26 @@
+-- 数据库迁移脚本：搜索分析
+-- 为已有数据库增加搜索日志表和搜索词小时统计表，新部署直接使用 schema.sql 即可
+
+-- ========== 搜索日志表 ==========
+CREATE TABLE IF NOT EXISTS `search_logs` (
+    `id` BIGINT NOT NULL COMMENT '日志ID (使用雪花算法生成)',
+    `keyword` VARCHAR(64) NOT NULL COMMENT '规范化后的搜索词',
+    `filters` VARCHAR(512) NOT NULL DEFAULT '' COMMENT '筛选条件（JSON）',
+    `result_count` INT NOT NULL DEFAULT 0 COMMENT '结果总数',
+    `latency_ms` INT NOT NULL DEFAULT 0 COMMENT '搜索耗时（毫秒）',
+    `relaxed` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否为放宽匹配后的结果',
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '搜索时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_created_at` (`created_at`),
+    KEY `idx_keyword_created` (`keyword`, `created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='搜索日志表';
+
+-- ========== 搜索词小时统计表 ==========
+CREATE TABLE IF NOT EXISTS `search_query_stats` (
+    `hour` DATETIME NOT NULL COMMENT '统计小时（整点）',
+    `keyword` VARCHAR(64) NOT NULL COMMENT '规范化后的搜索词',
+    `search_count` INT NOT NULL DEFAULT 0 COMMENT '搜索次数',
+    `zero_count` INT NOT NULL DEFAULT 0 COMMENT '零结果次数',
+    PRIMARY KEY (`hour`, `keyword`),
+    KEY `idx_keyword` (`keyword`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='搜索词小时统计表';
@@ -0,0 +1,7 @@
+-- 数据库迁移脚本：话题全文索引
+-- 为已有的 topics 表增加标题和内容的 FULLTEXT 索引（ngram 分词，支持中文），新部署直接使用 schema.sql 即可
+-- Elasticsearch 不可用时 /api/v1/search 降级为 MySQL 全文检索，依赖该索引
+-- ngram 分词长度由 MySQL 的 ngram_token_size 参数决定（默认2），修改后需要重建索引
+
+ALTER TABLE `topics`
+    ADD FULLTEXT KEY `ft_title_content` (`title`, `content`) WITH PARSER ngram COMMENT 'Elasticsearch不可用时的降级搜索';
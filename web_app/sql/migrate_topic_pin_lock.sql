@@ -0,0 +1,8 @@
+-- 数据库迁移脚本：话题置顶与锁定
+-- 为已有的 topics 表增加置顶/锁定字段，新部署直接使用 schema.sql 即可
+
+ALTER TABLE `topics`
+    ADD COLUMN `is_pinned` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否置顶' AFTER `view_count`,
+    ADD COLUMN `pin_scope` VARCHAR(10) NOT NULL DEFAULT '' COMMENT '置顶范围：global=全站置顶，category=分类置顶' AFTER `is_pinned`,
+    ADD COLUMN `is_locked` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否锁定（锁定后禁止评论）' AFTER `pin_scope`,
+    ADD KEY `idx_pinned` (`is_pinned`, `pin_scope`);
@@ -0,0 +1,6 @@
+-- 数据库迁移脚本：话题标签
+-- 为已有的 topics 表增加标签字段，新部署直接使用 schema.sql 即可
+-- 标签的筛选和聚合由 Elasticsearch 完成，执行后需要重建搜索索引（POST /api/v1/admin/es/reindex）
+
+ALTER TABLE `topics`
+    ADD COLUMN `tags` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '标签（逗号分隔，最多5个）' AFTER `category`;
@@ -0,0 +1,9 @@
+-- 数据库迁移脚本：用户角色
+-- 为已有的 users 表增加角色字段，新部署直接使用 schema.sql 即可
+-- 版主（moderator）可置顶、锁定话题；管理员（admin）另外可执行索引运维、查看搜索分析
+
+ALTER TABLE `users`
+    ADD COLUMN `role` VARCHAR(20) NOT NULL DEFAULT 'user' COMMENT '角色：user=普通用户，moderator=版主，admin=管理员' AFTER `password`;
+
+-- 指定管理员（按实际用户名修改）
+-- UPDATE `users` SET `role` = 'admin' WHERE `username` = 'admin';
@@ -6,6 +6,7 @@ CREATE TABLE IF NOT EXISTS `users` (
     `username` VARCHAR(50) NOT NULL COMMENT '用户名',
     `email` VARCHAR(100) NOT NULL COMMENT '邮箱',
     `password` VARCHAR(255) NOT NULL COMMENT '密码（bcrypt加密）',
+    `role` VARCHAR(20) NOT NULL DEFAULT 'user' COMMENT '角色：user=普通用户，moderator=版主，admin=管理员',
     `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
     `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
     PRIMARY KEY (`id`),
@@ -19,12 +20,17 @@ CREATE TABLE IF NOT EXISTS `topics` (
     `id` BIGINT NOT NULL COMMENT '话题ID (使用雪花算法生成)',
     `user_id` BIGINT NOT NULL COMMENT '发布者ID',
     `title` VARCHAR(200) NOT NULL COMMENT '话题标题',
-    `content` TEXT NOT NULL COMMENT '话题内容',
+    `content` TEXT NOT NULL COMMENT '话题内容（Markdown原文）',
+    `content_html` MEDIUMTEXT NOT NULL COMMENT '渲染后的HTML缓存',
     `category` VARCHAR(20) NOT NULL COMMENT '分类：tech/design/discuss/share/product',
+    `tags` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '标签（逗号分隔，最多5个）',
     `like_count` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '点赞数',
     `dislike_count` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '点踩数',
     `comment_count` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '评论数',
     `view_count` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '浏览数',
+    `is_pinned` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否置顶',
+    `pin_scope` VARCHAR(10) NOT NULL DEFAULT '' COMMENT '置顶范围：global=全站置顶，category=分类置顶',
+    `is_locked` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否锁定（锁定后禁止评论）',
     `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
     `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
     PRIMARY KEY (`id`),
@@ -32,6 +38,8 @@ CREATE TABLE IF NOT EXISTS `topics` (
     KEY `idx_category` (`category`),
     KEY `idx_created_at` (`created_at`),
     KEY `idx_like_count` (`like_count`),
+    KEY `idx_pinned` (`is_pinned`, `pin_scope`),
+    FULLTEXT KEY `ft_title_content` (`title`, `content`) WITH PARSER ngram COMMENT 'Elasticsearch不可用时的降级搜索',
     CONSTRAINT `fk_topics_user_id` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='话题表';
 
@@ -54,7 +62,8 @@ CREATE TABLE IF NOT EXISTS `comments` (
     `id` BIGINT NOT NULL COMMENT '评论ID (使用雪花算法生成)',
     `topic_id` BIGINT NOT NULL COMMENT '话题ID',
     `user_id` BIGINT NOT NULL COMMENT '用户ID',
-    `content` TEXT NOT NULL COMMENT '评论内容',
+    `content` TEXT NOT NULL COMMENT '评论内容（Markdown原文）',
+    `content_html` MEDIUMTEXT NOT NULL COMMENT '渲染后的HTML缓存',
     `parent_id` BIGINT DEFAULT NULL COMMENT '父评论ID（用于回复）',
     `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
     `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
@@ -66,6 +75,149 @@ CREATE TABLE IF NOT EXISTS `comments` (
     CONSTRAINT `fk_comments_user_id` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='评论表';
 
+-- ========== 投票（问卷）表 ==========
+CREATE TABLE IF NOT EXISTS `polls` (
+    `id` BIGINT NOT NULL COMMENT '投票ID (使用雪花算法生成)',
+    `topic_id` BIGINT NOT NULL COMMENT '所属话题ID',
+    `multi_choice` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否多选',
+    `close_at` DATETIME DEFAULT NULL COMMENT '截止时间（为空表示不截止）',
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_topic_id` (`topic_id`),
+    CONSTRAINT `fk_polls_topic_id` FOREIGN KEY (`topic_id`) REFERENCES `topics` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='话题投票表';
+
+-- ========== 投票选项表 ==========
+CREATE TABLE IF NOT EXISTS `poll_options` (
+    `id` BIGINT NOT NULL COMMENT '选项ID (使用雪花算法生成)',
+    `poll_id` BIGINT NOT NULL COMMENT '投票ID',
+    `content` VARCHAR(100) NOT NULL COMMENT '选项内容',
+    `position` INT NOT NULL DEFAULT 0 COMMENT '选项顺序',
+    `vote_count` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '得票数',
+    PRIMARY KEY (`id`),
+    KEY `idx_poll_id` (`poll_id`),
+    CONSTRAINT `fk_poll_options_poll_id` FOREIGN KEY (`poll_id`) REFERENCES `polls` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='投票选项表';
+
+-- ========== 投票记录表 ==========
+CREATE TABLE IF NOT EXISTS `poll_votes` (
+    `id` BIGINT NOT NULL COMMENT '记录ID (使用雪花算法生成)',
+    `poll_id` BIGINT NOT NULL COMMENT '投票ID',
+    `option_id` BIGINT NOT NULL COMMENT '选项ID',
+    `user_id` BIGINT NOT NULL COMMENT '用户ID',
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_poll_user_option` (`poll_id`, `user_id`, `option_id`),
+    KEY `idx_option_id` (`option_id`),
+    CONSTRAINT `fk_poll_votes_poll_id` FOREIGN KEY (`poll_id`) REFERENCES `polls` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_poll_votes_user_id` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='投票记录表';
+
+-- ========== 附件表 ==========
+CREATE TABLE IF NOT EXISTS `attachments` (
+    `id` BIGINT NOT NULL COMMENT '附件ID (使用雪花算法生成)',
+    `user_id` BIGINT NOT NULL COMMENT '首次上传的用户ID',
+    `sha256` CHAR(64) NOT NULL COMMENT '内容SHA-256（用于去重）',
+    `storage_key` VARCHAR(255) NOT NULL COMMENT '存储key',
+    `thumbnail_key` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '缩略图存储key（非图片为空）',
+    `mime_type` VARCHAR(100) NOT NULL COMMENT '嗅探得到的MIME类型',
+    `size` BIGINT NOT NULL COMMENT '文件大小（字节）',
+    `width` INT NOT NULL DEFAULT 0 COMMENT '图片宽度',
+    `height` INT NOT NULL DEFAULT 0 COMMENT '图片高度',
+    `original_name` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '原始文件名',
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_sha256` (`sha256`),
+    KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='附件表';
+
+-- ========== 话题附件关联表 ==========
+CREATE TABLE IF NOT EXISTS `topic_attachments` (
+    `topic_id` BIGINT NOT NULL COMMENT '话题ID',
+    `attachment_id` BIGINT NOT NULL COMMENT '附件ID',
+    `position` INT NOT NULL DEFAULT 0 COMMENT '顺序',
+    PRIMARY KEY (`topic_id`, `attachment_id`),
+    KEY `idx_attachment_id` (`attachment_id`),
+    CONSTRAINT `fk_topic_attachments_topic_id` FOREIGN KEY (`topic_id`) REFERENCES `topics` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_topic_attachments_attachment_id` FOREIGN KEY (`attachment_id`) REFERENCES `attachments` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='话题附件关联表';
+
+-- ========== 评论附件关联表 ==========
+CREATE TABLE IF NOT EXISTS `comment_attachments` (
+    `comment_id` BIGINT NOT NULL COMMENT '评论ID',
+    `attachment_id` BIGINT NOT NULL COMMENT '附件ID',
+    `position` INT NOT NULL DEFAULT 0 COMMENT '顺序',
+    PRIMARY KEY (`comment_id`, `attachment_id`),
+    KEY `idx_attachment_id` (`attachment_id`),
+    CONSTRAINT `fk_comment_attachments_comment_id` FOREIGN KEY (`comment_id`) REFERENCES `comments` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_comment_attachments_attachment_id` FOREIGN KEY (`attachment_id`) REFERENCES `attachments` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='评论附件关联表';
+
+-- ========== 附件上传记录表 ==========
+CREATE TABLE IF NOT EXISTS `attachment_uploads` (
+    `user_id` BIGINT NOT NULL COMMENT '上传的用户ID',
+    `attachment_id` BIGINT NOT NULL COMMENT '附件ID',
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '首次上传时间',
+    PRIMARY KEY (`user_id`, `attachment_id`),
+    KEY `idx_attachment_id` (`attachment_id`),
+    CONSTRAINT `fk_attachment_uploads_user_id` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_attachment_uploads_attachment_id` FOREIGN KEY (`attachment_id`) REFERENCES `attachments` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='附件上传记录表（附件按内容去重，记录每个上传过该文件的用户，只能引用自己上传的附件）';
+
+-- ========== 提及（@）表 ==========
+CREATE TABLE IF NOT EXISTS `mentions` (
+    `id` BIGINT NOT NULL COMMENT '提及ID（由被@用户、话题和评论确定，重复写入时忽略）',
+    `user_id` BIGINT NOT NULL COMMENT '被@的用户ID',
+    `from_user_id` BIGINT NOT NULL COMMENT '发起@的用户ID',
+    `topic_id` BIGINT NOT NULL COMMENT '所在话题ID',
+    `comment_id` BIGINT DEFAULT NULL COMMENT '所在评论ID（在话题正文中@时为空）',
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_user_created` (`user_id`, `created_at`),
+    KEY `idx_topic_id` (`topic_id`),
+    KEY `idx_comment_id` (`comment_id`),
+    CONSTRAINT `fk_mentions_user_id` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_mentions_topic_id` FOREIGN KEY (`topic_id`) REFERENCES `topics` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_mentions_comment_id` FOREIGN KEY (`comment_id`) REFERENCES `comments` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='提及表';
+
+-- ========== 搜索日志表 ==========
+CREATE TABLE IF NOT EXISTS `search_logs` (
+    `id` BIGINT NOT NULL COMMENT '日志ID (使用雪花算法生成)',
+    `keyword` VARCHAR(64) NOT NULL COMMENT '规范化后的搜索词',
+    `filters` VARCHAR(512) NOT NULL DEFAULT '' COMMENT '筛选条件（JSON）',
+    `result_count` INT NOT NULL DEFAULT 0 COMMENT '结果总数',
+    `latency_ms` INT NOT NULL DEFAULT 0 COMMENT '搜索耗时（毫秒）',
+    `relaxed` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否为放宽匹配后的结果',
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '搜索时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_created_at` (`created_at`),
+    KEY `idx_keyword_created` (`keyword`, `created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='搜索日志表';
+
+-- ========== 搜索词小时统计表 ==========
+CREATE TABLE IF NOT EXISTS `search_query_stats` (
+    `hour` DATETIME NOT NULL COMMENT '统计小时（整点）',
+    `keyword` VARCHAR(64) NOT NULL COMMENT '规范化后的搜索词',
+    `search_count` INT NOT NULL DEFAULT 0 COMMENT '搜索次数',
+    `zero_count` INT NOT NULL DEFAULT 0 COMMENT '零结果次数',
+    PRIMARY KEY (`hour`, `keyword`),
+    KEY `idx_keyword` (`keyword`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='搜索词小时统计表';
+
+-- ========== 事务发件箱表 ==========
+CREATE TABLE IF NOT EXISTS `outbox_events` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '自增ID（转发顺序）',
+    `table_name` VARCHAR(64) NOT NULL COMMENT '变更的表',
+    `row_id` BIGINT NOT NULL COMMENT '变更行的主键',
+    `operation` VARCHAR(10) NOT NULL COMMENT '变更类型：INSERT/UPDATE/DELETE',
+    `payload` MEDIUMTEXT NOT NULL COMMENT 'Canal flatMessage格式的消息体',
+    `created_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '写入时间',
+    `published_at` DATETIME(3) DEFAULT NULL COMMENT '发布时间，NULL表示未发布',
+    PRIMARY KEY (`id`),
+    KEY `idx_published_at` (`published_at`, `id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='事务发件箱表（outbox模式下代替Canal同步ES）';
+
 -- ========== 插入测试数据 ==========
 -- 注意：由于使用雪花算法生成ID，测试数据需要通过应用程序API插入
 -- 或手动指定有效的雪花算法ID
@@ -0,0 +1,391 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+	"web_app/dao/elasticsearch"
+	"web_app/dao/mysql"
+	"web_app/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// maxDriftSamples 报告中每类差异最多列出的ID数
+const maxDriftSamples = 100
+
+var (
+	// 最近一次一致性检查发现的差异文档数（kind=missing/extra/stale）
+	esDriftDocuments = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "es_consistency_drift_documents",
+			Help: "最近一次MySQL与ES一致性检查发现的差异文档数",
+		},
+		[]string{"kind"},
+	)
+
+	// 一致性检查修复的文档数
+	esConsistencyRepaired = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "es_consistency_repaired_total",
+			Help: "一致性检查修复的ES文档数",
+		},
+	)
+
+	// 最近一次完成一致性检查的时间
+	esConsistencyLastRun = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "es_consistency_last_run_timestamp_seconds",
+			Help: "最近一次完成MySQL与ES一致性检查的时间（Unix秒）",
+		},
+	)
+)
+
+// ConsistencyOptions 一致性检查配置
+type ConsistencyOptions struct {
+	BatchSize    int           // 每批读取的话题/文档数
+	RecheckDelay time.Duration // 发现差异后等待多久再确认（排除同步延迟造成的误报）
+	Repair       bool          // 是否修复确认的差异
+}
+
+// LoadConsistencyOptions 从配置文件读取一致性检查配置（未配置的项使用默认值）
+func LoadConsistencyOptions() ConsistencyOptions {
+	opts := ConsistencyOptions{
+		BatchSize:    viper.GetInt("elasticsearch.consistency.batch_size"),
+		RecheckDelay: viper.GetDuration("elasticsearch.consistency.recheck_delay"),
+		Repair:       viper.GetBool("elasticsearch.consistency.repair"),
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+	if opts.RecheckDelay < 0 {
+		opts.RecheckDelay = 0
+	}
+	return opts
+}
+
+// consistencyChecker MySQL与ES话题索引的一致性检查器
+type consistencyChecker struct {
+	opts        ConsistencyOptions
+	scanTopics  func(lastID int64, limit int) ([]*models.Topic, error)                                          // 按ID游标读取MySQL话题
+	getTopics   func(ids []int64) ([]*models.Topic, error)                                                      // 按ID读取MySQL话题
+	esChecksums func(ctx context.Context, ids []int64) (map[int64]string, error)                                // 按ID读取ES文档的校验和
+	scanESIDs   func(ctx context.Context, batchSize int, fn func(ids []string) error) error                     // 遍历ES文档ID
+	existingIDs func(ids []int64) (map[int64]bool, error)                                                       // 查询MySQL中仍存在的话题ID
+	apply       func(ctx context.Context, changes *elasticsearch.ChangeSet) (elasticsearch.ChangeResult, error) // 写入修复
+}
+
+// topicDrift 一批话题确认后的差异
+type topicDrift struct {
+	missing []int64
+	extra   []int64
+	stale   []int64
+}
+
+// CheckESConsistency 检查MySQL与ES话题索引的一致性，opts.Repair 为true时修复确认的差异
+//  1. 按ID顺序分批扫描MySQL，与ES中同ID文档的校验和比较，找出缺失和过期的文档
+//  2. 遍历ES中的文档ID，找出MySQL中已不存在的多余文档
+//  3. 等待 RecheckDelay 后重新读取两边的数据确认差异，排除同步延迟造成的误报
+func CheckESConsistency(ctx context.Context, opts ConsistencyOptions) (*models.ESConsistencyReport, error) {
+	checker := &consistencyChecker{
+		opts:        opts,
+		scanTopics:  mysql.GetTopicsAfterID,
+		getTopics:   mysql.GetTopicsByIDs,
+		esChecksums: elasticsearch.GetTopicChecksums,
+		scanESIDs: func(ctx context.Context, batchSize int, fn func(ids []string) error) error {
+			return elasticsearch.ScanTopicIDs(ctx, elasticsearch.GetIndex(), batchSize, fn)
+		},
+		existingIDs: mysql.GetExistingTopicIDs,
+		apply:       elasticsearch.ApplyChanges,
+	}
+	report, err := checker.run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	esDriftDocuments.WithLabelValues("missing").Set(float64(report.Missing))
+	esDriftDocuments.WithLabelValues("extra").Set(float64(report.Extra))
+	esDriftDocuments.WithLabelValues("stale").Set(float64(report.Stale))
+	esConsistencyRepaired.Add(float64(report.Repaired))
+	esConsistencyLastRun.Set(float64(report.FinishedAt.Unix()))
+	return report, nil
+}
+
+// run 执行一次完整的检查
+func (c *consistencyChecker) run(ctx context.Context) (*models.ESConsistencyReport, error) {
+	report := &models.ESConsistencyReport{StartedAt: time.Now()}
+
+	candidates, err := c.scanMySQL(ctx, report)
+	if err != nil {
+		return nil, err
+	}
+	extra, err := c.scanES(ctx, report)
+	if err != nil {
+		return nil, err
+	}
+	candidates = mergeIDs(candidates, extra)
+
+	if len(candidates) > 0 && c.opts.RecheckDelay > 0 {
+		timer := time.NewTimer(c.opts.RecheckDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+
+	for start := 0; start < len(candidates); start += c.opts.BatchSize {
+		end := start + c.opts.BatchSize
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+		if err := c.confirm(ctx, candidates[start:end], report); err != nil {
+			return nil, err
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// scanMySQL 按ID顺序扫描MySQL话题，返回ES中缺失或校验和不一致的话题ID
+func (c *consistencyChecker) scanMySQL(ctx context.Context, report *models.ESConsistencyReport) ([]int64, error) {
+	var candidates []int64
+	var lastID int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		topics, err := c.scanTopics(lastID, c.opts.BatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("读取MySQL话题失败: %w", err)
+		}
+		if len(topics) == 0 {
+			return candidates, nil
+		}
+		report.MySQLScanned += int64(len(topics))
+
+		ids, checksums := topicChecksums(topics)
+		esChecksums, err := c.esChecksums(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("读取ES文档失败: %w", err)
+		}
+		for _, id := range ids {
+			if esChecksums[id] != checksums[id] {
+				candidates = append(candidates, id)
+			}
+		}
+		lastID = topics[len(topics)-1].ID
+	}
+}
+
+// scanES 遍历ES中的文档ID，返回MySQL中已不存在的话题ID
+func (c *consistencyChecker) scanES(ctx context.Context, report *models.ESConsistencyReport) ([]int64, error) {
+	var candidates []int64
+	err := c.scanESIDs(ctx, c.opts.BatchSize, func(docIDs []string) error {
+		report.ESScanned += int64(len(docIDs))
+		ids := make([]int64, 0, len(docIDs))
+		for _, docID := range docIDs {
+			id, err := strconv.ParseInt(docID, 10, 64)
+			if err != nil {
+				zap.L().Warn("ES中存在无法解析的文档ID，跳过", zap.String("id", docID))
+				continue
+			}
+			ids = append(ids, id)
+		}
+
+		existing, err := c.existingIDs(ids)
+		if err != nil {
+			return fmt.Errorf("查询MySQL话题失败: %w", err)
+		}
+		for _, id := range ids {
+			if !existing[id] {
+				candidates = append(candidates, id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("扫描ES文档失败: %w", err)
+	}
+	return candidates, nil
+}
+
+// confirm 重新读取两边的数据确认差异并计入报告，开启修复时把MySQL中的数据写入ES
+// 修复以读取MySQL前的时间作为外部版本号：读取之后同步过来的变更版本更新，不会被修复覆盖
+func (c *consistencyChecker) confirm(ctx context.Context, ids []int64, report *models.ESConsistencyReport) error {
+	readAt := time.Now()
+	topics, err := c.getTopics(ids)
+	if err != nil {
+		return fmt.Errorf("读取MySQL话题失败: %w", err)
+	}
+	esChecksums, err := c.esChecksums(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("读取ES文档失败: %w", err)
+	}
+
+	byID := make(map[int64]*models.Topic, len(topics))
+	for _, topic := range topics {
+		byID[topic.ID] = topic
+	}
+	_, checksums := topicChecksums(topics)
+	drift := classifyDrift(ids, checksums, esChecksums)
+	addDrift(report, drift)
+
+	if !c.opts.Repair {
+		return nil
+	}
+	changes := repairChanges(drift, byID, readAt.UnixMilli())
+	if len(changes.Topics) == 0 {
+		return nil
+	}
+	result, err := c.apply(ctx, changes)
+	if err != nil {
+		return fmt.Errorf("修复ES文档失败: %w", err)
+	}
+	report.Repaired += result.Applied
+	report.Superseded += result.Stale
+	return nil
+}
+
+// topicChecksums 计算一批话题的校验和，返回按原顺序的ID和 ID -> 校验和
+func topicChecksums(topics []*models.Topic) ([]int64, map[int64]string) {
+	ids := make([]int64, len(topics))
+	checksums := make(map[int64]string, len(topics))
+	for i, topic := range topics {
+		ids[i] = topic.ID
+		checksums[topic.ID] = elasticsearch.TopicChecksum(topic)
+	}
+	return ids, checksums
+}
+
+// classifyDrift 比较两边的校验和：只在MySQL中为缺失，只在ES中为多余，都存在但不同为过期
+func classifyDrift(ids []int64, mysqlChecksums, esChecksums map[int64]string) topicDrift {
+	var drift topicDrift
+	for _, id := range ids {
+		want, inMySQL := mysqlChecksums[id]
+		got, inES := esChecksums[id]
+		switch {
+		case inMySQL && !inES:
+			drift.missing = append(drift.missing, id)
+		case !inMySQL && inES:
+			drift.extra = append(drift.extra, id)
+		case inMySQL && want != got:
+			drift.stale = append(drift.stale, id)
+		}
+	}
+	return drift
+}
+
+// repairChanges 构造修复用的变更：缺失和过期的写入MySQL中的数据，多余的删除
+func repairChanges(drift topicDrift, topics map[int64]*models.Topic, version int64) *elasticsearch.ChangeSet {
+	changes := &elasticsearch.ChangeSet{}
+	for _, ids := range [][]int64{drift.missing, drift.stale} {
+		for _, id := range ids {
+			changes.Topics = append(changes.Topics, &elasticsearch.TopicChange{TopicID: id, Topic: topics[id], Version: version})
+		}
+	}
+	for _, id := range drift.extra {
+		changes.Topics = append(changes.Topics, &elasticsearch.TopicChange{TopicID: id, Deleted: true, Version: version})
+	}
+	return changes
+}
+
+// addDrift 把一批差异计入报告（ID只保留前 maxDriftSamples 个）
+func addDrift(report *models.ESConsistencyReport, drift topicDrift) {
+	report.Missing += len(drift.missing)
+	report.Extra += len(drift.extra)
+	report.Stale += len(drift.stale)
+	report.MissingIDs = appendDriftSamples(report.MissingIDs, drift.missing)
+	report.ExtraIDs = appendDriftSamples(report.ExtraIDs, drift.extra)
+	report.StaleIDs = appendDriftSamples(report.StaleIDs, drift.stale)
+}
+
+// appendDriftSamples 追加ID样例，最多保留 maxDriftSamples 个
+func appendDriftSamples(samples []string, ids []int64) []string {
+	for _, id := range ids {
+		if len(samples) >= maxDriftSamples {
+			break
+		}
+		samples = append(samples, strconv.FormatInt(id, 10))
+	}
+	return samples
+}
+
+// mergeIDs 合并两组ID并去重（话题可能在两次扫描之间被删除，同时出现在两组中）
+func mergeIDs(a, b []int64) []int64 {
+	seen := make(map[int64]bool, len(a)+len(b))
+	merged := make([]int64, 0, len(a)+len(b))
+	for _, ids := range [][]int64{a, b} {
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				merged = append(merged, id)
+			}
+		}
+	}
+	return merged
+}
+
+// RunESConsistencyTask 定期检查MySQL与ES的一致性，直到ctx取消（elasticsearch.consistency.enabled 为 true 时由main.go启动）
+func RunESConsistencyTask(ctx context.Context) error {
+	interval := viper.GetDuration("elasticsearch.consistency.interval")
+	if interval <= 0 {
+		interval = 6 * time.Hour
+	}
+	opts := LoadConsistencyOptions()
+
+	check := func() {
+		report, err := CheckESConsistency(ctx, opts)
+		if err != nil {
+			if ctx.Err() == nil {
+				zap.L().Error("MySQL与ES一致性检查失败", zap.Error(err))
+			}
+			return
+		}
+		logConsistencyReport(report)
+	}
+
+	// 立即执行一次
+	check()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	zap.L().Info("MySQL与ES一致性检查定时任务已启动", zap.Duration("interval", interval), zap.Bool("repair", opts.Repair))
+	for {
+		select {
+		case <-ticker.C:
+			check()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// logConsistencyReport 记录检查结果，有差异时使用WARN级别
+func logConsistencyReport(report *models.ESConsistencyReport) {
+	fields := []zap.Field{
+		zap.Int64("mysql_scanned", report.MySQLScanned),
+		zap.Int64("es_scanned", report.ESScanned),
+		zap.Int("missing", report.Missing),
+		zap.Int("extra", report.Extra),
+		zap.Int("stale", report.Stale),
+		zap.Int("repaired", report.Repaired),
+		zap.Duration("took", report.FinishedAt.Sub(report.StartedAt)),
+	}
+	if report.Drift() == 0 {
+		zap.L().Info("MySQL与ES一致性检查完成，未发现差异", fields...)
+		return
+	}
+	fields = append(fields,
+		zap.Strings("missing_ids", report.MissingIDs),
+		zap.Strings("extra_ids", report.ExtraIDs),
+		zap.Strings("stale_ids", report.StaleIDs))
+	zap.L().Warn("MySQL与ES一致性检查发现差异", fields...)
+}
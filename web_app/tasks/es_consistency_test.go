@@ -0,0 +1,189 @@
+package tasks
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+	"web_app/dao/elasticsearch"
+	"web_app/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStores 内存中的MySQL话题和ES文档校验和
+type fakeStores struct {
+	topics  map[int64]*models.Topic
+	es      map[int64]string
+	applied []*elasticsearch.TopicChange
+}
+
+func newFakeStores(topicIDs ...int64) *fakeStores {
+	s := &fakeStores{topics: make(map[int64]*models.Topic), es: make(map[int64]string)}
+	for _, id := range topicIDs {
+		topic := &models.Topic{ID: id, Title: "话题" + strconv.FormatInt(id, 10)}
+		s.topics[id] = topic
+		s.es[id] = elasticsearch.TopicChecksum(topic)
+	}
+	return s
+}
+
+func (s *fakeStores) sortedIDs() []int64 {
+	ids := make([]int64, 0, len(s.topics))
+	for id := range s.topics {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (s *fakeStores) checker(opts ConsistencyOptions) *consistencyChecker {
+	return &consistencyChecker{
+		opts: opts,
+		scanTopics: func(lastID int64, limit int) ([]*models.Topic, error) {
+			var topics []*models.Topic
+			for _, id := range s.sortedIDs() {
+				if id > lastID && len(topics) < limit {
+					topics = append(topics, s.topics[id])
+				}
+			}
+			return topics, nil
+		},
+		getTopics: func(ids []int64) ([]*models.Topic, error) {
+			var topics []*models.Topic
+			for _, id := range ids {
+				if topic, ok := s.topics[id]; ok {
+					topics = append(topics, topic)
+				}
+			}
+			return topics, nil
+		},
+		esChecksums: func(_ context.Context, ids []int64) (map[int64]string, error) {
+			result := make(map[int64]string)
+			for _, id := range ids {
+				if sum, ok := s.es[id]; ok {
+					result[id] = sum
+				}
+			}
+			return result, nil
+		},
+		scanESIDs: func(_ context.Context, batchSize int, fn func(ids []string) error) error {
+			var batch []string
+			for id := range s.es {
+				batch = append(batch, strconv.FormatInt(id, 10))
+				if len(batch) == batchSize {
+					if err := fn(batch); err != nil {
+						return err
+					}
+					batch = nil
+				}
+			}
+			if len(batch) > 0 {
+				return fn(batch)
+			}
+			return nil
+		},
+		existingIDs: func(ids []int64) (map[int64]bool, error) {
+			existing := make(map[int64]bool)
+			for _, id := range ids {
+				if _, ok := s.topics[id]; ok {
+					existing[id] = true
+				}
+			}
+			return existing, nil
+		},
+		apply: func(_ context.Context, changes *elasticsearch.ChangeSet) (elasticsearch.ChangeResult, error) {
+			s.applied = append(s.applied, changes.Topics...)
+			return elasticsearch.ChangeResult{Applied: len(changes.Topics)}, nil
+		},
+	}
+}
+
+// TestConsistencyChecker_Drift 测试识别缺失、多余和过期的文档
+func TestConsistencyChecker_Drift(t *testing.T) {
+	stores := newFakeStores(1, 2, 3, 4, 5)
+	delete(stores.es, 2)            // 缺失
+	stores.es[99] = "deleted"       // 多余
+	stores.topics[4].LikeCount = 10 // 过期
+
+	report, err := stores.checker(ConsistencyOptions{BatchSize: 2}).run(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(5), report.MySQLScanned)
+	assert.Equal(t, int64(5), report.ESScanned)
+	assert.Equal(t, 1, report.Missing)
+	assert.Equal(t, 1, report.Extra)
+	assert.Equal(t, 1, report.Stale)
+	assert.Equal(t, []string{"2"}, report.MissingIDs)
+	assert.Equal(t, []string{"99"}, report.ExtraIDs)
+	assert.Equal(t, []string{"4"}, report.StaleIDs)
+	assert.Equal(t, 3, report.Drift())
+	assert.Empty(t, stores.applied, "未开启修复时不写入")
+}
+
+// TestConsistencyChecker_Recheck 测试确认阶段排除已同步的差异
+func TestConsistencyChecker_Recheck(t *testing.T) {
+	stores := newFakeStores(1, 2)
+	delete(stores.es, 2)
+	checker := stores.checker(ConsistencyOptions{BatchSize: 10, RecheckDelay: time.Millisecond})
+
+	// 扫描完成后同步追上（模拟Canal延迟）
+	scanES := checker.scanESIDs
+	checker.scanESIDs = func(ctx context.Context, batchSize int, fn func(ids []string) error) error {
+		err := scanES(ctx, batchSize, fn)
+		stores.es[2] = elasticsearch.TopicChecksum(stores.topics[2])
+		return err
+	}
+
+	report, err := checker.run(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, report.Drift())
+}
+
+// TestConsistencyChecker_Repair 测试修复：写入MySQL中的数据并删除多余文档，使用读取前的时间作为版本号
+func TestConsistencyChecker_Repair(t *testing.T) {
+	stores := newFakeStores(1, 2, 3)
+	delete(stores.es, 1)
+	stores.es[3] = "old"
+	stores.es[7] = "deleted"
+
+	before := time.Now().UnixMilli()
+	report, err := stores.checker(ConsistencyOptions{BatchSize: 10, Repair: true}).run(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, report.Repaired)
+	require.Len(t, stores.applied, 3)
+	byID := make(map[int64]*elasticsearch.TopicChange)
+	for _, change := range stores.applied {
+		byID[change.TopicID] = change
+		assert.GreaterOrEqual(t, change.Version, before)
+	}
+	assert.Same(t, stores.topics[1], byID[1].Topic)
+	assert.Same(t, stores.topics[3], byID[3].Topic)
+	assert.True(t, byID[7].Deleted)
+}
+
+// TestClassifyDrift 测试按两边的校验和分类
+func TestClassifyDrift(t *testing.T) {
+	mysqlSums := map[int64]string{1: "a", 2: "b", 3: "c"}
+	esSums := map[int64]string{1: "a", 3: "x", 4: "d"}
+
+	drift := classifyDrift([]int64{1, 2, 3, 4, 5}, mysqlSums, esSums)
+	assert.Equal(t, []int64{2}, drift.missing)
+	assert.Equal(t, []int64{4}, drift.extra)
+	assert.Equal(t, []int64{3}, drift.stale)
+}
+
+// TestAppendDriftSamples 测试样例ID数量上限
+func TestAppendDriftSamples(t *testing.T) {
+	ids := make([]int64, maxDriftSamples+10)
+	for i := range ids {
+		ids[i] = int64(i)
+	}
+	samples := appendDriftSamples(nil, ids[:50])
+	samples = appendDriftSamples(samples, ids[50:])
+	assert.Len(t, samples, maxDriftSamples)
+	assert.Equal(t, "0", samples[0])
+}
@@ -79,8 +79,8 @@ func UpdateHotRanking() error {
 	return nil
 }
 
-// StartHotRankingTask 启动热度排行榜定时任务
-func StartHotRankingTask() {
+// RunHotRankingTask 运行热度排行榜定时任务，直到ctx取消
+func RunHotRankingTask(ctx context.Context) error {
 	// 立即执行一次
 	if err := UpdateHotRanking(); err != nil {
 		zap.L().Error("初始化热度排行榜失败", zap.Error(err))
@@ -88,16 +88,19 @@ func StartHotRankingTask() {
 
 	// 每5分钟执行一次
 	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
 
-	go func() {
-		for range ticker.C {
+	zap.L().Info("热度排行榜定时任务已启动")
+	for {
+		select {
+		case <-ticker.C:
 			if err := UpdateHotRanking(); err != nil {
 				zap.L().Error("更新热度排行榜失败", zap.Error(err))
 			}
+		case <-ctx.Done():
+			return nil
 		}
-	}()
-
-	zap.L().Info("热度排行榜定时任务已启动")
+	}
 }
 
 // GetHotTopicIDs 从Redis获取热门话题ID列表
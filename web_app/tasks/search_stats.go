@@ -0,0 +1,75 @@
+package tasks
+
+import (
+	"context"
+	"time"
+	"web_app/dao/mysql"
+	"web_app/dao/redis"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// searchStatsRollupHours 每次持久化最近几个已结束的小时（补上延迟写入的日志，重复写入会覆盖）
+const searchStatsRollupHours = 2
+
+// RollupSearchStats 将Redis中已结束小时的搜索词统计写入MySQL，并清理过期的搜索日志
+// 每小时执行一次
+func RollupSearchStats() error {
+	startTime := time.Now()
+	current := startTime.Truncate(time.Hour)
+
+	// 1. 持久化最近几个已结束小时的搜索词统计
+	for i := 1; i <= searchStatsRollupHours; i++ {
+		hour := current.Add(-time.Duration(i) * time.Hour)
+		searches, zeros, err := redis.GetHourSearchQueryCounts(hour)
+		if err != nil {
+			zap.L().Error("读取搜索词小时统计失败", zap.Error(err), zap.Time("hour", hour))
+			return err
+		}
+		if err := mysql.UpsertSearchQueryStats(hour, searches, zeros); err != nil {
+			zap.L().Error("写入搜索词小时统计失败", zap.Error(err), zap.Time("hour", hour))
+			return err
+		}
+	}
+
+	// 2. 清理过期的搜索日志
+	retentionDays := viper.GetInt("search_analytics.log_retention_days")
+	if retentionDays <= 0 {
+		retentionDays = 30
+	}
+	deleted, err := mysql.DeleteSearchLogsBefore(startTime.AddDate(0, 0, -retentionDays))
+	if err != nil {
+		zap.L().Warn("清理过期搜索日志失败", zap.Error(err))
+	}
+
+	zap.L().Info("搜索统计持久化完成",
+		zap.Int64("deleted_logs", deleted),
+		zap.Duration("duration", time.Since(startTime)))
+
+	return nil
+}
+
+// RunSearchStatsTask 运行搜索统计持久化定时任务，直到ctx取消
+func RunSearchStatsTask(ctx context.Context) error {
+	// 立即执行一次
+	if err := RollupSearchStats(); err != nil {
+		zap.L().Error("初始化搜索统计持久化失败", zap.Error(err))
+	}
+
+	// 每小时执行一次
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	zap.L().Info("搜索统计持久化定时任务已启动")
+	for {
+		select {
+		case <-ticker.C:
+			if err := RollupSearchStats(); err != nil {
+				zap.L().Error("搜索统计持久化失败", zap.Error(err))
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
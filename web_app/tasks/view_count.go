@@ -0,0 +1,76 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"time"
+	"web_app/dao/mysql"
+	"web_app/dao/redis"
+	"web_app/utils"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultViewCountFlushInterval 浏览数写入MySQL的默认间隔
+	defaultViewCountFlushInterval = 10 * time.Second
+	// viewCountLockKey 浏览数写入锁（多个实例同时取出同一批增量会重复累加）
+	viewCountLockKey = "lock:view_count_flush"
+	// viewCountLockTTL 写入锁的过期时间（远大于一批写入的耗时）
+	viewCountLockTTL = time.Minute
+)
+
+// FlushViewCounts 把Redis中汇总的浏览数增量按批写入MySQL，同一时间只有一个实例写入（其他实例跳过本轮）
+// 写入失败时增量保留在Redis中，下次重试同一批；写入成功但删除增量前进程退出时，这一批会被重复累加
+func FlushViewCounts() error {
+	err := utils.WithLock(context.Background(), redis.GetClient(), viewCountLockKey, viewCountLockTTL, flushViewCounts)
+	if errors.Is(err, utils.ErrLockFailed) {
+		return nil
+	}
+	return err
+}
+
+// flushViewCounts 取出一批增量写入MySQL，成功后删除（须持有写入锁）
+func flushViewCounts() error {
+	counts, err := redis.TakePendingTopicViews()
+	if err != nil {
+		return err
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+	if err := mysql.AddTopicViewCounts(counts); err != nil {
+		return err
+	}
+	if err := redis.AckPendingTopicViews(); err != nil {
+		return err
+	}
+	zap.L().Debug("浏览数已写入MySQL", zap.Int("topics", len(counts)))
+	return nil
+}
+
+// RunViewCountTask 运行浏览数写入定时任务，直到ctx取消（退出前再写入一次）
+func RunViewCountTask(ctx context.Context) error {
+	interval := viper.GetDuration("view_count.flush_interval")
+	if interval <= 0 {
+		interval = defaultViewCountFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	zap.L().Info("浏览数写入定时任务已启动", zap.Duration("interval", interval))
+	for {
+		select {
+		case <-ticker.C:
+			if err := FlushViewCounts(); err != nil {
+				zap.L().Error("浏览数写入MySQL失败", zap.Error(err))
+			}
+		case <-ctx.Done():
+			if err := FlushViewCounts(); err != nil {
+				zap.L().Error("关机前写入浏览数失败", zap.Error(err))
+			}
+			return nil
+		}
+	}
+}
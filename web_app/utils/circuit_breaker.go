@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState 熔断器状态
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // 关闭：正常放行
+	BreakerOpen                         // 打开：拒绝请求，直接走降级逻辑
+	BreakerHalfOpen                     // 半开：放行一个探测请求，根据结果关闭或重新打开
+)
+
+// String 状态名称（用于日志）
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker 熔断器
+// 连续失败达到阈值后打开，打开期间Allow返回false；经过openTimeout后进入半开状态，
+// 只放行一个探测请求，成功则关闭，失败则重新打开
+type CircuitBreaker struct {
+	mu               sync.Mutex
+	failureThreshold int
+	openTimeout      time.Duration
+	state            BreakerState
+	failures         int       // 关闭状态下的连续失败次数
+	openedAt         time.Time // 最近一次打开的时间
+	probing          bool      // 半开状态下是否已放行探测请求
+	now              func() time.Time
+
+	// OnStateChange 状态变化回调（在锁内调用，不能再调用熔断器的方法）
+	OnStateChange func(from, to BreakerState)
+}
+
+// NewCircuitBreaker 创建熔断器，failureThreshold<=0 时按1处理
+func NewCircuitBreaker(failureThreshold int, openTimeout time.Duration) *CircuitBreaker {
+	if failureThreshold <= 0 {
+		failureThreshold = 1
+	}
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		now:              time.Now,
+	}
+}
+
+// Allow 是否放行本次请求，放行后需要调用Success或Failure报告结果
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return false
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success 报告请求成功
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	if b.state == BreakerHalfOpen {
+		b.probing = false
+		b.setState(BreakerClosed)
+	}
+}
+
+// Failure 报告请求失败
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerHalfOpen:
+		b.probing = false
+		b.open()
+	case BreakerClosed:
+		b.failures++
+		if b.failures >= b.failureThreshold {
+			b.open()
+		}
+	}
+}
+
+// State 当前状态
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// open 打开熔断器并开始计时
+func (b *CircuitBreaker) open() {
+	b.failures = 0
+	b.openedAt = b.now()
+	b.setState(BreakerOpen)
+}
+
+// setState 切换状态并通知回调
+func (b *CircuitBreaker) setState(state BreakerState) {
+	if state == b.state {
+		return
+	}
+	from := b.state
+	b.state = state
+	if b.OnStateChange != nil {
+		b.OnStateChange(from, state)
+	}
+}
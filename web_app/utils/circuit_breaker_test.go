@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestBreaker 创建使用可控时钟的熔断器
+func newTestBreaker(threshold int, openTimeout time.Duration) (*CircuitBreaker, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker(threshold, openTimeout)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+// TestCircuitBreaker_OpensAfterThreshold 测试连续失败达到阈值后打开，成功会清零失败计数
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	b, _ := newTestBreaker(3, time.Minute)
+
+	b.Failure()
+	b.Failure()
+	b.Success()
+	b.Failure()
+	b.Failure()
+	assert.Equal(t, BreakerClosed, b.State())
+	assert.True(t, b.Allow())
+
+	b.Failure()
+	assert.Equal(t, BreakerOpen, b.State())
+	assert.False(t, b.Allow())
+}
+
+// TestCircuitBreaker_HalfOpen 测试超时后只放行一个探测请求，根据结果关闭或重新打开
+func TestCircuitBreaker_HalfOpen(t *testing.T) {
+	b, now := newTestBreaker(1, time.Minute)
+	var transitions []string
+	b.OnStateChange = func(from, to BreakerState) {
+		transitions = append(transitions, from.String()+"->"+to.String())
+	}
+
+	b.Failure()
+	*now = now.Add(59 * time.Second)
+	assert.False(t, b.Allow())
+
+	// 探测失败，重新打开并重新计时
+	*now = now.Add(time.Second)
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow(), "探测期间不放行其他请求")
+	b.Failure()
+	assert.Equal(t, BreakerOpen, b.State())
+	*now = now.Add(30 * time.Second)
+	assert.False(t, b.Allow())
+
+	// 探测成功，关闭
+	*now = now.Add(30 * time.Second)
+	assert.True(t, b.Allow())
+	b.Success()
+	assert.Equal(t, BreakerClosed, b.State())
+	assert.True(t, b.Allow())
+
+	assert.Equal(t, []string{
+		"closed->open",
+		"open->half_open",
+		"half_open->open",
+		"open->half_open",
+		"half_open->closed",
+	}, transitions)
+}
@@ -68,6 +68,25 @@ func (l *DistributedLock) Unlock(ctx context.Context) error {
 	return nil
 }
 
+// Extend 延长锁的过期时间（只有锁仍归自己持有时才生效），用于长时间运行的任务续期
+func (l *DistributedLock) Extend(ctx context.Context) error {
+	script := `
+		if redis.call("get", KEYS[1]) == ARGV[1] then
+			return redis.call("pexpire", KEYS[1], ARGV[2])
+		else
+			return 0
+		end
+	`
+	result, err := l.client.Eval(ctx, script, []string{l.key}, l.value, l.expiration.Milliseconds()).Result()
+	if err != nil {
+		return err
+	}
+	if result == int64(0) {
+		return ErrLockFailed
+	}
+	return nil
+}
+
 // TryLock 尝试获取锁（带重试）
 func (l *DistributedLock) TryLock(ctx context.Context, retryCount int, retryDelay time.Duration) error {
 	for i := 0; i < retryCount; i++ {
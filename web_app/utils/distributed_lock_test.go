@@ -206,3 +206,32 @@ func TestDistributedLock_Expiration(t *testing.T) {
 		t.Error("锁过期后应该可以被重新获取")
 	}
 }
+
+// TestDistributedLock_Extend 测试锁续期
+func TestDistributedLock_Extend(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+	defer client.Close()
+
+	ctx := context.Background()
+	lockKey := "test:extend"
+
+	lock := NewDistributedLock(client, lockKey, 10*time.Second)
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatalf("加锁失败: %v", err)
+	}
+
+	mr.FastForward(8 * time.Second)
+	if err := lock.Extend(ctx); err != nil {
+		t.Fatalf("续期失败: %v", err)
+	}
+	if ttl := mr.TTL(lockKey); ttl != 10*time.Second {
+		t.Errorf("续期后TTL应为10s，实际为 %v", ttl)
+	}
+
+	// 锁被他人持有时续期失败
+	mr.Set(lockKey, "other")
+	if err := lock.Extend(ctx); err != ErrLockFailed {
+		t.Errorf("锁已不属于自己时续期应失败，实际: %v", err)
+	}
+}
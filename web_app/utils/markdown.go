@@ -0,0 +1,56 @@
+// Package utils 提供工具函数
+package utils
+
+import (
+	"bytes"
+	"regexp"
+	"sync"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// 内容输出格式
+const (
+	ContentFormatRaw  = "raw"  // 仅返回Markdown原文
+	ContentFormatHTML = "html" // 仅返回渲染后的HTML
+)
+
+var (
+	markdownOnce     sync.Once
+	markdownRenderer goldmark.Markdown
+	htmlPolicy       *bluemonday.Policy
+)
+
+// initMarkdown 初始化Markdown渲染器和HTML白名单策略
+func initMarkdown() {
+	// GFM扩展：表格、删除线、自动链接、任务列表
+	// 不开启html.WithUnsafe，原文中的HTML标签不会被原样输出
+	markdownRenderer = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(html.WithHardWraps()),
+	)
+
+	// 白名单策略：只保留常见排版标签，去除脚本、事件属性和危险协议
+	policy := bluemonday.UGCPolicy()
+	policy.RequireNoFollowOnLinks(true)
+	policy.AddTargetBlankToFullyQualifiedLinks(true)
+	policy.AllowAttrs("class").Matching(bluemonday.SpaceSeparatedTokens).OnElements("code")  // 代码块语言标识
+	policy.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input") // 任务列表复选框
+	policy.AllowAttrs("checked", "disabled").OnElements("input")
+	htmlPolicy = policy
+}
+
+// RenderMarkdown 将Markdown原文渲染为经过白名单过滤的安全HTML
+func RenderMarkdown(source string) string {
+	markdownOnce.Do(initMarkdown)
+
+	var buf bytes.Buffer
+	if err := markdownRenderer.Convert([]byte(source), &buf); err != nil {
+		// 渲染失败时退化为转义后的纯文本
+		return bluemonday.StrictPolicy().Sanitize(source)
+	}
+	return htmlPolicy.Sanitize(buf.String())
+}
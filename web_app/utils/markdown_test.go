@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRenderMarkdown_Basic 测试常见Markdown语法的渲染
+func TestRenderMarkdown_Basic(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		contains string
+	}{
+		{"标题", "# 标题", "<h1>标题</h1>"},
+		{"加粗", "**加粗**", "<strong>加粗</strong>"},
+		{"行内代码", "`go run`", "<code>go run</code>"},
+		{"删除线", "~~删除~~", "<del>删除</del>"},
+		{"代码块语言", "```go\nfmt.Println()\n```", `<code class="language-go">`},
+		{"表格", "| a | b |\n|---|---|\n| 1 | 2 |", "<table>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Contains(t, RenderMarkdown(tt.source), tt.contains)
+		})
+	}
+}
+
+// TestRenderMarkdown_Links 测试链接添加nofollow和target
+func TestRenderMarkdown_Links(t *testing.T) {
+	out := RenderMarkdown("[官网](https://example.com)")
+	assert.Contains(t, out, `href="https://example.com"`)
+	assert.Contains(t, out, `rel="nofollow noopener"`)
+	assert.Contains(t, out, `target="_blank"`)
+}
+
+// TestRenderMarkdown_XSS 测试各种XSS载荷都会被过滤
+func TestRenderMarkdown_XSS(t *testing.T) {
+	payloads := []string{
+		"<script>alert(1)</script>",
+		"<img src=x onerror=alert(1)>",
+		"[点我](javascript:alert(1))",
+		"![图](javascript:alert(1))",
+		"<a href=\"javascript:alert(1)\">x</a>",
+		"<iframe src=\"https://evil.com\"></iframe>",
+		"<div style=\"background:url(javascript:alert(1))\">x</div>",
+		"[x](data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==)",
+		"<svg onload=alert(1)>",
+	}
+
+	for _, payload := range payloads {
+		out := strings.ToLower(RenderMarkdown(payload))
+		assert.NotContains(t, out, "<script", "payload: %s", payload)
+		assert.NotContains(t, out, "javascript:", "payload: %s", payload)
+		assert.NotContains(t, out, "onerror", "payload: %s", payload)
+		assert.NotContains(t, out, "onload", "payload: %s", payload)
+		assert.NotContains(t, out, "<iframe", "payload: %s", payload)
+		assert.NotContains(t, out, "<svg", "payload: %s", payload)
+		assert.NotContains(t, out, "data:text/html", "payload: %s", payload)
+	}
+}
+
+// TestRenderMarkdown_TaskList 测试任务列表只保留复选框
+func TestRenderMarkdown_TaskList(t *testing.T) {
+	out := RenderMarkdown("- [x] 完成\n- [ ] 未完成")
+	assert.Contains(t, out, `type="checkbox"`)
+	assert.Contains(t, out, "checked")
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"web_app/models"
+)
+
+const (
+	// maxMentionNameLen 用户名最大长度（与注册校验保持一致）
+	maxMentionNameLen = 20
+	// MaxMentionsPerContent 单条内容最多解析的@人数，防止刷屏式@
+	MaxMentionsPerContent = 20
+)
+
+// isMentionNameRune 判断字符是否可以出现在被@的用户名中
+func isMentionNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-'
+}
+
+// ParseMentions 解析内容中的 @用户名
+// 同一用户名只返回一次（保持首次出现顺序）；代码块和行内代码中的 @ 会被忽略
+func ParseMentions(content string) []models.MentionSpan {
+	var spans []models.MentionSpan
+	names := make(map[string]bool)
+
+	inFence := false // 是否处于 ``` 代码块中
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		inCode := false // 是否处于行内代码中
+		prev := rune(-1)
+		for i := 0; i < len(line); {
+			r, size := utf8.DecodeRuneInString(line[i:])
+			if r == '`' {
+				inCode = !inCode
+			}
+			if r == '@' && !inCode && !isMentionBlockedBy(prev) {
+				name := scanMentionName(line[i+size:])
+				if n := utf8.RuneCountInString(name); n > 0 && n <= maxMentionNameLen {
+					if !names[name] {
+						if len(names) >= MaxMentionsPerContent {
+							return spans
+						}
+						names[name] = true
+						spans = append(spans, models.MentionSpan{Username: name})
+					}
+					i += size + len(name)
+					prev = 'a'
+					continue
+				}
+			}
+			prev = r
+			i += size
+		}
+	}
+	return spans
+}
+
+// isMentionBlockedBy 判断@前一个字符是否使其不构成提及
+// 紧跟ASCII字母数字（如邮箱 me@example.com）或连续@时不算；中文之间不加空格的 感谢@某人 仍然有效
+func isMentionBlockedBy(prev rune) bool {
+	if prev < 0 {
+		return false
+	}
+	return prev < utf8.RuneSelf && (isMentionNameRune(prev) || prev == '.' || prev == '@')
+}
+
+// scanMentionName 读取@后面的用户名（超过最大长度时视为无效）
+func scanMentionName(s string) string {
+	end := 0
+	for end < len(s) {
+		r, size := utf8.DecodeRuneInString(s[end:])
+		if !isMentionNameRune(r) {
+			break
+		}
+		end += size
+	}
+	return s[:end]
+}
+
+// MentionedUsernames 提取去重后的被@用户名（保持首次出现顺序）
+func MentionedUsernames(spans []models.MentionSpan) []string {
+	seen := make(map[string]bool, len(spans))
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		if !seen[span.Username] {
+			seen[span.Username] = true
+			names = append(names, span.Username)
+		}
+	}
+	return names
+}
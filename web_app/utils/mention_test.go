@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseMentions 测试@用户名的解析规则
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"单个", "你好 @alice", []string{"alice"}},
+		{"行首", "@bob 看一下", []string{"bob"}},
+		{"多个重复", "@alice @bob @alice", []string{"alice", "bob"}},
+		{"中文用户名", "感谢@技术极客，", []string{"技术极客"}},
+		{"标点结束", "(@alice)!", []string{"alice"}},
+		{"邮箱不算", "联系 me@example.com", nil},
+		{"连续@", "@@alice", nil},
+		{"单独@", "@ 空格", nil},
+		{"行内代码忽略", "运行 `@alice` 和 @bob", []string{"bob"}},
+		{"代码块忽略", "```\n@alice\n```\n@bob", []string{"bob"}},
+		{"超长用户名忽略", "@" + strings.Repeat("a", 21), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, span := range ParseMentions(tt.content) {
+				got = append(got, span.Username)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestParseMentions_Limit 测试单条内容最多解析的@人数
+func TestParseMentions_Limit(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < MaxMentionsPerContent+5; i++ {
+		fmt.Fprintf(&b, "@user%d ", i)
+	}
+	spans := ParseMentions(b.String())
+	assert.Len(t, MentionedUsernames(spans), MaxMentionsPerContent)
+}
+
+// TestMentionedUsernames 测试用户名去重并保持顺序
+func TestMentionedUsernames(t *testing.T) {
+	spans := ParseMentions("@bob @alice @bob")
+	assert.Equal(t, []string{"bob", "alice"}, MentionedUsernames(spans))
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSearchQueryLen 搜索词统计时保留的最大长度（字符）
+const maxSearchQueryLen = 50
+
+// NormalizeSearchQuery 规范化搜索词用于统计：合并连续空白、去除首尾空白、转小写，过长时截断
+func NormalizeSearchQuery(query string) string {
+	query = strings.ToLower(strings.Join(strings.Fields(query), " "))
+	if utf8.RuneCountInString(query) > maxSearchQueryLen {
+		query = strings.TrimSpace(string([]rune(query)[:maxSearchQueryLen]))
+	}
+	return query
+}
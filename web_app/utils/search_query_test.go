@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNormalizeSearchQuery 测试搜索词规范化
+func TestNormalizeSearchQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"空", "   ", ""},
+		{"转小写", "Golang", "golang"},
+		{"合并空白", "  go \t 并发\n编程 ", "go 并发 编程"},
+		{"截断", strings.Repeat("搜", 60), strings.Repeat("搜", 50)},
+		{"截断后去掉末尾空白", strings.Repeat("a", 49) + " b", strings.Repeat("a", 49)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeSearchQuery(tt.query))
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxTagsPerTopic 每个话题最多的标签数
+	MaxTagsPerTopic = 5
+	// maxTagLen 单个标签的最大长度（字符）
+	maxTagLen = 20
+)
+
+var (
+	ErrTooManyTags = errors.New("标签最多5个")
+	ErrInvalidTag  = errors.New("标签只能包含文字、数字、下划线、短横线和点号，且不超过20个字符")
+)
+
+// isTagRune 判断字符是否可以出现在标签中（逗号用于数据库存储分隔，不允许出现）
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.' || r == '+' || r == '#'
+}
+
+// NormalizeTags 规范化标签：去除首尾空白和前导#、转小写、去重，并校验长度和字符
+// 输入的单个元素中可以包含逗号分隔的多个标签（兼容 tags=go,redis 形式的查询参数）
+func NormalizeTags(raw []string) ([]string, error) {
+	tags := make([]string, 0, len(raw))
+	seen := make(map[string]bool)
+	for _, item := range raw {
+		for _, tag := range strings.Split(item, ",") {
+			tag = strings.ToLower(strings.TrimLeft(strings.TrimSpace(tag), "#"))
+			if tag == "" || seen[tag] {
+				continue
+			}
+			if utf8.RuneCountInString(tag) > maxTagLen || strings.IndexFunc(tag, func(r rune) bool { return !isTagRune(r) }) >= 0 {
+				return nil, ErrInvalidTag
+			}
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > MaxTagsPerTopic {
+		return nil, ErrTooManyTags
+	}
+	return tags, nil
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNormalizeTags 测试标签规范化和校验
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     []string
+		want    []string
+		wantErr error
+	}{
+		{"空", nil, []string{}, nil},
+		{"转小写去重", []string{"Go", "go", " Redis "}, []string{"go", "redis"}, nil},
+		{"去掉前导#", []string{"#golang"}, []string{"golang"}, nil},
+		{"逗号分隔", []string{"go,redis", "mysql"}, []string{"go", "redis", "mysql"}, nil},
+		{"中文和特殊字符", []string{"微服务", "c++", "c#", "node.js"}, []string{"微服务", "c++", "c#", "node.js"}, nil},
+		{"忽略空标签", []string{"go,,", " "}, []string{"go"}, nil},
+		{"超过数量", []string{"a,b,c,d,e,f"}, nil, ErrTooManyTags},
+		{"重复不计数", []string{"a,b,c,d,e,A"}, []string{"a", "b", "c", "d", "e"}, nil},
+		{"非法字符", []string{"go lang"}, nil, ErrInvalidTag},
+		{"超长", []string{strings.Repeat("标", 21)}, nil, ErrInvalidTag},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeTags(tt.raw)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Package utils 提供工具函数
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif" // 注册GIF解码器
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // 注册WebP解码器
+)
+
+// ErrImageTooLarge 图片像素尺寸超过限制
+var ErrImageTooLarge = errors.New("图片尺寸过大")
+
+// maxImagePixels 允许解码的最大像素数，防止解压炸弹占满内存
+const maxImagePixels = 40_000_000
+
+// ImageInfo 图片基本信息
+type ImageInfo struct {
+	Width  int    // 宽度（像素）
+	Height int    // 高度（像素）
+	Format string // 格式：jpeg/png/gif/webp
+}
+
+// DecodeImageInfo 只读取图片头部获取尺寸和格式，不解码像素
+func DecodeImageInfo(data []byte) (*ImageInfo, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+	return &ImageInfo{Width: cfg.Width, Height: cfg.Height, Format: format}, nil
+}
+
+// GenerateThumbnail 生成等比缩放的缩略图（长边不超过maxSize）
+// PNG/GIF 输出PNG以保留透明度，其余输出JPEG；返回缩略图数据和MIME类型
+func GenerateThumbnail(data []byte, maxSize int) ([]byte, string, error) {
+	info, err := DecodeImageInfo(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 计算缩放后尺寸（小图不放大）
+	width, height := info.Width, info.Height
+	if width > maxSize || height > maxSize {
+		if width >= height {
+			height = max(1, height*maxSize/width)
+			width = maxSize
+		} else {
+			width = max(1, width*maxSize/height)
+			height = maxSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+
+	var buf bytes.Buffer
+	if info.Format == "png" || info.Format == "gif" {
+		if err := png.Encode(&buf, dst); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
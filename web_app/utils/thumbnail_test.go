@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeTestImage 生成指定尺寸的测试图片
+func encodeTestImage(t *testing.T, width, height int, format string) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if format == "png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, nil)
+	}
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+// TestGenerateThumbnail 测试缩略图尺寸和输出格式
+func TestGenerateThumbnail(t *testing.T) {
+	tests := []struct {
+		name       string
+		width      int
+		height     int
+		format     string
+		wantWidth  int
+		wantHeight int
+		wantType   string
+	}{
+		{"横图JPEG", 800, 400, "jpeg", 320, 160, "image/jpeg"},
+		{"竖图PNG", 300, 600, "png", 160, 320, "image/png"},
+		{"小图不放大", 100, 50, "jpeg", 100, 50, "image/jpeg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := encodeTestImage(t, tt.width, tt.height, tt.format)
+
+			thumb, contentType, err := GenerateThumbnail(data, 320)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantType, contentType)
+
+			info, err := DecodeImageInfo(thumb)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantWidth, info.Width)
+			assert.Equal(t, tt.wantHeight, info.Height)
+		})
+	}
+}
+
+// TestGenerateThumbnail_Invalid 测试非图片数据
+func TestGenerateThumbnail_Invalid(t *testing.T) {
+	_, _, err := GenerateThumbnail([]byte("not an image"), 320)
+	assert.Error(t, err)
+}
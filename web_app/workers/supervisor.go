@@ -0,0 +1,223 @@
+// Package workers 管理后台任务的生命周期：按分组启动，异常退出或panic时退避重启，关机时按分组顺序取消并等待退出
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+	"web_app/models"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultRestartBackoff 首次重启的等待时间，连续失败时按指数增长
+	defaultRestartBackoff = time.Second
+	// defaultMaxRestartBackoff 最长重启等待时间
+	defaultMaxRestartBackoff = time.Minute
+	// stableRunDuration 运行超过该时间后再退出视为偶发异常，重启等待时间重新计算
+	stableRunDuration = time.Minute
+)
+
+// RunFunc 后台任务函数：阻塞运行直到ctx取消，返回error或panic时由监督者重启，ctx未取消时返回nil表示任务正常结束
+type RunFunc func(ctx context.Context) error
+
+// Supervisor 后台任务监督者
+type Supervisor struct {
+	mu                sync.Mutex
+	groups            []*Group
+	stopping          bool
+	restartBackoff    time.Duration
+	maxRestartBackoff time.Duration
+}
+
+// Group 一组一起关闭的后台任务
+type Group struct {
+	name    string
+	s       *Supervisor
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	workers []*worker
+}
+
+// worker 单个后台任务及其状态
+type worker struct {
+	name   string
+	run    RunFunc
+	status models.WorkerStatus
+}
+
+// NewSupervisor 创建监督者
+func NewSupervisor() *Supervisor {
+	return &Supervisor{
+		restartBackoff:    defaultRestartBackoff,
+		maxRestartBackoff: defaultMaxRestartBackoff,
+	}
+}
+
+// Group 创建分组，关机时按创建顺序依次关闭各分组
+func (s *Supervisor) Group(name string) *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	g := &Group{name: name, s: s, ctx: ctx, cancel: cancel}
+
+	s.mu.Lock()
+	s.groups = append(s.groups, g)
+	s.mu.Unlock()
+	return g
+}
+
+// Go 在分组中启动后台任务，任务异常重启不影响就绪状态
+func (g *Group) Go(name string, run RunFunc) {
+	g.start(name, run, false)
+}
+
+// GoCritical 在分组中启动关键后台任务，任务不在运行（等待重启）时服务未就绪
+func (g *Group) GoCritical(name string, run RunFunc) {
+	g.start(name, run, true)
+}
+
+// start 启动后台任务
+func (g *Group) start(name string, run RunFunc, critical bool) {
+	w := &worker{
+		name:   name,
+		run:    run,
+		status: models.WorkerStatus{Name: name, Group: g.name, Critical: critical},
+	}
+	g.mu.Lock()
+	g.workers = append(g.workers, w)
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		g.supervise(w)
+	}()
+}
+
+// supervise 运行任务，异常退出时退避重启，直到ctx取消或任务正常结束
+func (g *Group) supervise(w *worker) {
+	backoff := g.s.restartBackoff
+	for {
+		startedAt := time.Now()
+		g.setStatus(w, func(st *models.WorkerStatus) {
+			st.State = models.WorkerStateRunning
+			st.StartedAt = &startedAt
+		})
+
+		err := runSafely(g.ctx, w.run)
+		if g.ctx.Err() != nil || err == nil {
+			g.setStatus(w, func(st *models.WorkerStatus) { st.State = models.WorkerStateStopped })
+			zap.L().Info("后台任务已停止", zap.String("group", g.name), zap.String("worker", w.name))
+			return
+		}
+
+		if time.Since(startedAt) > stableRunDuration {
+			backoff = g.s.restartBackoff
+		}
+		g.setStatus(w, func(st *models.WorkerStatus) {
+			st.State = models.WorkerStateRestarting
+			st.Restarts++
+			st.LastError = err.Error()
+		})
+		zap.L().Error("后台任务异常退出，稍后重启",
+			zap.String("group", g.name),
+			zap.String("worker", w.name),
+			zap.Error(err),
+			zap.Duration("backoff", backoff))
+
+		select {
+		case <-time.After(backoff):
+		case <-g.ctx.Done():
+			g.setStatus(w, func(st *models.WorkerStatus) { st.State = models.WorkerStateStopped })
+			return
+		}
+		if backoff *= 2; backoff > g.s.maxRestartBackoff {
+			backoff = g.s.maxRestartBackoff
+		}
+	}
+}
+
+// runSafely 运行任务并把panic转换为error
+func runSafely(ctx context.Context, run RunFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			zap.L().Error("后台任务panic", zap.Any("error", r), zap.ByteString("stack", debug.Stack()))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return run(ctx)
+}
+
+// setStatus 更新任务状态
+func (g *Group) setStatus(w *worker, update func(st *models.WorkerStatus)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	update(&w.status)
+}
+
+// stop 取消分组内的任务并等待退出，ctx超时时不再等待
+func (g *Group) stop(ctx context.Context) error {
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		zap.L().Info("后台任务分组已关闭", zap.String("group", g.name))
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待后台任务分组 %s 关闭超时: %w", g.name, ctx.Err())
+	}
+}
+
+// Shutdown 按分组创建顺序依次关闭所有后台任务
+// 超时后仍会取消剩余的分组（不再等待退出），返回所有未能按时关闭的分组的错误
+func (s *Supervisor) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopping = true
+	groups := append([]*Group(nil), s.groups...)
+	s.mu.Unlock()
+
+	var errs []error
+	for _, g := range groups {
+		if err := g.stop(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Health 返回所有后台任务的状态，ready 表示未在关机且所有关键任务都在运行
+// 非关键任务（消费者、定时任务等）异常重启只体现在各任务的状态中，不影响就绪
+func (s *Supervisor) Health() (ready bool, statuses []*models.WorkerStatus) {
+	s.mu.Lock()
+	ready = !s.stopping
+	groups := append([]*Group(nil), s.groups...)
+	s.mu.Unlock()
+
+	statuses = make([]*models.WorkerStatus, 0)
+	for _, g := range groups {
+		g.mu.Lock()
+		for _, w := range g.workers {
+			st := w.status
+			statuses = append(statuses, &st)
+			if st.Critical && st.State != models.WorkerStateRunning {
+				ready = false
+			}
+		}
+		g.mu.Unlock()
+	}
+	return ready, statuses
+}
+
+// Default 应用的后台任务监督者
+var Default = NewSupervisor()
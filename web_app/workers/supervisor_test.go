@@ -0,0 +1,139 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"web_app/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSupervisor 创建重启等待时间很短的监督者
+func newTestSupervisor() *Supervisor {
+	s := NewSupervisor()
+	s.restartBackoff = time.Millisecond
+	s.maxRestartBackoff = 4 * time.Millisecond
+	return s
+}
+
+// blockUntilDone 运行到ctx取消的任务
+func blockUntilDone(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// TestSupervisor_RestartOnPanicAndError 测试任务panic或返回错误后重启
+func TestSupervisor_RestartOnPanicAndError(t *testing.T) {
+	s := newTestSupervisor()
+	var runs int32
+	s.Group("consumers").Go("flaky", func(ctx context.Context) error {
+		switch atomic.AddInt32(&runs, 1) {
+		case 1:
+			panic("boom")
+		case 2:
+			return errors.New("连接断开")
+		}
+		return blockUntilDone(ctx)
+	})
+
+	require.Eventually(t, func() bool {
+		ready, _ := s.Health()
+		return ready && atomic.LoadInt32(&runs) == 3
+	}, time.Second, time.Millisecond)
+
+	_, statuses := s.Health()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "flaky", statuses[0].Name)
+	assert.Equal(t, "consumers", statuses[0].Group)
+	assert.Equal(t, 2, statuses[0].Restarts)
+	assert.Equal(t, "连接断开", statuses[0].LastError)
+
+	require.NoError(t, s.Shutdown(context.Background()))
+	ready, statuses := s.Health()
+	assert.False(t, ready)
+	assert.Equal(t, models.WorkerStateStopped, statuses[0].State)
+}
+
+// TestSupervisor_ShutdownOrder 测试按分组创建顺序关闭，前一组退出后才关闭下一组
+func TestSupervisor_ShutdownOrder(t *testing.T) {
+	s := newTestSupervisor()
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) RunFunc {
+		return func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return nil
+		}
+	}
+	s.Group("consumers").Go("consumer", record("consumer"))
+	s.Group("tasks").Go("task", record("task"))
+
+	require.NoError(t, s.Shutdown(context.Background()))
+	assert.Equal(t, []string{"consumer", "task"}, order)
+}
+
+// TestSupervisor_ShutdownTimeout 测试超时后仍取消其余分组，并返回所有未按时关闭的分组的错误
+func TestSupervisor_ShutdownTimeout(t *testing.T) {
+	s := newTestSupervisor()
+	release := make(chan struct{})
+	defer close(release)
+	stuck := func(context.Context) error {
+		<-release
+		return nil
+	}
+	s.Group("consumers").Go("stuck-consumer", stuck)
+	s.Group("tasks").Go("stuck-task", stuck)
+	var cancelled atomic.Bool
+	s.Group("cleanup").Go("cleanup", func(ctx context.Context) error {
+		<-ctx.Done()
+		cancelled.Store(true)
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := s.Shutdown(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "consumers")
+	assert.Contains(t, err.Error(), "tasks")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Eventually(t, cancelled.Load, time.Second, time.Millisecond, "超时后仍取消后面的分组")
+}
+
+// TestSupervisor_ReadinessCountsCriticalOnly 测试只有关键任务等待重启时未就绪，其他任务的状态只在响应中体现
+func TestSupervisor_ReadinessCountsCriticalOnly(t *testing.T) {
+	s := NewSupervisor()
+	s.restartBackoff = time.Hour
+	broken := func(context.Context) error {
+		return errors.New("初始化失败")
+	}
+	s.Group("tasks").Go("broken-task", broken)
+
+	require.Eventually(t, func() bool {
+		_, statuses := s.Health()
+		return statuses[0].State == models.WorkerStateRestarting
+	}, time.Second, time.Millisecond)
+	ready, statuses := s.Health()
+	assert.True(t, ready, "非关键任务重启不影响就绪")
+	assert.False(t, statuses[0].Critical)
+
+	s.Group("events").GoCritical("broken-bus", broken)
+	require.Eventually(t, func() bool {
+		_, statuses := s.Health()
+		return len(statuses) == 2 && statuses[1].State == models.WorkerStateRestarting
+	}, time.Second, time.Millisecond)
+	ready, statuses = s.Health()
+	assert.False(t, ready, "关键任务重启时未就绪")
+	assert.True(t, statuses[1].Critical)
+
+	require.NoError(t, s.Shutdown(context.Background()))
+}